@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// client - клиент HTTP API календаря
+type client struct {
+	server string
+	token  string
+	userID int64
+	http   *http.Client
+}
+
+func newClient(cfg config) *client {
+	return &client{
+		server: strings.TrimRight(cfg.Server, "/"),
+		token:  cfg.Token,
+		userID: cfg.UserID,
+		http:   &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// apiError - ошибка, которую вернул сервер
+type apiError struct {
+	Status  int
+	Message string `json:"error"`
+	Fields  []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"fields"`
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("server responded %d: %s", e.Status, e.Message)
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("\n  %s: %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// get - GET запрос к API, возвращает поле result ответа
+func (c *client) get(ctx context.Context, path string, query url.Values) (json.RawMessage, error) {
+	return c.result(c.do(ctx, http.MethodGet, path, query, nil, ""))
+}
+
+// post - POST запрос с телом JSON, возвращает поле result ответа
+func (c *client) post(ctx context.Context, path string, body map[string]any) (json.RawMessage, error) {
+	if c.userID != 0 {
+		body["user_id"] = c.userID
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	return c.result(c.do(ctx, http.MethodPost, path, nil, bytes.NewReader(data), "application/json"))
+}
+
+// do - выполняет запрос с токеном и user_id в параметрах; ответ с ошибкой превращается в *apiError
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.userID != 0 {
+		query.Set("user_id", strconv.FormatInt(c.userID, 10))
+	}
+
+	u := c.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		apiErr := &apiError{Status: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// result - поле result ответа API
+func (c *client) result(resp *http.Response, err error) (json.RawMessage, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return body.Result, nil
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// errUsage - неверные аргументы команды (код выхода 2)
+var errUsage = errors.New("usage error")
+
+// env - окружение выполнения команды
+type env struct {
+	client *client
+	out    printer
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command - подкоманда calctl
+type command struct {
+	usage string
+	run   func(ctx context.Context, e env, args []string) error
+}
+
+var commands = map[string]command{
+	"create":  {"create -title T -date D [-end D] [-description S] [-remind 15m] [-rrule R] [-tz Z] [-tags a,b] [-calendar ID]", runCreate},
+	"update":  {"update -id ID -title T -date D [-end D] [-description S] [-remind 15m] [-rrule R] [-tz Z] [-tags a,b] [-recurrence D]", runUpdate},
+	"delete":  {"delete -id ID [-recurrence D] [-tz Z]", runDelete},
+	"restore": {"restore -id ID", runRestore},
+	"history": {"history -id ID", runHistory},
+	"day":     {"day [YYYY-MM-DD]", runPeriod("/events_for_day")},
+	"week":    {"week [YYYY-MM-DD]", runPeriod("/events_for_week")},
+	"month":   {"month [YYYY-MM-DD]", runPeriod("/events_for_month")},
+	"search":  {"search [-q TEXT] [-from D] [-to D] [-tags a,b] [-limit N] [-cursor C]", runSearch},
+	"import":  {"import FILE.ics (- - stdin)", runImport},
+	"export":  {"export [-o FILE.ics]", runExport},
+}
+
+// commandNames - порядок команд в справке
+var commandNames = []string{"create", "update", "delete", "restore", "history", "day", "week", "month", "search", "import", "export"}
+
+// eventFlags - поля события, общие для create и update
+type eventFlags struct {
+	title, date, end, description string
+	remind, rrule, tz, tags       string
+}
+
+func (f *eventFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.title, "title", "", "event title")
+	fs.StringVar(&f.date, "date", "", `start, e.g. "2026-01-02 15:04" or RFC 3339`)
+	fs.StringVar(&f.end, "end", "", "end; defaults to start")
+	fs.StringVar(&f.description, "description", "", "event description")
+	fs.StringVar(&f.remind, "remind", "", `remind before start, e.g. "15m"`)
+	fs.StringVar(&f.rrule, "rrule", "", `recurrence rule, e.g. "FREQ=WEEKLY;BYDAY=MO"`)
+	fs.StringVar(&f.tz, "tz", "", "IANA time zone; defaults to the user's zone")
+	fs.StringVar(&f.tags, "tags", "", "comma-separated tags")
+}
+
+// body - тело запроса только с заданными полями
+func (f *eventFlags) body() map[string]any {
+	body := map[string]any{}
+	set := func(key, v string) {
+		if v != "" {
+			body[key] = v
+		}
+	}
+	set("title", f.title)
+	set("date", f.date)
+	set("end", f.end)
+	set("description", f.description)
+	set("remind_before", f.remind)
+	set("rrule", f.rrule)
+	set("time_zone", f.tz)
+	set("tags", f.tags)
+	return body
+}
+
+func runCreate(ctx context.Context, e env, args []string) error {
+	var (
+		f        eventFlags
+		calendar int64
+	)
+	fs := newFlagSet("create", e.stderr)
+	f.register(fs)
+	fs.Int64Var(&calendar, "calendar", 0, "shared calendar ID")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	body := f.body()
+	if calendar != 0 {
+		body["calendar_id"] = calendar
+	}
+	res, err := e.client.post(ctx, "/create_event", body)
+	if err != nil {
+		return err
+	}
+	return e.out.event(res)
+}
+
+func runUpdate(ctx context.Context, e env, args []string) error {
+	var (
+		f          eventFlags
+		id         int64
+		recurrence string
+	)
+	fs := newFlagSet("update", e.stderr)
+	f.register(fs)
+	fs.Int64Var(&id, "id", 0, "event ID (required)")
+	fs.StringVar(&recurrence, "recurrence", "", "start of the occurrence to change")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if id <= 0 {
+		return fmt.Errorf("%w: -id is required", errUsage)
+	}
+
+	body := f.body()
+	body["event_id"] = id
+	if recurrence != "" {
+		body["recurrence_id"] = recurrence
+	}
+	res, err := e.client.post(ctx, "/update_event", body)
+	if err != nil {
+		return err
+	}
+	return e.out.event(res)
+}
+
+func runDelete(ctx context.Context, e env, args []string) error {
+	var (
+		id             int64
+		recurrence, tz string
+	)
+	fs := newFlagSet("delete", e.stderr)
+	fs.Int64Var(&id, "id", 0, "event ID (required)")
+	fs.StringVar(&recurrence, "recurrence", "", "start of the single occurrence to delete")
+	fs.StringVar(&tz, "tz", "", "time zone of -recurrence")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if id <= 0 {
+		return fmt.Errorf("%w: -id is required", errUsage)
+	}
+
+	body := map[string]any{"event_id": id}
+	if recurrence != "" {
+		body["recurrence_id"] = recurrence
+	}
+	if tz != "" {
+		body["time_zone"] = tz
+	}
+	res, err := e.client.post(ctx, "/delete_event", body)
+	if err != nil {
+		return err
+	}
+	return e.out.message(res)
+}
+
+func runRestore(ctx context.Context, e env, args []string) error {
+	id, err := parseEventIDFlag("restore", e, args)
+	if err != nil {
+		return err
+	}
+
+	res, err := e.client.post(ctx, "/restore_event", map[string]any{"event_id": id})
+	if err != nil {
+		return err
+	}
+	return e.out.event(res)
+}
+
+func runHistory(ctx context.Context, e env, args []string) error {
+	id, err := parseEventIDFlag("history", e, args)
+	if err != nil {
+		return err
+	}
+
+	res, err := e.client.get(ctx, fmt.Sprintf("/events/%d/history", id), url.Values{})
+	if err != nil {
+		return err
+	}
+	return e.out.history(res)
+}
+
+// parseEventIDFlag - разбирает единственный обязательный флаг -id
+func parseEventIDFlag(name string, e env, args []string) (int64, error) {
+	var id int64
+	fs := newFlagSet(name, e.stderr)
+	fs.Int64Var(&id, "id", 0, "event ID (required)")
+	if err := parseFlags(fs, args); err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("%w: -id is required", errUsage)
+	}
+	return id, nil
+}
+
+// runPeriod - выборка событий за день, неделю или месяц; по умолчанию - текущий
+func runPeriod(path string) func(ctx context.Context, e env, args []string) error {
+	return func(ctx context.Context, e env, args []string) error {
+		date := time.Now().Format(time.DateOnly)
+		switch len(args) {
+		case 0:
+		case 1:
+			if _, err := time.Parse(time.DateOnly, args[0]); err != nil {
+				return fmt.Errorf("%w: date must be YYYY-MM-DD", errUsage)
+			}
+			date = args[0]
+		default:
+			return fmt.Errorf("%w: too many arguments", errUsage)
+		}
+
+		res, err := e.client.get(ctx, path, url.Values{"date": {date}})
+		if err != nil {
+			return err
+		}
+		return e.out.events(res)
+	}
+}
+
+func runSearch(ctx context.Context, e env, args []string) error {
+	query := url.Values{}
+	fs := newFlagSet("search", e.stderr)
+	for _, name := range []string{"q", "from", "to", "tags", "limit", "cursor"} {
+		fs.Func(name, "search "+name, func(v string) error {
+			query.Set(name, v)
+			return nil
+		})
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	res, err := e.client.get(ctx, "/events/search", query)
+	if err != nil {
+		return err
+	}
+	return e.out.searchPage(res)
+}
+
+func runImport(ctx context.Context, e env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: import takes one file", errUsage)
+	}
+
+	src := e.stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = f
+	}
+
+	res, err := e.client.result(e.client.do(ctx, http.MethodPost, "/import", nil, src, "text/calendar"))
+	if err != nil {
+		return err
+	}
+	return e.out.imported(res)
+}
+
+func runExport(ctx context.Context, e env, args []string) error {
+	var path string
+	fs := newFlagSet("export", e.stderr)
+	fs.StringVar(&path, "o", "", "output file; defaults to stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	resp, err := e.client.do(ctx, http.MethodGet, "/export.ics", nil, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if path == "" {
+		_, err = io.Copy(e.stdout, resp.Body)
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("calctl "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// parseFlags - разбирает флаги команды; позиционные аргументы не допускаются
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix - префикс переменных окружения клиента
+const envPrefix = "CALCTL_"
+
+// config - настройки клиента: файл, переменные окружения CALCTL_* и флаги (по возрастанию приоритета)
+type config struct {
+	// адрес сервера календаря, например http://localhost:8000
+	Server string `yaml:"server"`
+	// JWT или API ключ; пусто - сервер без аутентификации
+	Token string `yaml:"token"`
+	// пользователь для сервера без аутентификации (0 - не передавать)
+	UserID int64 `yaml:"user_id"`
+	// table или json
+	Output  string        `yaml:"output"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func defaultConfig() config {
+	return config{
+		Server:  "http://localhost:8000",
+		Output:  outputTable,
+		Timeout: 30 * time.Second,
+	}
+}
+
+// defaultConfigPath - ~/.config/calctl/config.yaml (или аналог для ОС)
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "calctl", "config.yaml")
+}
+
+// loadConfig - читает файл path; если путь не задан явно (explicit=false), отсутствие файла не ошибка
+func loadConfig(path string, explicit bool) (config, error) {
+	cfg := defaultConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		case err != nil:
+			return cfg, fmt.Errorf("read config: %w", err)
+		default:
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("decode config %s: %w", path, err)
+			}
+		}
+	}
+
+	if v, ok := os.LookupEnv(envPrefix + "SERVER"); ok {
+		cfg.Server = v
+	}
+	if v, ok := os.LookupEnv(envPrefix + "TOKEN"); ok {
+		cfg.Token = v
+	}
+	if v, ok := os.LookupEnv(envPrefix + "USER_ID"); ok {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("%sUSER_ID: %w", envPrefix, err)
+		}
+		cfg.UserID = id
+	}
+	if v, ok := os.LookupEnv(envPrefix + "OUTPUT"); ok {
+		cfg.Output = v
+	}
+
+	return cfg, nil
+}
+
+// validate - проверяет итоговые настройки
+func (c config) validate() error {
+	if c.Server == "" {
+		return errors.New("server url is required")
+	}
+	switch c.Output {
+	case outputTable, outputJSON:
+	default:
+		return fmt.Errorf("unknown output %q: want table or json", c.Output)
+	}
+	if c.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	_ "time/tzdata"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run - разбирает глобальные флаги и выполняет подкоманду, возвращает код выхода
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("calctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { usage(fs) }
+
+	configPath := fs.String("config", "", "config file (default $CALCTL_CONFIG or "+defaultConfigPath()+")")
+	server := fs.String("server", "", "calendar server URL")
+	token := fs.String("token", "", "JWT or API key")
+	userID := fs.Int64("user", 0, "user ID for servers without authentication")
+	output := fs.String("output", "", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() == 0 {
+		usage(fs)
+		return 2
+	}
+
+	path, explicit := *configPath, *configPath != ""
+	if !explicit {
+		path, explicit = os.LookupEnv(envPrefix + "CONFIG")
+		if !explicit {
+			path = defaultConfigPath()
+		}
+	}
+	cfg, err := loadConfig(path, explicit)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "server":
+			cfg.Server = *server
+		case "token":
+			cfg.Token = *token
+		case "user":
+			cfg.UserID = *userID
+		case "output":
+			cfg.Output = *output
+		}
+	})
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		usage(fs)
+		return 2
+	}
+
+	e := env{
+		client: newClient(cfg),
+		out:    printer{w: stdout, format: cfg.Output},
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	err = cmd.run(ctx, e, fs.Args()[1:])
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(stderr, err)
+		fmt.Fprintln(stderr, "usage: calctl [flags] "+cmd.usage)
+		return 2
+	default:
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "usage: calctl [flags] <command> [args]")
+	fmt.Fprintln(out, "\ncommands:")
+	for _, name := range commandNames {
+		fmt.Fprintln(out, "  "+commands[name].usage)
+	}
+	fmt.Fprintln(out, "\nflags:")
+	fs.PrintDefaults()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testEvent = `{"id":7,"user_id":1,"title":"Standup","start":"2026-01-05T07:00:00Z","end":"2026-01-05T07:15:00Z","time_zone":"Europe/Moscow","rrule":"FREQ=DAILY"}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /create_event", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, map[string]any{"title": "Standup", "date": "2026-01-05 10:00", "time_zone": "Europe/Moscow"}, body)
+		io.WriteString(w, `{"result":`+testEvent+`}`)
+	})
+	mux.HandleFunc("GET /events_for_day", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "2026-01-05", r.URL.Query().Get("date"))
+		io.WriteString(w, `{"result":[`+testEvent+`]}`)
+	})
+	mux.HandleFunc("GET /events/search", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "standup", r.URL.Query().Get("q"))
+		require.Equal(t, "work,daily", r.URL.Query().Get("tags"))
+		io.WriteString(w, `{"result":{"events":[`+testEvent+`],"next_cursor":"abc"}}`)
+	})
+	mux.HandleFunc("POST /delete_event", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, `{"error":"event not found"}`)
+	})
+	mux.HandleFunc("GET /events/7/history", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"result":[{"id":1,"event_id":7,"actor_id":1,"action":"created","at":"2026-01-04T12:00:00Z","after":`+testEvent+`},`+
+			`{"id":2,"event_id":7,"actor_id":2,"action":"deleted","at":"2026-01-04T13:00:00Z","before":`+testEvent+`}]}`)
+	})
+	mux.HandleFunc("POST /import", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "3", r.URL.Query().Get("user_id"))
+		require.Equal(t, "text/calendar", r.Header.Get("Content-Type"))
+		data, _ := io.ReadAll(r.Body)
+		require.Equal(t, "BEGIN:VCALENDAR", string(data))
+		io.WriteString(w, `{"result":{"created":2,"updated":1,"skipped":[{"uid":"mars@example.com","reason":"unknown TZID"}]}}`)
+	})
+	mux.HandleFunc("GET /export.ics", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// cleanEnv - убирает переменные CALCTL_* и файл настроек по умолчанию
+func cleanEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{"CONFIG", "SERVER", "TOKEN", "USER_ID", "OUTPUT"} {
+		t.Setenv(envPrefix+name, "")
+		require.NoError(t, os.Unsetenv(envPrefix+name))
+	}
+}
+
+func runCmd(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	cleanEnv(t)
+	srv := newTestServer(t)
+
+	t.Run("create table", func(t *testing.T) {
+		code, out, errOut := runCmd(t, "", "-server", srv.URL, "-token", "secret",
+			"create", "-title", "Standup", "-date", "2026-01-05 10:00", "-tz", "Europe/Moscow")
+		require.Equal(t, 0, code, errOut)
+		require.Contains(t, out, "ID  START")
+		// время показывается в зоне события
+		require.Contains(t, out, "7   2026-01-05 10:00  2026-01-05 10:15  Europe/Moscow  Standup  FREQ=DAILY")
+	})
+
+	t.Run("day json", func(t *testing.T) {
+		code, out, errOut := runCmd(t, "", "-server", srv.URL, "-output", "json", "day", "2026-01-05")
+		require.Equal(t, 0, code, errOut)
+		var events []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out), &events))
+		require.Len(t, events, 1)
+		require.Equal(t, "Standup", events[0]["title"])
+	})
+
+	t.Run("search", func(t *testing.T) {
+		code, out, errOut := runCmd(t, "", "-server", srv.URL, "search", "-q", "standup", "-tags", "work,daily")
+		require.Equal(t, 0, code, errOut)
+		require.Contains(t, out, "Standup")
+		require.Contains(t, out, "next page: -cursor abc")
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		code, _, errOut := runCmd(t, "", "-server", srv.URL, "delete", "-id", "7")
+		require.Equal(t, 1, code)
+		require.Contains(t, errOut, "server responded 503: event not found")
+	})
+
+	t.Run("history", func(t *testing.T) {
+		code, out, errOut := runCmd(t, "", "-server", srv.URL, "history", "-id", "7")
+		require.Equal(t, 0, code, errOut)
+		require.Contains(t, out, "AT")
+		require.Regexp(t, `created\s+1\s+Standup`, out)
+		require.Regexp(t, `deleted\s+2\s+Standup`, out)
+	})
+
+	t.Run("usage error", func(t *testing.T) {
+		code, _, errOut := runCmd(t, "", "-server", srv.URL, "delete")
+		require.Equal(t, 2, code)
+		require.Contains(t, errOut, "-id is required")
+
+		code, _, _ = runCmd(t, "", "-server", srv.URL, "unknown")
+		require.Equal(t, 2, code)
+	})
+
+	t.Run("import stdin", func(t *testing.T) {
+		code, out, errOut := runCmd(t, "BEGIN:VCALENDAR", "-server", srv.URL, "-user", "3", "import", "-")
+		require.Equal(t, 0, code, errOut)
+		require.Equal(t, "created: 2, updated: 1\nskipped mars@example.com: unknown TZID\n", out)
+	})
+
+	t.Run("export file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "out.ics")
+		code, _, errOut := runCmd(t, "", "-server", srv.URL, "export", "-o", path)
+		require.Equal(t, 0, code, errOut)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", string(data))
+	})
+}
+
+func TestConfigFile(t *testing.T) {
+	srv := newTestServer(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server: "+srv.URL+"\ntoken: secret\noutput: json\n"), 0o600))
+	cleanEnv(t)
+
+	// сервер и токен из файла, формат вывода - из флага
+	code, out, errOut := runCmd(t, "", "-config", path, "-output", "table",
+		"create", "-title", "Standup", "-date", "2026-01-05 10:00", "-tz", "Europe/Moscow")
+	require.Equal(t, 0, code, errOut)
+	require.Contains(t, out, "Standup")
+
+	code, _, errOut = runCmd(t, "", "-config", filepath.Join(t.TempDir(), "missing.yaml"), "day")
+	require.Equal(t, 2, code)
+	require.Contains(t, errOut, "read config")
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"Calendar/internal/domain"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// timeLayout - формат времени в таблице
+const timeLayout = "2006-01-02 15:04"
+
+// printer - вывод результатов команд в выбранном формате
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// raw - в режиме json печатает результат как есть, с отступами
+func (p printer) raw(result json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, result, "", "  "); err != nil {
+		return fmt.Errorf("format result: %w", err)
+	}
+	buf.WriteByte('\n')
+	_, err := p.w.Write(buf.Bytes())
+	return err
+}
+
+// message - результат вида "event deleted"
+func (p printer) message(result json.RawMessage) error {
+	if p.format == outputJSON {
+		return p.raw(result)
+	}
+	var msg string
+	if err := json.Unmarshal(result, &msg); err != nil {
+		return fmt.Errorf("decode result: %w", err)
+	}
+	_, err := fmt.Fprintln(p.w, msg)
+	return err
+}
+
+// events - список событий; время показывается в зоне события
+func (p printer) events(result json.RawMessage) error {
+	if p.format == outputJSON {
+		return p.raw(result)
+	}
+	var events []domain.Event
+	if err := json.Unmarshal(result, &events); err != nil {
+		return fmt.Errorf("decode events: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTART\tEND\tZONE\tTITLE\tRRULE\tTAGS")
+	for _, e := range events {
+		loc := eventLocation(e)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.ID, e.Start.In(loc).Format(timeLayout), e.End.In(loc).Format(timeLayout),
+			loc, e.Title, e.RRule, strings.Join(e.Tags, ","))
+	}
+	return tw.Flush()
+}
+
+// searchPage - страница поиска; курсор следующей страницы печатается после таблицы
+func (p printer) searchPage(result json.RawMessage) error {
+	if p.format == outputJSON {
+		return p.raw(result)
+	}
+	var page struct {
+		Events     json.RawMessage `json:"events"`
+		NextCursor string          `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(result, &page); err != nil {
+		return fmt.Errorf("decode result: %w", err)
+	}
+	if err := p.events(page.Events); err != nil {
+		return err
+	}
+	if page.NextCursor != "" {
+		_, err := fmt.Fprintf(p.w, "\nnext page: -cursor %s\n", page.NextCursor)
+		return err
+	}
+	return nil
+}
+
+// history - журнал изменений события; название - из состояния после изменения, а для удаления - до
+func (p printer) history(result json.RawMessage) error {
+	if p.format == outputJSON {
+		return p.raw(result)
+	}
+	var entries []domain.AuditEntry
+	if err := json.Unmarshal(result, &entries); err != nil {
+		return fmt.Errorf("decode history: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AT\tACTION\tACTOR\tTITLE")
+	for _, a := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n",
+			a.At.Local().Format(timeLayout), a.Action, a.ActorID, a.Snapshot().Title)
+	}
+	return tw.Flush()
+}
+
+// imported - итог импорта
+func (p printer) imported(result json.RawMessage) error {
+	if p.format == outputJSON {
+		return p.raw(result)
+	}
+	var res domain.ImportResult
+	if err := json.Unmarshal(result, &res); err != nil {
+		return fmt.Errorf("decode result: %w", err)
+	}
+	if _, err := fmt.Fprintf(p.w, "created: %d, updated: %d\n", res.Created, res.Updated); err != nil {
+		return err
+	}
+	for _, sk := range res.Skipped {
+		if _, err := fmt.Fprintf(p.w, "skipped %s: %s\n", sk.UID, sk.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eventLocation(e domain.Event) *time.Location {
+	if e.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// event - одно событие (результат создания и изменения)
+func (p printer) event(result json.RawMessage) error {
+	if p.format == outputJSON {
+		return p.raw(result)
+	}
+	return p.events(json.RawMessage("[" + string(result) + "]"))
+}
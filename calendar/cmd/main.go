@@ -2,20 +2,258 @@ package main
 
 import (
 	"Calendar/internal/app"
+	"Calendar/internal/auth"
+	"Calendar/internal/config"
+	"Calendar/internal/handler"
+	"Calendar/internal/middleware"
+	"Calendar/internal/reminder"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/file"
+	"Calendar/internal/storage/memory"
+	"Calendar/internal/storage/migrate"
+	"Calendar/internal/storage/postgres"
 	"Calendar/pkg/logger"
 	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata"
+
+	_ "github.com/lib/pq"
 )
 
 func main() {
-	log := logger.New("env")
-	app := app.New(log, "0.0.0.0", "8000")
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	level, _ := cfg.Level()
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(level)
+
+	var logOut io.Writer
+	if cfg.Log.File != "" {
+		f, err := logger.OpenFile(cfg.Log.File, cfg.Log.MaxBytes, cfg.Log.MaxBackups)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		logOut = f
+	}
+	log := logger.New(cfg.Env, logLevel, logOut)
+
+	repo, closer, err := openStorage(cfg.Storage)
+	if err != nil {
+		log.Error("failed to open storage", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+
+	calendar := service.New(repo)
+	calendar.SetRetention(cfg.Storage.Retention)
+	events := handler.NewEvents(calendar, log)
+	icalendar := handler.NewICal(calendar, log)
+	users := handler.NewUsers(calendar, log)
+	calendars := handler.NewCalendars(calendar, log)
+	freeBusy := handler.NewFreeBusy(calendar, log)
+
+	appCfg := app.Config{
+		Host:            cfg.HTTP.Host,
+		Port:            cfg.HTTP.Port,
+		ShutdownTimeout: cfg.HTTP.ShutdownTimeout,
+		ReadTimeout:     cfg.HTTP.ReadTimeout,
+		WriteTimeout:    cfg.HTTP.WriteTimeout,
+		IdleTimeout:     cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes:  cfg.HTTP.MaxHeaderBytes,
+		MaxBodyBytes:    cfg.HTTP.MaxBodyBytes,
+		Public:          []app.Handler{handler.NewOpenAPI()},
+		LogLevel:        logLevel,
+		AdminToken:      cfg.Log.AdminToken,
+	}
+	// хранилища с внешним ресурсом (файл, БД) проверяются в /readyz
+	if p, ok := repo.(pinger); ok {
+		appCfg.Ready = p.Ping
+	}
+	if cfg.Auth.Enabled {
+		authn := auth.NewAuthenticator([]byte(cfg.Auth.JWTSecret), repo)
+		appCfg.Auth = middleware.Auth(authn, log)
+	}
+
+	if cfg.RPC.Enabled {
+		appCfg.RPC = handler.NewRPC(calendar, log)
+		appCfg.RPCPort = cfg.RPC.Port
+	}
+	if cfg.RateLimit.Enabled {
+		appCfg.IPRateLimit = middleware.RateLimitIP(cfg.RateLimit.IPRPS, cfg.RateLimit.IPBurst)
+		appCfg.RateLimit = middleware.RateLimit(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+	}
+	if cfg.Idempotency.Enabled {
+		appCfg.Idempotency = middleware.Idempotency(middleware.NewIdempotencyStore(cfg.Idempotency.TTL))
+	}
+
+	app := app.New(log, appCfg, events, icalendar, users, calendars, freeBusy)
+	app.OnShutdown(calendar.CloseSubscriptions)
+	if closer != nil {
+		app.OnStop(closer)
+	}
+	// очистка останавливается раньше закрытия хранилища
+	app.OnStop(startPurge(calendar, log))
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if cfg.Reminders.Enabled {
+		scheduler, sent, err := newScheduler(cfg.Reminders, repo, log)
+		if err != nil {
+			log.Error("failed to init reminders", slog.String("err", err.Error()))
+			if closer != nil {
+				closer.Close()
+			}
+			os.Exit(1)
+		}
+		if sent != nil {
+			app.OnStop(sent)
+		}
+		// планировщик останавливается первым, до закрытия хранилищ
+		scheduler.Start()
+		app.OnStop(scheduler)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	app.Start()
-	<-ctx.Done()
-	app.Stop()
+	if err := app.Run(ctx); err != nil {
+		log.Error("server failed", slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+}
+
+// pinger - хранилище, доступность которого можно проверить
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// openStorage - открывает выбранное хранилище событий
+func openStorage(cfg config.Storage) (service.Repository, io.Closer, error) {
+	switch cfg.Backend {
+	case "memory":
+		return memory.New(), nil, nil
+	case "file":
+		s, err := file.Open(cfg.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s, nil
+	case "postgres":
+		db, err := sql.Open("postgres", cfg.DSN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open db: %w", err)
+		}
+
+		ctx := context.Background()
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("ping db: %w", err)
+		}
+
+		migrator, err := migrate.New(db, postgres.Migrations())
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("migrate: %w", err)
+		}
+
+		return postgres.New(db), db, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// purgeInterval - период окончательного удаления событий с истекшим сроком восстановления
+const purgeInterval = time.Hour
+
+// startPurge - запускает периодическое окончательное удаление событий из корзины
+func startPurge(svc *service.Calendar, log *slog.Logger) io.Closer {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+
+		for {
+			n, err := svc.PurgeDeleted(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Error("failed to purge deleted events", slog.String("err", err.Error()))
+			} else if n > 0 {
+				log.Info("purged deleted events", slog.Int("count", n))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return closerFunc(func() error {
+		cancel()
+		<-done
+		return nil
+	})
+}
+
+// closerFunc - функция как io.Closer
+type closerFunc func() error
+
+// Close - вызывает функцию
+func (f closerFunc) Close() error { return f() }
+
+// newScheduler - создает планировщик напоминаний с выбранным способом доставки
+func newScheduler(cfg config.Reminders, src reminder.Source, log *slog.Logger) (*reminder.Scheduler, io.Closer, error) {
+	var notifier reminder.Notifier
+	switch cfg.Notifier {
+	case "stdout":
+		notifier = reminder.NewWriterNotifier(os.Stdout)
+	case "webhook":
+		notifier = reminder.NewWebhookNotifier(cfg.WebhookURL, 5*time.Second)
+	default:
+		notifier = reminder.NewLogNotifier(log)
+	}
+
+	// отправленные напоминания учитываются там же, где события, чтобы не повторять их после перезапуска
+	var (
+		sent   reminder.SentStore
+		closer io.Closer
+	)
+	repo, inRepo := src.(reminder.SentRepository)
+	switch {
+	case cfg.SentPath != "":
+		fileSent, err := reminder.OpenFileSent(cfg.SentPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		sent, closer = fileSent, fileSent
+	case inRepo:
+		sent = reminder.NewRepoSent(repo)
+	default:
+		sent = reminder.NewMemorySent()
+	}
+
+	return reminder.NewScheduler(src, sent, notifier, log, cfg.Interval, cfg.Lookback), closer, nil
 }
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// adminOnly - пропускает только запросы с токеном администратора (Authorization: Bearer <токен>)
+func (a *App) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(a.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="calendar-admin"`)
+			writeStatus(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getLogLevel - текущий уровень логирования
+func (a *App) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, a.logLevel.Level().String())
+}
+
+// setLogLevel - меняет уровень логирования без перезапуска: {"level": "debug"}
+func (a *App) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeStatus(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(body.Level))); err != nil {
+		writeStatus(w, http.StatusBadRequest, "invalid log level")
+		return
+	}
+
+	old := a.logLevel.Level()
+	a.logLevel.Set(level)
+	a.log.WarnContext(r.Context(), "log level changed", slog.String("from", old.String()), slog.String("to", level.String()))
+	writeStatus(w, http.StatusOK, level.String())
+}
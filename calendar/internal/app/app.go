@@ -1,59 +1,315 @@
 package app
 
 import (
+	"Calendar/internal/metrics"
+	"Calendar/internal/middleware"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	chimw "github.com/go-chi/chi/v5/middleware"
 )
 
 type App struct {
-	srv *http.Server
-	log *slog.Logger
+	servers []*server
+	// защищает server.ln: Addr читается из других горутин, пока идет Start
+	listenersMu sync.RWMutex
+
+	log             *slog.Logger
+	shutdownTimeout time.Duration
+	closers         []io.Closer
+	onShutdown      []func()
+
+	serveErr chan error
+
+	ready    func(ctx context.Context) error
+	stopping atomic.Bool
+	metrics  *metrics.HTTP
+
+	logLevel   *slog.LevelVar
+	adminToken string
+}
+
+// server - HTTP сервер приложения и его listener
+type server struct {
+	name string
+	srv  *http.Server
+	ln   net.Listener
+}
+
+// Config - настройки HTTP сервера приложения
+type Config struct {
+	Host            string
+	Port            string
+	ShutdownTimeout time.Duration
+	// таймауты http.Server (0 - без таймаута)
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// 0 - http.DefaultMaxHeaderBytes
+	MaxHeaderBytes int
+	// MaxBodyBytes - максимальный размер тела запроса (0 - без ограничения)
+	MaxBodyBytes int64
+	// Auth - middleware аутентификации обработчиков (nil - без аутентификации)
+	Auth func(http.Handler) http.Handler
+	// IPRateLimit - ограничение частоты запросов с одного IP; ставится до Auth и на публичные маршруты,
+	// чтобы ограничивать и запросы с неверными учетными данными (nil - без ограничения)
+	IPRateLimit func(http.Handler) http.Handler
+	// RateLimit - ограничение частоты запросов; ставится после Auth, чтобы различать пользователей (nil - без ограничения)
+	RateLimit func(http.Handler) http.Handler
+	// Idempotency - повтор запросов с ключом идемпотентности; ставится после Auth (nil - без повторов)
+	Idempotency func(http.Handler) http.Handler
+	// Public - обработчики, доступные без аутентификации (например, описание API)
+	Public []Handler
+	// Ready - проверка доступности хранилища для /readyz (nil - хранилище всегда доступно)
+	Ready func(ctx context.Context) error
+	// LogLevel - уровень логгера, который меняется через /admin/log_level;
+	// маршрут доступен только с токеном AdminToken (nil или пустой токен - маршрута нет)
+	LogLevel   *slog.LevelVar
+	AdminToken string
+
+	// RPC - обработчик второго listener'а (JSON-RPC) на порту RPCPort того же хоста;
+	// nil - второй listener не запускается. Аутентификация и ограничения те же.
+	RPC     Handler
+	RPCPort string
 }
 
 type Handler interface {
 	Init(r chi.Router)
 }
 
-func New(log *slog.Logger, host, port string, handlers ...Handler) *App {
+func New(log *slog.Logger, cfg Config, handlers ...Handler) *App {
+	a := &App{
+		log:             log,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		ready:           cfg.Ready,
+		metrics:         metrics.NewHTTP(nil),
+		logLevel:        cfg.LogLevel,
+		adminToken:      cfg.AdminToken,
+	}
+
+	router := a.router(cfg)
+	// служебные маршруты доступны без аутентификации
+	router.Get("/healthz", a.healthz)
+	router.Get("/readyz", a.readyz)
+	router.Get("/metrics", a.metricsHandler)
+	router.Group(func(r chi.Router) {
+		if cfg.IPRateLimit != nil {
+			r.Use(cfg.IPRateLimit)
+		}
+		if a.logLevel != nil && a.adminToken != "" {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(a.adminOnly)
+				r.Get("/log_level", a.getLogLevel)
+				r.Put("/log_level", a.setLogLevel)
+			})
+		}
+		for _, h := range cfg.Public {
+			h.Init(r)
+		}
+	})
+	router.Group(protected(cfg, handlers...))
+	a.servers = append(a.servers, newServer("http", router, cfg, cfg.Port))
+
+	if cfg.RPC != nil {
+		rpc := a.router(cfg)
+		rpc.Group(protected(cfg, cfg.RPC))
+		a.servers = append(a.servers, newServer("rpc", rpc, cfg, cfg.RPCPort))
+	}
+
+	return a
+}
+
+// router - маршрутизатор с общими для всех listener'ов middleware
+func (a *App) router(cfg Config) *chi.Mux {
 	router := chi.NewRouter()
-	router.Use(middleware.Logger)
+	router.Use(chimw.RequestID)
+	router.Use(middleware.LogContext)
+	router.Use(middleware.Metrics(a.metrics))
+	router.Use(middleware.Logger(a.log))
+	router.Use(middleware.Recoverer(a.log))
+	if cfg.MaxBodyBytes > 0 {
+		router.Use(middleware.MaxBodySize(cfg.MaxBodyBytes))
+	}
+	return router
+}
 
-	for _, h := range handlers {
-		h.Init(router)
+// protected - группа маршрутов за аутентификацией, ограничением частоты запросов и повтором по ключу идемпотентности
+func protected(cfg Config, handlers ...Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		if cfg.IPRateLimit != nil {
+			r.Use(cfg.IPRateLimit)
+		}
+		if cfg.Auth != nil {
+			r.Use(cfg.Auth)
+		}
+		if cfg.RateLimit != nil {
+			r.Use(cfg.RateLimit)
+		}
+		if cfg.Idempotency != nil {
+			r.Use(cfg.Idempotency)
+		}
+		for _, h := range handlers {
+			h.Init(r)
+		}
 	}
+}
 
-	srv := &http.Server{
-		Handler: router,
-		Addr:    net.JoinHostPort(host, port),
+func newServer(name string, h http.Handler, cfg Config, port string) *server {
+	return &server{
+		name: name,
+		srv: &http.Server{
+			Handler:        h,
+			Addr:           net.JoinHostPort(cfg.Host, port),
+			ReadTimeout:    cfg.ReadTimeout,
+			WriteTimeout:   cfg.WriteTimeout,
+			IdleTimeout:    cfg.IdleTimeout,
+			MaxHeaderBytes: cfg.MaxHeaderBytes,
+		},
 	}
+}
 
-	return &App{srv: srv, log: log}
+// OnStop - регистрирует ресурсы слоя данных (хранилища, соединения с БД),
+// которые закрываются после остановки сервера в обратном порядке
+func (a *App) OnStop(closers ...io.Closer) {
+	a.closers = append(a.closers, closers...)
 }
 
-func (a *App) Start() {
+// OnShutdown - регистрирует функции, вызываемые в начале остановки сервера,
+// например закрытие долгих потоков, которые иначе задержат ее до таймаута
+func (a *App) OnShutdown(fns ...func()) {
+	a.onShutdown = append(a.onShutdown, fns...)
+}
+
+// Start - синхронно открывает listener'ы и запускает обслуживание запросов в фоне.
+// Ошибка занятого порта и т.п. возвращается сразу.
+func (a *App) Start() error {
+	if err := a.listen(); err != nil {
+		return err
+	}
+
+	a.serveErr = make(chan error, len(a.servers))
+	var wg sync.WaitGroup
+	for _, s := range a.servers {
+		a.log.Info("starting server", slog.String("name", s.name), slog.String("addr", s.ln.Addr().String()))
+		wg.Go(func() {
+			if err := s.srv.Serve(s.ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				a.serveErr <- fmt.Errorf("serve %s: %w", s.name, err)
+			}
+		})
+	}
 	go func() {
-		a.log.Info("starting server", slog.String("addr", a.srv.Addr))
-		if err := a.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			a.log.Error("failed to start server")
-			panic("failed to start server")
-		}
+		wg.Wait()
+		close(a.serveErr)
 	}()
+
+	return nil
+}
+
+// listen - открывает listener'ы всех серверов; при ошибке уже открытые закрываются
+func (a *App) listen() error {
+	a.listenersMu.Lock()
+	defer a.listenersMu.Unlock()
+
+	for i, s := range a.servers {
+		ln, err := net.Listen("tcp", s.srv.Addr)
+		if err != nil {
+			// уже открытые listener'ы закрываем, чтобы не держать порты
+			for _, opened := range a.servers[:i] {
+				_ = opened.ln.Close()
+				opened.ln = nil
+			}
+			return fmt.Errorf("listen %s: %w", s.srv.Addr, err)
+		}
+		s.ln = ln
+	}
+	return nil
+}
+
+// Addr - адрес, на котором слушает запущенный HTTP сервер
+func (a *App) Addr() net.Addr {
+	return a.addr("http")
+}
+
+// RPCAddr - адрес, на котором слушает запущенный сервер JSON-RPC
+func (a *App) RPCAddr() net.Addr {
+	return a.addr("rpc")
 }
 
-func (a *App) Stop() {
-	const shutdownTimeout = time.Second * 5
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+func (a *App) addr(name string) net.Addr {
+	a.listenersMu.RLock()
+	defer a.listenersMu.RUnlock()
+
+	for _, s := range a.servers {
+		if s.name == name && s.ln != nil {
+			return s.ln.Addr()
+		}
+	}
+	return nil
+}
+
+// Stop - останавливает серверы, дожидаясь завершения запросов не дольше таймаута,
+// и закрывает зарегистрированные ресурсы
+func (a *App) Stop() error {
+	// пока идет остановка, /readyz отвечает 503
+	a.stopping.Store(true)
+	for _, f := range a.onShutdown {
+		f()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
 	defer cancel()
 
-	if err := a.srv.Shutdown(ctx); err != nil {
-		panic("failed to shutdown server")
+	// серверы останавливаются одновременно, общий таймаут на всех
+	errs := make([]error, len(a.servers))
+	var wg sync.WaitGroup
+	for i, s := range a.servers {
+		wg.Go(func() {
+			if err := s.srv.Shutdown(ctx); err != nil {
+				errs[i] = fmt.Errorf("shutdown %s server: %w", s.name, err)
+				// не дождались активных запросов - рвем соединения принудительно
+				_ = s.srv.Close()
+			}
+		})
 	}
+	wg.Wait()
 	a.log.Info("server stopped")
+
+	errs = append(errs, a.closeResources())
+	return errors.Join(errs...)
+}
+
+// Run - запускает сервер и блокируется до отмены ctx или падения сервера, затем останавливает его
+func (a *App) Run(ctx context.Context) error {
+	if err := a.Start(); err != nil {
+		return errors.Join(err, a.closeResources())
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-a.serveErr:
+	}
+
+	return errors.Join(serveErr, a.Stop())
+}
+
+// closeResources - закрывает ресурсы в обратном порядке регистрации
+func (a *App) closeResources() error {
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i].Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close resource: %w", err))
+		}
+	}
+	a.closers = nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,291 @@
+package app_test
+
+import (
+	"Calendar/internal/app"
+	"Calendar/internal/middleware"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+func newApp(port string) *app.App {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return app.New(log, app.Config{
+		Host:            "127.0.0.1",
+		Port:            port,
+		ShutdownTimeout: time.Second,
+	})
+}
+
+func TestRunStopsOnContextCancel(t *testing.T) {
+	a := newApp("0")
+
+	closed := false
+	a.OnStop(closerFunc(func() error {
+		closed = true
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return a.Addr() != nil }, time.Second, 10*time.Millisecond)
+	resp, err := http.Get("http://" + a.Addr().String() + "/unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	require.True(t, closed)
+}
+
+func TestRunReturnsListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+
+	a := newApp(port)
+	closeErr := errors.New("close failed")
+	a.OnStop(closerFunc(func() error { return closeErr }))
+
+	err = a.Run(context.Background())
+	require.Error(t, err)
+	require.ErrorIs(t, err, closeErr)
+}
+
+type routeHandler struct{}
+
+func (routeHandler) Init(r chi.Router) {
+	r.Get("/events/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+}
+
+func TestHealthAndMetrics(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	storageErr := error(nil)
+	a := app.New(log, app.Config{
+		Host:            "127.0.0.1",
+		Port:            "0",
+		ShutdownTimeout: time.Second,
+		Ready:           func(ctx context.Context) error { return storageErr },
+		// служебные маршруты не требуют аутентификации
+		Auth: func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		},
+	}, routeHandler{})
+	require.NoError(t, a.Start())
+	base := "http://" + a.Addr().String()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(base + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := get("/healthz")
+	require.Equal(t, http.StatusOK, status)
+	status, _ = get("/readyz")
+	require.Equal(t, http.StatusOK, status)
+
+	storageErr = errors.New("connection refused")
+	status, body := get("/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Contains(t, body, "storage unavailable")
+	storageErr = nil
+
+	status, _ = get("/events/1")
+	require.Equal(t, http.StatusUnauthorized, status)
+	get("/events/2")
+	get("/missing")
+
+	status, body = get("/metrics")
+	require.Equal(t, http.StatusOK, status)
+	// учитывается шаблон маршрута, а не путь
+	require.Contains(t, body, `calendar_http_requests_total{method="GET",route="/events/{id}",status="401"} 2`)
+	require.Contains(t, body, `calendar_http_requests_total{method="GET",route="/readyz",status="503"} 1`)
+	require.Contains(t, body, `calendar_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	// запрос к /metrics еще обрабатывается
+	require.Contains(t, body, "calendar_http_requests_in_flight 1\n")
+
+	require.NoError(t, a.Stop())
+}
+
+type streamHandler chan struct{}
+
+func (h streamHandler) Init(r chi.Router) {
+	r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		http.NewResponseController(w).Flush()
+		<-h
+	})
+}
+
+func TestStopRunsShutdownHooks(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	streams := make(streamHandler)
+	a := app.New(log, app.Config{Host: "127.0.0.1", Port: "0", ShutdownTimeout: 5 * time.Second}, streams)
+	a.OnShutdown(func() { close(streams) })
+	require.NoError(t, a.Start())
+
+	resp, err := http.Get("http://" + a.Addr().String() + "/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// без закрытия потока остановка ждала бы таймаут
+	start := time.Now()
+	require.NoError(t, a.Stop())
+	require.Less(t, time.Since(start), time.Second)
+}
+
+type rpcHandler struct{}
+
+func (rpcHandler) Init(r chi.Router) {
+	r.Post("/rpc", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func TestRPCListener(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := app.New(log, app.Config{
+		Host:            "127.0.0.1",
+		Port:            "0",
+		RPCPort:         "0",
+		RPC:             rpcHandler{},
+		ShutdownTimeout: time.Second,
+	})
+	require.NoError(t, a.Start())
+	require.NotNil(t, a.RPCAddr())
+	require.NotEqual(t, a.Addr().String(), a.RPCAddr().String())
+
+	resp, err := http.Post("http://"+a.RPCAddr().String()+"/rpc", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	// служебные маршруты только на основном listener'е
+	resp, err = http.Get("http://" + a.RPCAddr().String() + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.NoError(t, a.Stop())
+	for _, addr := range []net.Addr{a.Addr(), a.RPCAddr()} {
+		_, err := net.DialTimeout("tcp", addr.String(), time.Second)
+		require.Error(t, err)
+	}
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	level := new(slog.LevelVar)
+	a := app.New(log, app.Config{
+		Host:            "127.0.0.1",
+		Port:            "0",
+		ShutdownTimeout: time.Second,
+		LogLevel:        level,
+		AdminToken:      "admin-secret",
+	})
+	require.NoError(t, a.Start())
+	defer a.Stop()
+	url := "http://" + a.Addr().String() + "/admin/log_level"
+
+	do := func(method, token, body string) (int, string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(data)
+	}
+
+	status, _ := do(http.MethodGet, "", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = do(http.MethodPut, "wrong", `{"level":"debug"}`)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, body := do(http.MethodGet, "admin-secret", "")
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"result":"INFO"}`, body)
+
+	status, body = do(http.MethodPut, "admin-secret", `{"level":"warn"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `{"result":"WARN"}`, body)
+	require.Equal(t, slog.LevelWarn, level.Level())
+
+	status, _ = do(http.MethodPut, "admin-secret", `{"level":"loud"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, slog.LevelWarn, level.Level())
+
+	// без токена маршрут не регистрируется
+	b := newApp("0")
+	require.NoError(t, b.Start())
+	defer b.Stop()
+	resp, err := http.Get("http://" + b.Addr().String() + "/admin/log_level")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestIPRateLimitBeforeAuth(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	a := app.New(log, app.Config{
+		Host:            "127.0.0.1",
+		Port:            "0",
+		ShutdownTimeout: time.Second,
+		Auth:            deny,
+		IPRateLimit:     middleware.RateLimitIP(1, 1),
+	}, routeHandler{})
+	require.NoError(t, a.Start())
+	defer a.Stop()
+
+	status := func() int {
+		resp, err := http.Get("http://" + a.Addr().String() + "/events/1")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// неудачные попытки аутентификации тоже расходуют корзину адреса
+	require.Equal(t, http.StatusUnauthorized, status())
+	require.Equal(t, http.StatusTooManyRequests, status())
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"Calendar/internal/metrics"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// readyTimeout - сколько ждать ответа хранилища при проверке готовности
+const readyTimeout = 2 * time.Second
+
+// healthz - процесс жив и обслуживает запросы
+func (a *App) healthz(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, "ok")
+}
+
+// readyz - сервер готов принимать трафик: не останавливается и хранилище доступно
+func (a *App) readyz(w http.ResponseWriter, r *http.Request) {
+	if a.stopping.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+
+	if a.ready != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		if err := a.ready(ctx); err != nil {
+			a.log.WarnContext(r.Context(), "storage is not ready", slog.String("err", err.Error()))
+			writeStatus(w, http.StatusServiceUnavailable, "storage unavailable")
+			return
+		}
+	}
+
+	writeStatus(w, http.StatusOK, "ok")
+}
+
+// metricsHandler - метрики в текстовом формате Prometheus
+func (a *App) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if _, err := a.metrics.WriteTo(w); err != nil {
+		a.log.WarnContext(r.Context(), "failed to write metrics", slog.String("err", err.Error()))
+	}
+}
+
+// writeStatus - ответ служебных маршрутов в формате API: {"result": ...} или {"error": ...}
+func writeStatus(w http.ResponseWriter, status int, msg string) {
+	body := map[string]string{"result": msg}
+	if status != http.StatusOK {
+		body = map[string]string{"error": msg}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
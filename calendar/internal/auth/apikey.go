@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"Calendar/internal/domain"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// keyPrefix - префикс API ключей, по нему они отличаются от JWT
+const keyPrefix = "cal_"
+
+// NewAPIKey - создает ключ пользователя. Возвращает запись для хранилища и сам ключ,
+// который показывается один раз: в хранилище остается только хэш.
+func NewAPIKey(userID int64, name string) (domain.APIKey, string) {
+	id := rand.Text()[:12]
+	secret := rand.Text()
+
+	key := domain.APIKey{
+		ID:        id,
+		UserID:    userID,
+		Name:      name,
+		Hash:      hashSecret(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	return key, keyPrefix + id + "_" + secret
+}
+
+// IsAPIKey - похоже ли значение на API ключ
+func IsAPIKey(s string) bool {
+	return strings.HasPrefix(s, keyPrefix)
+}
+
+// splitAPIKey - открытая и секретная части ключа
+func splitAPIKey(s string) (id, secret string, ok bool) {
+	return strings.Cut(strings.TrimPrefix(s, keyPrefix), "_")
+}
+
+// checkSecret - сравнивает секрет с сохраненным хэшем за постоянное время
+func checkSecret(key domain.APIKey, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(secret))) == 1
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// KeyStore - хранилище API ключей
+type KeyStore interface {
+	// GetAPIKey - возвращает ключ по открытой части или domain.ErrAPIKeyNotFound
+	GetAPIKey(ctx context.Context, id string) (domain.APIKey, error)
+}
+
+// Authenticator - проверяет bearer токены: JWT, подписанные общим секретом, или API ключи
+type Authenticator struct {
+	secret []byte
+	keys   KeyStore
+}
+
+// NewAuthenticator - конструктор. Пустой secret отключает JWT, nil keys - API ключи.
+func NewAuthenticator(secret []byte, keys KeyStore) *Authenticator {
+	return &Authenticator{secret: secret, keys: keys}
+}
+
+// Authenticate - возвращает идентификатор пользователя, которому принадлежит токен.
+// Любая ошибка проверки оборачивает domain.ErrUnauthorized.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (int64, error) {
+	if token == "" {
+		return 0, domain.ErrUnauthorized
+	}
+
+	if IsAPIKey(token) {
+		return a.apiKey(ctx, token)
+	}
+
+	if len(a.secret) == 0 {
+		return 0, domain.ErrUnauthorized
+	}
+	return ParseToken(a.secret, token)
+}
+
+func (a *Authenticator) apiKey(ctx context.Context, token string) (int64, error) {
+	id, secret, ok := splitAPIKey(token)
+	if !ok || a.keys == nil {
+		return 0, domain.ErrUnauthorized
+	}
+
+	key, err := a.keys.GetAPIKey(ctx, id)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return 0, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get api key: %w", err)
+	}
+
+	if !checkSecret(key, secret) {
+		return 0, domain.ErrUnauthorized
+	}
+	return key.UserID, nil
+}
@@ -0,0 +1,74 @@
+package auth_test
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/internal/storage/memory"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestAuthenticateJWT(t *testing.T) {
+	ctx := context.Background()
+	a := auth.NewAuthenticator(secret, nil)
+
+	token, err := auth.IssueToken(secret, 42, time.Hour)
+	require.NoError(t, err)
+	userID, err := a.Authenticate(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), userID)
+
+	expired, err := auth.IssueToken(secret, 42, -time.Minute)
+	require.NoError(t, err)
+	foreign, err := auth.IssueToken([]byte("another secret of the same length!"), 42, time.Hour)
+	require.NoError(t, err)
+	// без срока действия
+	noExp, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "42"}).SignedString(secret)
+	require.NoError(t, err)
+	// алгоритм none
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	for _, token := range []string{"", "garbage", expired, foreign, noExp, none} {
+		_, err := a.Authenticate(ctx, token)
+		require.ErrorIs(t, err, domain.ErrUnauthorized, token)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	a := auth.NewAuthenticator(nil, store)
+
+	key, token := auth.NewAPIKey(7, "cli")
+	require.NotContains(t, token, key.Hash)
+	require.NoError(t, store.SaveAPIKey(ctx, key))
+
+	userID, err := a.Authenticate(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), userID)
+
+	_, err = a.Authenticate(ctx, token+"x")
+	require.ErrorIs(t, err, domain.ErrUnauthorized)
+	_, err = a.Authenticate(ctx, "cal_unknown_secret")
+	require.ErrorIs(t, err, domain.ErrUnauthorized)
+
+	// JWT не принимаются, если секрет не задан
+	jwtToken, err := auth.IssueToken(secret, 7, time.Hour)
+	require.NoError(t, err)
+	_, err = a.Authenticate(ctx, jwtToken)
+	require.ErrorIs(t, err, domain.ErrUnauthorized)
+
+	require.NoError(t, store.DeleteAPIKey(ctx, key.ID))
+	_, err = a.Authenticate(ctx, token)
+	require.ErrorIs(t, err, domain.ErrUnauthorized)
+}
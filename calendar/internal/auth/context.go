@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type userIDKey struct{}
+
+// WithUserID - возвращает контекст с идентификатором аутентифицированного пользователя
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID - идентификатор аутентифицированного пользователя из контекста
+func UserID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDKey{}).(int64)
+	return id, ok
+}
@@ -0,0 +1,494 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix - префикс переменных окружения
+const envPrefix = "CALENDAR_"
+
+// Config - конфигурация сервера календаря
+type Config struct {
+	// окружение: local, staging или production
+	Env string `yaml:"env"`
+	// уровень логирования: debug, info, warn, error (по умолчанию зависит от окружения)
+	LogLevel string `yaml:"log_level"`
+	Log      Log    `yaml:"log"`
+
+	HTTP        HTTP        `yaml:"http"`
+	Storage     Storage     `yaml:"storage"`
+	Reminders   Reminders   `yaml:"reminders"`
+	Auth        Auth        `yaml:"auth"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	RPC         RPC         `yaml:"rpc"`
+}
+
+// Log - вывод логов и управление ими
+type Log struct {
+	// файл лога с ротацией по размеру; пусто - stdout
+	File string `yaml:"file"`
+	// размер файла в байтах, после которого он ротируется, и сколько старых файлов хранить
+	MaxBytes   int64 `yaml:"max_bytes"`
+	MaxBackups int   `yaml:"max_backups"`
+	// токен для смены уровня через /admin/log_level; пусто - маршрут отключен
+	AdminToken string `yaml:"admin_token"`
+}
+
+// HTTP - настройки HTTP сервера
+type HTTP struct {
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// таймауты чтения запроса целиком, записи ответа и простоя keep-alive соединения
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// максимальный размер заголовков и тела запроса в байтах
+	MaxHeaderBytes int   `yaml:"max_header_bytes"`
+	MaxBodyBytes   int64 `yaml:"max_body_bytes"`
+}
+
+// Storage - настройки хранилища событий
+type Storage struct {
+	// memory, file или postgres
+	Backend string `yaml:"backend"`
+	// путь к журналу для file
+	Path string `yaml:"path"`
+	// строка подключения для postgres
+	DSN string `yaml:"dsn"`
+	// сколько удаленные события можно восстановить, после - окончательное удаление
+	Retention time.Duration `yaml:"retention"`
+}
+
+// Reminders - настройки планировщика напоминаний
+type Reminders struct {
+	Enabled bool `yaml:"enabled"`
+	// период опроса хранилища
+	Interval time.Duration `yaml:"interval"`
+	// насколько назад досылать пропущенные (например, во время простоя) напоминания
+	Lookback time.Duration `yaml:"lookback"`
+	// log, stdout или webhook
+	Notifier   string `yaml:"notifier"`
+	WebhookURL string `yaml:"webhook_url"`
+	// файл с уже отправленными напоминаниями. По умолчанию для file - рядом с журналом событий,
+	// для postgres - таблица в той же БД, для memory - учет только в памяти
+	SentPath string `yaml:"sent_path"`
+}
+
+// Auth - настройки аутентификации
+type Auth struct {
+	// без аутентификации пользователь определяется параметром user_id
+	Enabled bool `yaml:"enabled"`
+	// общий секрет для проверки подписи JWT (HS256)
+	JWTSecret string `yaml:"jwt_secret"`
+}
+
+// RateLimit - ограничение частоты запросов на пользователя (без аутентификации - на IP)
+type RateLimit struct {
+	Enabled bool `yaml:"enabled"`
+	// запросов в секунду в среднем
+	RPS float64 `yaml:"rps"`
+	// сколько запросов можно сделать подряд сверх средней частоты
+	Burst int `yaml:"burst"`
+	// то же до аутентификации на IP адрес: неудачные попытки входа и публичные маршруты.
+	// Запас больше, чем на пользователя, потому что за одним адресом бывает несколько пользователей
+	IPRPS   float64 `yaml:"ip_rps"`
+	IPBurst int     `yaml:"ip_burst"`
+}
+
+// Idempotency - повтор POST запросов с заголовком Idempotency-Key без повторного выполнения
+type Idempotency struct {
+	Enabled bool `yaml:"enabled"`
+	// сколько помнить ответ на запрос с ключом
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// RPC - второй listener с JSON-RPC 2.0 на том же хосте, что и HTTP
+type RPC struct {
+	Enabled bool   `yaml:"enabled"`
+	Port    string `yaml:"port"`
+}
+
+// minSecretLen - минимальная длина секрета JWT в байтах
+const minSecretLen = 32
+
+// defaults - значения по умолчанию
+func defaults() Config {
+	return Config{
+		Env: "local",
+		Log: Log{
+			MaxBytes:   100 << 20,
+			MaxBackups: 5,
+		},
+		HTTP: HTTP{
+			Host:            "0.0.0.0",
+			Port:            "8000",
+			ShutdownTimeout: 5 * time.Second,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     time.Minute,
+			MaxHeaderBytes:  1 << 20,
+			// не меньше лимита импорта iCalendar
+			MaxBodyBytes: 10 << 20,
+		},
+		Storage: Storage{
+			Backend:   "memory",
+			Path:      "events.jsonl",
+			Retention: 30 * 24 * time.Hour,
+		},
+		Reminders: Reminders{
+			Enabled:  true,
+			Interval: 10 * time.Second,
+			Lookback: time.Hour,
+			Notifier: "log",
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			RPS:     10,
+			Burst:   20,
+			IPRPS:   50,
+			IPBurst: 100,
+		},
+		Idempotency: Idempotency{
+			Enabled: true,
+			TTL:     24 * time.Hour,
+		},
+		RPC: RPC{
+			Port: "8001",
+		},
+	}
+}
+
+// Load - загружает конфигурацию. Приоритет (по возрастанию): значения по умолчанию,
+// файл (YAML или JSON, флаг -config или CALENDAR_CONFIG), переменные окружения CALENDAR_*, флаги.
+func Load(args []string) (*Config, error) {
+	cfg := defaults()
+
+	fs := flag.NewFlagSet("calendar", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "path to YAML or JSON config file")
+	env := fs.String("env", "", "environment: local, staging or production")
+	logLevel := fs.String("log-level", "", "log level: debug, info, warn or error")
+	host := fs.String("host", "", "HTTP host")
+	port := fs.String("port", "", "HTTP port")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 0, "graceful shutdown timeout")
+	backend := fs.String("storage", "", "storage backend: memory, file or postgres")
+	storagePath := fs.String("storage-path", "", "path to the events journal for file storage")
+	dsn := fs.String("dsn", "", "database connection string for postgres storage")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := readFile(*path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := readEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	// флаги переопределяют остальное, только если заданы явно
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Env = *env
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		case "host":
+			cfg.HTTP.Host = *host
+		case "port":
+			cfg.HTTP.Port = *port
+		case "shutdown-timeout":
+			cfg.HTTP.ShutdownTimeout = *shutdownTimeout
+		case "storage":
+			cfg.Storage.Backend = *backend
+		case "storage-path":
+			cfg.Storage.Path = *storagePath
+		case "dsn":
+			cfg.Storage.DSN = *dsn
+		}
+	})
+
+	if cfg.Reminders.SentPath == "" && cfg.Storage.Backend == "file" && cfg.Storage.Path != "" {
+		cfg.Reminders.SentPath = sentPath(cfg.Storage.Path)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// sentPath - файл отправленных напоминаний рядом с журналом событий: events.jsonl -> events.sent.jsonl
+func sentPath(storagePath string) string {
+	return strings.TrimSuffix(storagePath, filepath.Ext(storagePath)) + ".sent.jsonl"
+}
+
+// readFile - читает файл конфигурации (JSON является подмножеством YAML)
+func readFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("decode config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readEnv - применяет переменные окружения CALENDAR_*
+func readEnv(cfg *Config) error {
+	strs := map[string]*string{
+		"ENV":          &cfg.Env,
+		"LOG_LEVEL":    &cfg.LogLevel,
+		"LOG_FILE":     &cfg.Log.File,
+		"HOST":         &cfg.HTTP.Host,
+		"PORT":         &cfg.HTTP.Port,
+		"STORAGE":      &cfg.Storage.Backend,
+		"STORAGE_PATH": &cfg.Storage.Path,
+		"DSN":          &cfg.Storage.DSN,
+
+		"REMINDERS_NOTIFIER":    &cfg.Reminders.Notifier,
+		"REMINDERS_WEBHOOK_URL": &cfg.Reminders.WebhookURL,
+		"REMINDERS_SENT_PATH":   &cfg.Reminders.SentPath,
+
+		"AUTH_JWT_SECRET": &cfg.Auth.JWTSecret,
+		"RPC_PORT":        &cfg.RPC.Port,
+		"LOG_ADMIN_TOKEN": &cfg.Log.AdminToken,
+	}
+	for name, dst := range strs {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			*dst = v
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"SHUTDOWN_TIMEOUT":  &cfg.HTTP.ShutdownTimeout,
+		"READ_TIMEOUT":      &cfg.HTTP.ReadTimeout,
+		"WRITE_TIMEOUT":     &cfg.HTTP.WriteTimeout,
+		"IDLE_TIMEOUT":      &cfg.HTTP.IdleTimeout,
+		"STORAGE_RETENTION": &cfg.Storage.Retention,
+		"IDEMPOTENCY_TTL":   &cfg.Idempotency.TTL,
+	}
+	for name, dst := range durations {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s%s: %w", envPrefix, name, err)
+			}
+			*dst = d
+		}
+	}
+
+	bools := map[string]*bool{
+		"REMINDERS_ENABLED":   &cfg.Reminders.Enabled,
+		"AUTH_ENABLED":        &cfg.Auth.Enabled,
+		"RATE_LIMIT_ENABLED":  &cfg.RateLimit.Enabled,
+		"IDEMPOTENCY_ENABLED": &cfg.Idempotency.Enabled,
+		"RPC_ENABLED":         &cfg.RPC.Enabled,
+	}
+	for name, dst := range bools {
+		if v, ok := os.LookupEnv(envPrefix + name); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s%s: %w", envPrefix, name, err)
+			}
+			*dst = b
+		}
+	}
+
+	if v, ok := os.LookupEnv(envPrefix + "LOG_MAX_BYTES"); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%sLOG_MAX_BYTES: %w", envPrefix, err)
+		}
+		cfg.Log.MaxBytes = n
+	}
+	if v, ok := os.LookupEnv(envPrefix + "LOG_MAX_BACKUPS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sLOG_MAX_BACKUPS: %w", envPrefix, err)
+		}
+		cfg.Log.MaxBackups = n
+	}
+	if v, ok := os.LookupEnv(envPrefix + "MAX_HEADER_BYTES"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sMAX_HEADER_BYTES: %w", envPrefix, err)
+		}
+		cfg.HTTP.MaxHeaderBytes = n
+	}
+	if v, ok := os.LookupEnv(envPrefix + "MAX_BODY_BYTES"); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%sMAX_BODY_BYTES: %w", envPrefix, err)
+		}
+		cfg.HTTP.MaxBodyBytes = n
+	}
+	if v, ok := os.LookupEnv(envPrefix + "RATE_LIMIT_RPS"); ok {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%sRATE_LIMIT_RPS: %w", envPrefix, err)
+		}
+		cfg.RateLimit.RPS = rps
+	}
+	if v, ok := os.LookupEnv(envPrefix + "RATE_LIMIT_BURST"); ok {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sRATE_LIMIT_BURST: %w", envPrefix, err)
+		}
+		cfg.RateLimit.Burst = burst
+	}
+	if v, ok := os.LookupEnv(envPrefix + "RATE_LIMIT_IP_RPS"); ok {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%sRATE_LIMIT_IP_RPS: %w", envPrefix, err)
+		}
+		cfg.RateLimit.IPRPS = rps
+	}
+	if v, ok := os.LookupEnv(envPrefix + "RATE_LIMIT_IP_BURST"); ok {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sRATE_LIMIT_IP_BURST: %w", envPrefix, err)
+		}
+		cfg.RateLimit.IPBurst = burst
+	}
+
+	return nil
+}
+
+// Validate - проверяет корректность конфигурации
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Env {
+	case "local", "staging", "production":
+	default:
+		errs = append(errs, fmt.Errorf("unknown env %q", c.Env))
+	}
+
+	if _, err := c.Level(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Log.File != "" {
+		if c.Log.MaxBytes <= 0 {
+			errs = append(errs, errors.New("log max bytes must be positive"))
+		}
+		if c.Log.MaxBackups < 0 {
+			errs = append(errs, errors.New("log max backups must not be negative"))
+		}
+	}
+
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid port %q", c.HTTP.Port))
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown timeout must be positive"))
+	}
+	// 0 - без таймаута
+	if c.HTTP.ReadTimeout < 0 || c.HTTP.WriteTimeout < 0 || c.HTTP.IdleTimeout < 0 {
+		errs = append(errs, errors.New("http timeouts must not be negative"))
+	}
+	if c.HTTP.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("max header bytes must be positive"))
+	}
+	if c.HTTP.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("max body bytes must be positive"))
+	}
+
+	switch c.Storage.Backend {
+	case "memory":
+	case "file":
+		if c.Storage.Path == "" {
+			errs = append(errs, errors.New("storage path is required for file storage"))
+		}
+	case "postgres":
+		if c.Storage.DSN == "" {
+			errs = append(errs, errors.New("dsn is required for postgres storage"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown storage backend %q", c.Storage.Backend))
+	}
+	if c.Storage.Retention <= 0 {
+		errs = append(errs, errors.New("storage retention must be positive"))
+	}
+
+	if c.Reminders.Enabled {
+		if c.Reminders.Interval <= 0 {
+			errs = append(errs, errors.New("reminders interval must be positive"))
+		}
+		if c.Reminders.Lookback < 0 {
+			errs = append(errs, errors.New("reminders lookback must not be negative"))
+		}
+		switch c.Reminders.Notifier {
+		case "log", "stdout":
+		case "webhook":
+			if c.Reminders.WebhookURL == "" {
+				errs = append(errs, errors.New("webhook url is required for webhook notifier"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown reminders notifier %q", c.Reminders.Notifier))
+		}
+	}
+
+	if c.RPC.Enabled {
+		if port, err := strconv.Atoi(c.RPC.Port); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("invalid rpc port %q", c.RPC.Port))
+		} else if c.RPC.Port == c.HTTP.Port {
+			errs = append(errs, errors.New("rpc port must differ from http port"))
+		}
+	}
+
+	if c.RateLimit.Enabled && (c.RateLimit.RPS <= 0 || c.RateLimit.Burst <= 0) {
+		errs = append(errs, errors.New("rate limit rps and burst must be positive"))
+	}
+	if c.RateLimit.Enabled && (c.RateLimit.IPRPS <= 0 || c.RateLimit.IPBurst <= 0) {
+		errs = append(errs, errors.New("rate limit ip rps and ip burst must be positive"))
+	}
+	if c.Idempotency.Enabled && c.Idempotency.TTL <= 0 {
+		errs = append(errs, errors.New("idempotency ttl must be positive"))
+	}
+
+	if c.Auth.Enabled && len(c.Auth.JWTSecret) < minSecretLen {
+		errs = append(errs, fmt.Errorf("jwt secret must be at least %d bytes", minSecretLen))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// Level - уровень логирования; если не задан, определяется окружением
+func (c *Config) Level() (slog.Level, error) {
+	if c.LogLevel == "" {
+		if c.Env == "production" {
+			return slog.LevelInfo, nil
+		}
+		return slog.LevelDebug, nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(c.LogLevel))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", c.LogLevel)
+	}
+	return level, nil
+}
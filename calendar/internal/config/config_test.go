@@ -0,0 +1,115 @@
+package config_test
+
+import (
+	"Calendar/internal/config"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	require.Equal(t, "local", cfg.Env)
+	require.Equal(t, "0.0.0.0", cfg.HTTP.Host)
+	require.Equal(t, "8000", cfg.HTTP.Port)
+	require.Equal(t, 5*time.Second, cfg.HTTP.ShutdownTimeout)
+	require.Equal(t, "memory", cfg.Storage.Backend)
+	require.Equal(t, int64(10<<20), cfg.HTTP.MaxBodyBytes)
+	require.True(t, cfg.RateLimit.Enabled)
+	require.Empty(t, cfg.Log.File)
+	require.Empty(t, cfg.Reminders.SentPath)
+	require.Equal(t, int64(100<<20), cfg.Log.MaxBytes)
+
+	level, err := cfg.Level()
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelDebug, level)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+env: production
+http:
+  host: 127.0.0.1
+  port: 9000
+  shutdown_timeout: 10s
+  write_timeout: 1m
+storage:
+  backend: file
+  path: /tmp/events.jsonl
+`)
+
+	t.Setenv("CALENDAR_PORT", "9100")
+	t.Setenv("CALENDAR_LOG_LEVEL", "warn")
+	t.Setenv("CALENDAR_READ_TIMEOUT", "3s")
+	t.Setenv("CALENDAR_MAX_BODY_BYTES", "4096")
+	t.Setenv("CALENDAR_RATE_LIMIT_RPS", "2.5")
+	t.Setenv("CALENDAR_IDEMPOTENCY_TTL", "1h")
+
+	cfg, err := config.Load([]string{"-config", path, "-host", "localhost"})
+	require.NoError(t, err)
+	require.Equal(t, "production", cfg.Env)
+	require.Equal(t, "localhost", cfg.HTTP.Host)
+	require.Equal(t, "9100", cfg.HTTP.Port)
+	require.Equal(t, 10*time.Second, cfg.HTTP.ShutdownTimeout)
+	require.Equal(t, 3*time.Second, cfg.HTTP.ReadTimeout)
+	require.Equal(t, time.Minute, cfg.HTTP.WriteTimeout)
+	require.Equal(t, int64(4096), cfg.HTTP.MaxBodyBytes)
+	require.Equal(t, 2.5, cfg.RateLimit.RPS)
+	require.Equal(t, "file", cfg.Storage.Backend)
+	require.Equal(t, "/tmp/events.sent.jsonl", cfg.Reminders.SentPath)
+	require.Equal(t, 30*24*time.Hour, cfg.Storage.Retention)
+	require.Equal(t, time.Hour, cfg.Idempotency.TTL)
+
+	level, err := cfg.Level()
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, level)
+}
+
+func TestLoadJSON(t *testing.T) {
+	path := writeFile(t, "config.json", `{"env": "staging", "http": {"port": "8080"}}`)
+
+	cfg, err := config.Load([]string{"-config", path})
+	require.NoError(t, err)
+	require.Equal(t, "staging", cfg.Env)
+	require.Equal(t, "8080", cfg.HTTP.Port)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown env", args: []string{"-env", "dev"}},
+		{name: "bad port", args: []string{"-port", "http"}},
+		{name: "bad log level", args: []string{"-log-level", "loud"}},
+		{name: "zero shutdown timeout", args: []string{"-shutdown-timeout", "0s"}},
+		{name: "postgres without dsn", args: []string{"-storage", "postgres"}},
+		{name: "unknown backend", args: []string{"-storage", "redis"}},
+		{name: "unknown field", args: []string{"-config", writeFile(t, "c.yaml", "hots: x")}},
+		{name: "negative timeout", args: []string{"-config", writeFile(t, "timeout.yaml", "http: {read_timeout: -1s}")}},
+		{name: "zero body limit", args: []string{"-config", writeFile(t, "body.yaml", "http: {max_body_bytes: 0}")}},
+		{name: "rate limit without burst", args: []string{"-config", writeFile(t, "rate.yaml", "rate_limit: {burst: 0}")}},
+		{name: "rpc on http port", args: []string{"-config", writeFile(t, "rpc.yaml", "rpc: {enabled: true, port: \"8000\"}")}},
+		{name: "log file without size", args: []string{"-config", writeFile(t, "log.yaml", "log: {file: calendar.log, max_bytes: 0}")}},
+		{name: "auth without secret", args: []string{"-config", writeFile(t, "auth.yaml", "auth: {enabled: true, jwt_secret: short}")}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Load(tt.args)
+			require.Error(t, err)
+		})
+	}
+}
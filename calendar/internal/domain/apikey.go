@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// APIKey - API ключ пользователя. Сам ключ не хранится, только его хэш.
+type APIKey struct {
+	// ID - открытая часть ключа, по которой он ищется в хранилище
+	ID        string    `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name,omitempty"`
+	Hash      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// AuditAction - вид изменения события
+type AuditAction string
+
+const (
+	AuditCreated  AuditAction = "created"
+	AuditUpdated  AuditAction = "updated"
+	AuditDeleted  AuditAction = "deleted"
+	AuditRestored AuditAction = "restored"
+)
+
+// AuditEntry - запись журнала изменений события
+type AuditEntry struct {
+	ID      int64 `json:"id"`
+	EventID int64 `json:"event_id"`
+	// пользователь, сделавший изменение
+	ActorID int64       `json:"actor_id"`
+	Action  AuditAction `json:"action"`
+	At      time.Time   `json:"at"`
+	// событие до и после изменения: при создании и восстановлении нет Before, при удалении - After
+	Before *Event `json:"before,omitempty"`
+	After  *Event `json:"after,omitempty"`
+}
+
+// Snapshot - последнее известное состояние события
+func (a AuditEntry) Snapshot() Event {
+	if a.After != nil {
+		return *a.After
+	}
+	if a.Before != nil {
+		return *a.Before
+	}
+	return Event{ID: a.EventID}
+}
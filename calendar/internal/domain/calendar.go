@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// Permission - право доступа к общему календарю
+type Permission string
+
+const (
+	// PermissionRead - просмотр событий календаря
+	PermissionRead Permission = "read"
+	// PermissionWrite - просмотр, создание, изменение и удаление событий календаря
+	PermissionWrite Permission = "write"
+)
+
+// Valid - известно ли право
+func (p Permission) Valid() bool {
+	return p == PermissionRead || p == PermissionWrite
+}
+
+// Allows - покрывает ли право p право need (запись включает чтение)
+func (p Permission) Allows(need Permission) bool {
+	return p == PermissionWrite || (p == PermissionRead && need == PermissionRead)
+}
+
+// Calendar - календарь пользователя, которым можно поделиться с другими
+type Calendar struct {
+	ID      int64  `json:"id"`
+	OwnerID int64  `json:"owner_id"`
+	Name    string `json:"name"`
+	// политика пересечений событий календаря; пустая - ConflictWarn
+	ConflictPolicy ConflictPolicy `json:"conflict_policy,omitempty"`
+	// право пользователя, запросившего список календарей
+	Permission Permission `json:"permission,omitempty"`
+}
+
+// Share - доступ пользователя к чужому календарю
+type Share struct {
+	CalendarID int64      `json:"calendar_id"`
+	UserID     int64      `json:"user_id"`
+	Permission Permission `json:"permission"`
+}
+
+// RSVP - ответ участника на приглашение
+type RSVP string
+
+const (
+	RSVPNeedsAction RSVP = "needs-action"
+	RSVPAccepted    RSVP = "accepted"
+	RSVPDeclined    RSVP = "declined"
+	RSVPTentative   RSVP = "tentative"
+)
+
+// Valid - допустимый ли ответ участника (needs-action ставится только при приглашении)
+func (r RSVP) Valid() bool {
+	return r == RSVPAccepted || r == RSVPDeclined || r == RSVPTentative
+}
+
+// Invitation - приглашение пользователя на событие (для серии - на все вхождения)
+type Invitation struct {
+	EventID   int64     `json:"event_id"`
+	UserID    int64     `json:"user_id"`
+	Status    RSVP      `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// ChangeType - вид изменения события
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change - уведомление об изменении события
+type Change struct {
+	Type ChangeType `json:"type"`
+	// событие после изменения; для удаленного - каким оно было
+	Event Event     `json:"event"`
+	At    time.Time `json:"at"`
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "fmt"
+
+// ConflictPolicy - как календарь поступает с событием, пересекающимся с другими его событиями
+type ConflictPolicy string
+
+const (
+	// ConflictWarn - событие сохраняется, пересечения возвращаются как предупреждение (по умолчанию)
+	ConflictWarn ConflictPolicy = "warn"
+	// ConflictReject - событие с пересечениями отклоняется
+	ConflictReject ConflictPolicy = "reject"
+)
+
+// Valid - известна ли политика
+func (p ConflictPolicy) Valid() bool {
+	return p == ConflictWarn || p == ConflictReject
+}
+
+// ConflictError - событие отклонено из-за пересечений; errors.Is(err, ErrConflict)
+type ConflictError struct {
+	// пересекающиеся события (для серий - вхождения)
+	Events []Event
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s with %d event(s)", ErrConflict, len(e.Events))
+}
+
+// Unwrap - для errors.Is(err, ErrConflict)
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// Overlaps - пересекаются ли события по времени. Моментальные события время не занимают
+// и ни с чем не пересекаются.
+func (e Event) Overlaps(other Event) bool {
+	if !e.Start.Before(e.End) || !other.Start.Before(other.End) {
+		return false
+	}
+	return e.Start.Before(other.End) && other.Start.Before(e.End)
+}
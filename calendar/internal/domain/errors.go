@@ -0,0 +1,58 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrEventNotFound - событие не найдено
+	ErrEventNotFound = errors.New("event not found")
+	// ErrInvalidDate - некорректная дата события
+	ErrInvalidDate = errors.New("invalid date")
+	// ErrEmptyTitle - пустое название события
+	ErrEmptyTitle = errors.New("empty title")
+	// ErrInvalidUserID - некорректный идентификатор пользователя
+	ErrInvalidUserID = errors.New("invalid user id")
+	// ErrInvalidReminder - некорректное время напоминания
+	ErrInvalidReminder = errors.New("invalid reminder")
+	// ErrInvalidRecurrence - некорректное правило повторения или вхождение серии
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	// ErrInvalidTimeZone - неизвестная IANA зона
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	// ErrUserNotFound - настройки пользователя не сохранены
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUnauthorized - запрос без действительного токена или ключа
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden - пользователь обращается к чужим данным
+	ErrForbidden = errors.New("forbidden")
+	// ErrAPIKeyNotFound - API ключ не найден
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrCalendarNotFound - календарь не найден или недоступен пользователю
+	ErrCalendarNotFound = errors.New("calendar not found")
+	// ErrEmptyName - пустое название календаря
+	ErrEmptyName = errors.New("empty name")
+	// ErrNotShared - календарь не открыт пользователю
+	ErrNotShared = errors.New("calendar is not shared with user")
+	// ErrInvalidPermission - неизвестное право доступа
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrInvitationNotFound - приглашение не найдено
+	ErrInvitationNotFound = errors.New("invitation not found")
+	// ErrInvalidRSVP - недопустимый ответ на приглашение
+	ErrInvalidRSVP = errors.New("invalid rsvp status")
+	// ErrInvalidSlotQuery - некорректные параметры поиска свободного времени
+	ErrInvalidSlotQuery = errors.New("invalid slot query")
+	// ErrInvalidTags - некорректные теги события
+	ErrInvalidTags = errors.New("invalid tags")
+	// ErrInvalidSearch - некорректные параметры поиска событий
+	ErrInvalidSearch = errors.New("invalid search query")
+	// ErrConflict - событие пересекается с другими событиями календаря
+	ErrConflict = errors.New("event conflicts with existing events")
+	// ErrInvalidConflictPolicy - неизвестная политика пересечений
+	ErrInvalidConflictPolicy = errors.New("invalid conflict policy")
+	// ErrNotDeleted - событие не удалено, восстанавливать нечего
+	ErrNotDeleted = errors.New("event is not deleted")
+	// ErrRestoreExpired - срок, в течение которого удаленное событие можно восстановить, истек
+	ErrRestoreExpired = errors.New("restore window has expired")
+	// ErrVersionMismatch - событие изменено с тех пор, как клиент получил его версию
+	ErrVersionMismatch = errors.New("event version mismatch")
+	// ErrStreamClosed - сервис останавливается и больше не принимает подписки
+	ErrStreamClosed = errors.New("event stream is closed")
+)
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event - событие календаря
+type Event struct {
+	ID int64 `json:"id"`
+	// владелец события; для событий общего календаря - владелец календаря
+	UserID int64 `json:"user_id"`
+	// календарь события, 0 - личные события пользователя
+	CalendarID  int64  `json:"calendar_id,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// начало и конец хранятся в UTC
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// IANA зона события: в ней разворачиваются повторения (с учетом перехода на летнее время)
+	TimeZone string `json:"time_zone,omitempty"`
+	// событие на весь день (даты без времени в iCalendar): Start и End - полночь UTC
+	// первого дня и дня после последнего
+	AllDay bool `json:"all_day,omitempty"`
+	// за сколько до начала напомнить о событии (0 - без напоминания)
+	RemindBefore time.Duration `json:"remind_before,omitempty"`
+
+	// правило повторения в формате RRULE (RFC 5545), пусто для разовых событий
+	RRule string `json:"rrule,omitempty"`
+	// начала исключенных из серии вхождений
+	ExDates []time.Time `json:"exdates,omitempty"`
+	// начало вхождения серии по правилу: для развернутых вхождений
+	// и для отдельно измененных вхождений (вместе с SeriesID)
+	RecurrenceID time.Time `json:"recurrence_id,omitzero"`
+	// серия, из которой выделено измененное вхождение
+	SeriesID int64 `json:"series_id,omitempty"`
+	// UID события из импортированного файла iCalendar
+	UID string `json:"uid,omitempty"`
+	// теги (категории) события: в нижнем регистре, без повторов, по алфавиту
+	Tags []string `json:"tags,omitempty"`
+	// версия события: 1 при создании, растет на единицу при каждом изменении (ETag и If-Match)
+	Version int64 `json:"version"`
+}
+
+// eventJSON - событие без методов (de)сериализации
+type eventJSON Event
+
+// MarshalJSON - RemindBefore пишется строкой длительности, как в запросах: "15m"
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		eventJSON
+		RemindBefore string `json:"remind_before,omitempty"`
+	}{eventJSON: eventJSON(e), RemindBefore: formatDuration(e.RemindBefore)})
+}
+
+// UnmarshalJSON - RemindBefore читается строкой длительности или числом наносекунд:
+// так событие записано в старых журналах и снимках аудита
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var v struct {
+		*eventJSON
+		RemindBefore json.RawMessage `json:"remind_before,omitempty"`
+	}
+	v.eventJSON = (*eventJSON)(e)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	e.RemindBefore = 0
+	if len(v.RemindBefore) == 0 || string(v.RemindBefore) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(v.RemindBefore, &s); err != nil {
+		var ns int64
+		if err := json.Unmarshal(v.RemindBefore, &ns); err != nil {
+			return fmt.Errorf("remind_before: %w", err)
+		}
+		e.RemindBefore = time.Duration(ns)
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("remind_before: %w", err)
+	}
+	e.RemindBefore = d
+	return nil
+}
+
+// formatDuration - длительность без нулевых хвостов: "15m" вместо "15m0s", пусто для нуля
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// RemindAt - момент отправки напоминания, если оно задано
+func (e Event) RemindAt() (time.Time, bool) {
+	if e.RemindBefore <= 0 {
+		return time.Time{}, false
+	}
+	return e.Start.Add(-e.RemindBefore), true
+}
+
+// Recurring - является ли событие повторяющейся серией
+func (e Event) Recurring() bool {
+	return e.RRule != ""
+}
+
+// ImportResult - итог импорта событий
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	// события, которые не удалось импортировать; остальные импортируются без них
+	Skipped []ImportSkip `json:"skipped,omitempty"`
+}
+
+// ImportSkip - пропущенное при импорте событие и причина
+type ImportSkip struct {
+	UID    string `json:"uid"`
+	Reason string `json:"reason"`
+}
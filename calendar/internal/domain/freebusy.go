@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Interval - полуинтервал времени [Start, End)
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// WorkingHours - рабочее время внутри дня как смещения от полуночи в зоне пользователя
+type WorkingHours struct {
+	Start time.Duration
+	End   time.Duration
+	// учитывать ли субботу и воскресенье
+	Weekends bool
+}
+
+// DefaultWorkingHours - с 9:00 до 18:00 по будням
+var DefaultWorkingHours = WorkingHours{Start: 9 * time.Hour, End: 18 * time.Hour}
+
+// SlotQuery - поиск общего свободного времени
+type SlotQuery struct {
+	UserIDs  []int64
+	From, To time.Time
+	// минимальная длительность встречи
+	Duration     time.Duration
+	WorkingHours WorkingHours
+}
+
+// BusyTimes - занятость пользователя
+type BusyTimes struct {
+	UserID int64      `json:"user_id"`
+	Busy   []Interval `json:"busy"`
+}
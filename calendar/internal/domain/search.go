@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchQuery - параметры поиска событий пользователя
+type SearchQuery struct {
+	UserID int64
+	// подстрока названия или описания без учета регистра (пусто - любые)
+	Text string
+	// полуинтервал [From, To) начала события; серия дает вхождения, начинающиеся в нем.
+	// Нулевая граница - без ограничения.
+	From, To time.Time
+	// события, у которых есть все перечисленные теги
+	Tags []string
+	// размер страницы (0 - по умолчанию)
+	Limit int
+	// курсор следующей страницы из SearchPage.NextCursor
+	Cursor string
+}
+
+// SearchPage - страница результатов поиска по возрастанию начала
+type SearchPage struct {
+	Events []Event `json:"events"`
+	// курсор следующей страницы; пусто - страница последняя
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// EventFilter - условия выборки событий из хранилища при поиске
+type EventFilter struct {
+	// события пользователя, события календарей CalendarIDs и отдельные события EventIDs (объединение)
+	UserID      int64
+	CalendarIDs []int64
+	EventIDs    []int64
+
+	// подстрока названия или описания без учета регистра
+	Text string
+	// все перечисленные теги
+	Tags     []string
+	From, To time.Time
+	// true - только повторяющиеся серии (по началу первого вхождения), false - разовые события
+	// и выделенные вхождения; вхождения серий разворачивает сервис
+	Recurring bool
+	// выборка продолжается строго после события After в порядке (Start, ID)
+	After *Cursor
+	Limit int
+}
+
+// Cursor - позиция в выдаче, упорядоченной по (Start, ID)
+type Cursor struct {
+	Start time.Time
+	ID    int64
+}
+
+// String - непрозрачное представление курсора для клиента
+func (c Cursor) String() string {
+	raw := strconv.FormatInt(c.Start.UnixNano(), 10) + ":" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor - разбирает курсор, полученный от String
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidSearch
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return Cursor{}, ErrInvalidSearch
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidSearch
+	}
+	c := Cursor{Start: time.Unix(0, n).UTC()}
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil || c.ID <= 0 {
+		return Cursor{}, ErrInvalidSearch
+	}
+	return c, nil
+}
+
+// Before - идет ли событие e в выдаче после курсора
+func (c Cursor) Before(e Event) bool {
+	if e.Start.Equal(c.Start) {
+		return e.ID > c.ID
+	}
+	return e.Start.After(c.Start)
+}
+
+// Match - подходит ли событие под условия фильтра (кроме After и Limit)
+func (f EventFilter) Match(e Event) bool {
+	if e.UserID != f.UserID && (e.CalendarID == 0 || !slices.Contains(f.CalendarIDs, e.CalendarID)) &&
+		!slices.Contains(f.EventIDs, e.ID) {
+		return false
+	}
+	if e.Recurring() != f.Recurring {
+		return false
+	}
+	if !f.From.IsZero() && e.Start.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Start.Before(f.To) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(e.Tags, tag) {
+			return false
+		}
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		return strings.Contains(strings.ToLower(e.Title), text) ||
+			strings.Contains(strings.ToLower(e.Description), text)
+	}
+	return true
+}
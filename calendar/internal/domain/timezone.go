@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// locations - кэш загруженных зон, чтобы не читать tzdata на каждый запрос
+var locations sync.Map
+
+// LoadLocation - загружает IANA зону по имени (пустое имя - UTC).
+// Зона "Local" не принимается: она зависит от машины, на которой запущен сервер.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "UTC" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	locations.Store(name, loc)
+
+	return loc, nil
+}
+
+// Location - зона события; при некорректной зоне - UTC
+func (e Event) Location() *time.Location {
+	loc, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
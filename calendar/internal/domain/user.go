@@ -0,0 +1,10 @@
+package domain
+
+// User - настройки пользователя календаря
+type User struct {
+	ID int64 `json:"id"`
+	// IANA зона пользователя, в которой считаются границы дня, недели и месяца
+	TimeZone string `json:"time_zone"`
+	// политика пересечений личных событий; пустая - ConflictWarn
+	ConflictPolicy ConflictPolicy `json:"conflict_policy,omitempty"`
+}
@@ -0,0 +1,142 @@
+package freebusy
+
+import (
+	"Calendar/internal/domain"
+	"sort"
+	"time"
+)
+
+// Merge - объединяет пересекающиеся и смежные интервалы, пустые отбрасываются.
+// Результат отсортирован по началу.
+func Merge(intervals []domain.Interval) []domain.Interval {
+	sorted := make([]domain.Interval, 0, len(intervals))
+	for _, iv := range intervals {
+		if iv.End.After(iv.Start) {
+			sorted = append(sorted, iv)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	merged := make([]domain.Interval, 0, len(sorted))
+	for _, iv := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && !iv.Start.After(merged[last].End) {
+			if iv.End.After(merged[last].End) {
+				merged[last].End = iv.End
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+
+	return merged
+}
+
+// Subtract - части интервалов free, не пересекающиеся с busy
+func Subtract(free, busy []domain.Interval) []domain.Interval {
+	free, busy = Merge(free), Merge(busy)
+
+	result := make([]domain.Interval, 0, len(free))
+	j := 0
+	for _, iv := range free {
+		start := iv.Start
+		// занятые интервалы, закончившиеся до начала, уже не нужны
+		for j < len(busy) && !busy[j].End.After(start) {
+			j++
+		}
+		for k := j; k < len(busy) && busy[k].Start.Before(iv.End); k++ {
+			if busy[k].Start.After(start) {
+				result = append(result, domain.Interval{Start: start, End: busy[k].Start})
+			}
+			if busy[k].End.After(start) {
+				start = busy[k].End
+			}
+		}
+		if iv.End.After(start) {
+			result = append(result, domain.Interval{Start: start, End: iv.End})
+		}
+	}
+
+	return result
+}
+
+// Intersect - пересечение двух наборов интервалов
+func Intersect(a, b []domain.Interval) []domain.Interval {
+	a, b = Merge(a), Merge(b)
+
+	result := make([]domain.Interval, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start := later(a[i].Start, b[j].Start)
+		end := earlier(a[i].End, b[j].End)
+		if end.After(start) {
+			result = append(result, domain.Interval{Start: start, End: end})
+		}
+
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return result
+}
+
+// WorkingWindows - рабочее время в зоне loc, попадающее в [from, to).
+// Дни перебираются по местному календарю, поэтому переход на летнее время учитывается.
+func WorkingWindows(from, to time.Time, loc *time.Location, hours domain.WorkingHours) []domain.Interval {
+	windows := make([]domain.Interval, 0)
+
+	local := from.In(loc)
+	// начинаем с предыдущего дня: рабочее время может переходить через полночь
+	day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !hours.Weekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+
+		start := atOffset(day, hours.Start)
+		end := atOffset(day, hours.End)
+		start, end = later(start, from), earlier(end, to)
+		if end.After(start) {
+			windows = append(windows, domain.Interval{Start: start.UTC(), End: end.UTC()})
+		}
+	}
+
+	return windows
+}
+
+// Slots - интервалы не короче d
+func Slots(intervals []domain.Interval, d time.Duration) []domain.Interval {
+	slots := make([]domain.Interval, 0, len(intervals))
+	for _, iv := range intervals {
+		if iv.End.Sub(iv.Start) >= d {
+			slots = append(slots, iv)
+		}
+	}
+	return slots
+}
+
+// atOffset - местное время day + offset по часам, а не по прошедшему времени:
+// 9:00 остается 9:00 и в день перехода на летнее время
+func atOffset(day time.Time, offset time.Duration) time.Time {
+	h := int(offset / time.Hour)
+	m := int(offset % time.Hour / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
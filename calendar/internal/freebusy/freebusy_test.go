@@ -0,0 +1,89 @@
+package freebusy_test
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/freebusy"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func iv(from, to string) domain.Interval {
+	return domain.Interval{Start: dt(from), End: dt(to)}
+}
+
+func dt(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestMerge(t *testing.T) {
+	merged := freebusy.Merge([]domain.Interval{
+		iv("2025-01-13 12:00", "2025-01-13 13:00"),
+		iv("2025-01-13 09:00", "2025-01-13 10:00"),
+		// смежный интервал склеивается
+		iv("2025-01-13 10:00", "2025-01-13 10:30"),
+		// вложенный поглощается
+		iv("2025-01-13 12:15", "2025-01-13 12:45"),
+		// пустой отбрасывается
+		iv("2025-01-13 15:00", "2025-01-13 15:00"),
+	})
+	require.Equal(t, []domain.Interval{
+		iv("2025-01-13 09:00", "2025-01-13 10:30"),
+		iv("2025-01-13 12:00", "2025-01-13 13:00"),
+	}, merged)
+}
+
+func TestSubtractIntersect(t *testing.T) {
+	free := []domain.Interval{iv("2025-01-13 09:00", "2025-01-13 18:00")}
+	busy := []domain.Interval{
+		iv("2025-01-13 08:00", "2025-01-13 09:30"),
+		iv("2025-01-13 12:00", "2025-01-13 13:00"),
+		iv("2025-01-13 17:30", "2025-01-13 19:00"),
+	}
+
+	left := freebusy.Subtract(free, busy)
+	require.Equal(t, []domain.Interval{
+		iv("2025-01-13 09:30", "2025-01-13 12:00"),
+		iv("2025-01-13 13:00", "2025-01-13 17:30"),
+	}, left)
+
+	common := freebusy.Intersect(left, []domain.Interval{
+		iv("2025-01-13 11:00", "2025-01-13 14:00"),
+		iv("2025-01-13 17:00", "2025-01-13 20:00"),
+	})
+	require.Equal(t, []domain.Interval{
+		iv("2025-01-13 11:00", "2025-01-13 12:00"),
+		iv("2025-01-13 13:00", "2025-01-13 14:00"),
+		iv("2025-01-13 17:00", "2025-01-13 17:30"),
+	}, common)
+
+	require.Equal(t, []domain.Interval{
+		iv("2025-01-13 11:00", "2025-01-13 12:00"),
+		iv("2025-01-13 13:00", "2025-01-13 14:00"),
+	}, freebusy.Slots(common, time.Hour))
+}
+
+func TestWorkingWindows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// пятница, выходные и понедельник после перехода на летнее время 30 марта
+	windows := freebusy.WorkingWindows(dt("2025-03-28 10:00"), dt("2025-04-01 00:00"), berlin, domain.DefaultWorkingHours)
+	require.Equal(t, []domain.Interval{
+		iv("2025-03-28 10:00", "2025-03-28 17:00"),
+		iv("2025-03-31 07:00", "2025-03-31 16:00"),
+	}, windows)
+
+	// с выходными и рабочим временем до полуночи
+	hours := domain.WorkingHours{Start: 20 * time.Hour, End: 24 * time.Hour, Weekends: true}
+	windows = freebusy.WorkingWindows(dt("2025-03-29 00:00"), dt("2025-03-31 00:00"), berlin, hours)
+	require.Equal(t, []domain.Interval{
+		iv("2025-03-29 19:00", "2025-03-29 23:00"),
+		iv("2025-03-30 18:00", "2025-03-30 22:00"),
+	}, windows)
+}
@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SharingService - общие календари и приглашения
+type SharingService interface {
+	CreateCalendar(ctx context.Context, userID int64, name string) (domain.Calendar, error)
+	Calendars(ctx context.Context, userID int64) ([]domain.Calendar, error)
+	ShareCalendar(ctx context.Context, ownerID, calendarID, userID int64, perm domain.Permission) (domain.Share, error)
+	UnshareCalendar(ctx context.Context, ownerID, calendarID, userID int64) error
+	InviteToEvent(ctx context.Context, userID, eventID, attendeeID int64) (domain.Invitation, error)
+	RespondToInvitation(ctx context.Context, userID, eventID int64, status domain.RSVP) (domain.Invitation, error)
+	Invitations(ctx context.Context, userID int64) ([]domain.Invitation, error)
+	Attendees(ctx context.Context, userID, eventID int64) ([]domain.Invitation, error)
+	SetUserConflictPolicy(ctx context.Context, userID int64, policy domain.ConflictPolicy) (domain.User, error)
+	SetCalendarConflictPolicy(ctx context.Context, userID, calendarID int64, policy domain.ConflictPolicy) (domain.Calendar, error)
+}
+
+// Calendars - HTTP обработчики общих календарей и приглашений
+type Calendars struct {
+	svc SharingService
+	log *slog.Logger
+}
+
+// NewCalendars - конструктор
+func NewCalendars(svc SharingService, log *slog.Logger) *Calendars {
+	return &Calendars{svc: svc, log: log}
+}
+
+// Init - регистрирует маршруты
+func (h *Calendars) Init(r chi.Router) {
+	r.Post("/create_calendar", h.createCalendar)
+	r.Get("/calendars", h.calendars)
+	r.Post("/share_calendar", h.shareCalendar)
+	r.Post("/unshare_calendar", h.unshareCalendar)
+	r.Post("/set_conflict_policy", h.setConflictPolicy)
+	r.Post("/invite", h.invite)
+	r.Post("/respond_invitation", h.respond)
+	r.Get("/invitations", h.invitations)
+	r.Get("/attendees", h.attendees)
+}
+
+// sharingRequest - тело запросов к календарям и приглашениям
+type sharingRequest struct {
+	UserID     json.Number `json:"user_id"`
+	CalendarID json.Number `json:"calendar_id"`
+	EventID    json.Number `json:"event_id"`
+	// пользователь, которому открывается календарь или который приглашается
+	TargetID   json.Number `json:"target_id"`
+	Name       string      `json:"name"`
+	Permission string      `json:"permission"`
+	Status     string      `json:"status"`
+	// политика пересечений: warn или reject
+	Policy string `json:"policy"`
+}
+
+func decodeSharingRequest(r *http.Request) (sharingRequest, error) {
+	var req sharingRequest
+
+	if isJSON(r) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("%w: decode json: %w", errBadRequest, err)
+		}
+		return req, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return req, fmt.Errorf("%w: parse form: %w", errBadRequest, err)
+	}
+	req.UserID = json.Number(r.PostForm.Get("user_id"))
+	req.CalendarID = json.Number(r.PostForm.Get("calendar_id"))
+	req.EventID = json.Number(r.PostForm.Get("event_id"))
+	req.TargetID = json.Number(r.PostForm.Get("target_id"))
+	req.Name = r.PostForm.Get("name")
+	req.Permission = r.PostForm.Get("permission")
+	req.Status = r.PostForm.Get("status")
+	req.Policy = r.PostForm.Get("policy")
+
+	return req, nil
+}
+
+func (h *Calendars) createCalendar(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSharingRequest(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	userID, err := requestUserID(r.Context(), req.UserID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	cal, err := h.svc.CreateCalendar(r.Context(), userID, req.Name)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, cal)
+}
+
+// setConflictPolicy - политика пересечений календаря, а без calendar_id - личных событий
+func (h *Calendars) setConflictPolicy(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSharingRequest(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	userID, err := requestUserID(r.Context(), req.UserID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	policy := domain.ConflictPolicy(strings.TrimSpace(req.Policy))
+
+	if req.CalendarID == "" {
+		u, err := h.svc.SetUserConflictPolicy(r.Context(), userID, policy)
+		if err != nil {
+			writeError(r.Context(), w, h.log, err)
+			return
+		}
+		writeResult(w, u)
+		return
+	}
+
+	calendarID, err := parseID(req.CalendarID.String(), "calendar id")
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	cal, err := h.svc.SetCalendarConflictPolicy(r.Context(), userID, calendarID, policy)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, cal)
+}
+
+func (h *Calendars) calendars(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	calendars, err := h.svc.Calendars(r.Context(), userID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, calendars)
+}
+
+func (h *Calendars) shareCalendar(w http.ResponseWriter, r *http.Request) {
+	p, err := decodeShare(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	sh, err := h.svc.ShareCalendar(r.Context(), p.ownerID, p.calendarID, p.userID, p.permission)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, sh)
+}
+
+func (h *Calendars) unshareCalendar(w http.ResponseWriter, r *http.Request) {
+	p, err := decodeShare(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	if err := h.svc.UnshareCalendar(r.Context(), p.ownerID, p.calendarID, p.userID); err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, "calendar unshared")
+}
+
+// shareParams - параметры открытия или закрытия календаря
+type shareParams struct {
+	ownerID    int64
+	calendarID int64
+	userID     int64
+	permission domain.Permission
+}
+
+func decodeShare(r *http.Request) (shareParams, error) {
+	var p shareParams
+
+	req, err := decodeSharingRequest(r)
+	if err != nil {
+		return p, err
+	}
+	if p.ownerID, err = requestUserID(r.Context(), req.UserID.String()); err != nil {
+		return p, err
+	}
+	if p.calendarID, err = parseID(req.CalendarID.String(), "calendar id"); err != nil {
+		return p, err
+	}
+	if p.userID, err = parseUserID(req.TargetID.String()); err != nil {
+		return p, err
+	}
+	p.permission = domain.Permission(strings.ToLower(strings.TrimSpace(req.Permission)))
+
+	return p, nil
+}
+
+func (h *Calendars) invite(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSharingRequest(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	userID, err := requestUserID(r.Context(), req.UserID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	eventID, err := parseEventID(req.EventID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	attendeeID, err := parseUserID(req.TargetID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	inv, err := h.svc.InviteToEvent(r.Context(), userID, eventID, attendeeID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, inv)
+}
+
+func (h *Calendars) respond(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeSharingRequest(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	userID, err := requestUserID(r.Context(), req.UserID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	eventID, err := parseEventID(req.EventID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	status := domain.RSVP(strings.ToLower(strings.TrimSpace(req.Status)))
+	inv, err := h.svc.RespondToInvitation(r.Context(), userID, eventID, status)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, inv)
+}
+
+func (h *Calendars) invitations(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	invitations, err := h.svc.Invitations(r.Context(), userID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, invitations)
+}
+
+func (h *Calendars) attendees(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	eventID, err := parseEventID(r.URL.Query().Get("event_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	attendees, err := h.svc.Attendees(r.Context(), userID, eventID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, attendees)
+}
@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"Calendar/internal/handler"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarsHandler(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewCalendars(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.PostForm(srv.URL+"/create_calendar", url.Values{"user_id": {"1"}, "name": {"team"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 1, decode(t, resp)["result"].(map[string]any)["id"])
+
+	resp, err = http.Post(srv.URL+"/share_calendar", "application/json", strings.NewReader(
+		`{"user_id": 1, "calendar_id": 1, "target_id": 2, "permission": "write"}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	// участник с правом записи добавляет событие в общий календарь
+	resp, err = http.PostForm(srv.URL+"/create_event", url.Values{
+		"user_id": {"2"}, "calendar_id": {"1"}, "date": {"2025-01-15"}, "title": {"planning"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 1, decode(t, resp)["result"].(map[string]any)["user_id"])
+
+	resp, err = http.PostForm(srv.URL+"/invite", url.Values{"user_id": {"2"}, "event_id": {"1"}, "target_id": {"3"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "needs-action", decode(t, resp)["result"].(map[string]any)["status"])
+
+	resp, err = http.PostForm(srv.URL+"/respond_invitation", url.Values{"user_id": {"3"}, "event_id": {"1"}, "status": {"maybe"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.PostForm(srv.URL+"/respond_invitation", url.Values{"user_id": {"3"}, "event_id": {"1"}, "status": {"accepted"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/attendees?user_id=1&event_id=1")
+	require.NoError(t, err)
+	attendees := decode(t, resp)["result"].([]any)
+	require.Len(t, attendees, 1)
+	require.Equal(t, "accepted", attendees[0].(map[string]any)["status"])
+
+	for _, userID := range []string{"1", "2", "3"} {
+		resp, err = http.Get(srv.URL + "/events_for_day?date=2025-01-15&user_id=" + userID)
+		require.NoError(t, err)
+		require.Len(t, decode(t, resp)["result"], 1, userID)
+	}
+
+	resp, err = http.Get(srv.URL + "/calendars?user_id=2")
+	require.NoError(t, err)
+	require.Equal(t, "write", decode(t, resp)["result"].([]any)[0].(map[string]any)["permission"])
+}
+
+func TestConflictPolicyHandler(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewCalendars(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	create := func(start, end string) *http.Response {
+		resp, err := http.PostForm(srv.URL+"/create_event", url.Values{
+			"user_id": {"1"}, "title": {"meeting"}, "date": {start}, "end": {end},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := create("2025-01-15T10:00", "2025-01-15T11:00")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotContains(t, decode(t, resp), "conflicts")
+
+	// по умолчанию событие сохраняется, пересечения - предупреждение
+	resp = create("2025-01-15T10:30", "2025-01-15T11:30")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body := decode(t, resp)
+	require.EqualValues(t, 2, body["result"].(map[string]any)["id"])
+	require.Len(t, body["conflicts"], 1)
+	require.EqualValues(t, 1, body["conflicts"].([]any)[0].(map[string]any)["id"])
+
+	resp, err := http.PostForm(srv.URL+"/set_conflict_policy", url.Values{"user_id": {"1"}, "policy": {"never"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(srv.URL+"/set_conflict_policy", "application/json", strings.NewReader(`{"user_id": 1, "policy": "reject"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "reject", decode(t, resp)["result"].(map[string]any)["conflict_policy"])
+
+	resp = create("2025-01-15T10:45", "2025-01-15T12:00")
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	body = decode(t, resp)
+	require.Contains(t, body["error"], "conflicts")
+	require.Len(t, body["conflicts"], 2)
+
+	// чужой календарь
+	resp, err = http.PostForm(srv.URL+"/set_conflict_policy", url.Values{"user_id": {"1"}, "calendar_id": {"7"}, "policy": {"reject"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// EventService - сервис событий календаря
+type EventService interface {
+	CreateEvent(ctx context.Context, e domain.Event) (domain.Event, error)
+	UpdateEvent(ctx context.Context, e domain.Event) (domain.Event, error)
+	DeleteEvent(ctx context.Context, userID, eventID, version int64) error
+	DeleteOccurrence(ctx context.Context, userID, eventID int64, recurrenceID time.Time, version int64) error
+	EventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error)
+	EventsForWeek(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error)
+	EventsForMonth(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error)
+	UserLocation(ctx context.Context, userID int64) (*time.Location, error)
+	Subscribe(ctx context.Context, userID int64) (<-chan domain.Change, func(), error)
+	SearchEvents(ctx context.Context, q domain.SearchQuery) (domain.SearchPage, error)
+	EventConflicts(ctx context.Context, userID, eventID int64) ([]domain.Event, error)
+	RestoreEvent(ctx context.Context, userID, eventID int64) (domain.Event, error)
+	EventHistory(ctx context.Context, userID, eventID int64) ([]domain.AuditEntry, error)
+}
+
+// Events - HTTP обработчики событий
+type Events struct {
+	svc EventService
+	log *slog.Logger
+}
+
+// NewEvents - конструктор
+func NewEvents(svc EventService, log *slog.Logger) *Events {
+	return &Events{svc: svc, log: log}
+}
+
+// Init - регистрирует маршруты
+func (h *Events) Init(r chi.Router) {
+	r.Post("/create_event", h.createEvent)
+	r.Post("/update_event", h.updateEvent)
+	r.Post("/delete_event", h.deleteEvent)
+	r.Post("/restore_event", h.restoreEvent)
+	r.Get("/events_for_day", h.eventsFor(h.svc.EventsForDay))
+	r.Get("/events_for_week", h.eventsFor(h.svc.EventsForWeek))
+	r.Get("/events_for_month", h.eventsFor(h.svc.EventsForMonth))
+	r.Get("/events/stream", h.stream)
+	r.Get("/events/search", h.search)
+	r.Get("/events/{id}/history", h.history)
+}
+
+func (h *Events) createEvent(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeEventRequest(r, schemaCreateEvent)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	e, err := h.create(r.Context(), req)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	h.writeEvent(w, r, req, e)
+}
+
+func (h *Events) updateEvent(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeEventRequest(r, schemaUpdateEvent)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	if err := req.withIfMatch(r.Header); err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	e, err := h.update(r.Context(), req)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	h.writeEvent(w, r, req, e)
+}
+
+// writeEvent - отдает сохраненное событие (версия - в ETag) и пересекающиеся с ним события календаря.
+// Событие уже сохранено, поэтому ошибка поиска пересечений только логируется.
+func (h *Events) writeEvent(w http.ResponseWriter, r *http.Request, req eventRequest, e domain.Event) {
+	res := resultResponse{Result: e}
+	setETag(w, e)
+
+	// user_id уже проверен при сохранении
+	userID, _ := parseUserID(req.UserID)
+	conflicts, err := h.svc.EventConflicts(r.Context(), userID, e.ID)
+	if err != nil {
+		h.log.WarnContext(r.Context(), "event conflicts", slog.Int64("event_id", e.ID), slog.String("err", err.Error()))
+	} else {
+		res.Conflicts = conflicts
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (h *Events) deleteEvent(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeEventRequest(r, schemaDeleteEvent)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	if err := req.withIfMatch(r.Header); err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	msg, err := h.delete(r.Context(), req)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, msg)
+}
+
+// create - создает событие по разобранному запросу (общее для HTTP и JSON-RPC)
+func (h *Events) create(ctx context.Context, req eventRequest) (domain.Event, error) {
+	req.EventID = ""
+
+	loc, err := h.location(ctx, req)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	e, err := req.event(loc)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return h.svc.CreateEvent(ctx, e)
+}
+
+// update - изменяет событие или вхождение серии по разобранному запросу
+func (h *Events) update(ctx context.Context, req eventRequest) (domain.Event, error) {
+	if _, err := parseEventID(req.EventID); err != nil {
+		return domain.Event{}, err
+	}
+
+	loc, err := h.location(ctx, req)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	e, err := req.event(loc)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return h.svc.UpdateEvent(ctx, e)
+}
+
+// delete - удаляет событие или одно вхождение серии, возвращает сообщение для клиента
+func (h *Events) delete(ctx context.Context, req eventRequest) (string, error) {
+	userID, err := parseUserID(req.UserID)
+	if err != nil {
+		return "", err
+	}
+	eventID, err := parseEventID(req.EventID)
+	if err != nil {
+		return "", err
+	}
+	version, err := req.version()
+	if err != nil {
+		return "", err
+	}
+
+	if req.RecurrenceID != "" {
+		loc, err := h.location(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		recurrenceID, err := parseTime(req.RecurrenceID, loc)
+		if err != nil {
+			return "", domain.ErrInvalidRecurrence
+		}
+		if err := h.svc.DeleteOccurrence(ctx, userID, eventID, recurrenceID, version); err != nil {
+			return "", err
+		}
+		return "occurrence deleted", nil
+	}
+
+	if err := h.svc.DeleteEvent(ctx, userID, eventID, version); err != nil {
+		return "", err
+	}
+	return "event deleted", nil
+}
+
+// location - зона, в которой читается время без смещения:
+// зона из запроса, а если она не задана - зона пользователя
+func (h *Events) location(ctx context.Context, req eventRequest) (*time.Location, error) {
+	if tz := strings.TrimSpace(req.TimeZone); tz != "" {
+		return domain.LoadLocation(tz)
+	}
+
+	userID, err := parseUserID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return h.svc.UserLocation(ctx, userID)
+}
+
+type periodQuery func(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error)
+
+// eventsFor - обработчик выборки событий за период
+func (h *Events) eventsFor(query periodQuery) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+		if err != nil {
+			writeError(r.Context(), w, h.log, err)
+			return
+		}
+		date, err := parseTime(r.URL.Query().Get("date"), time.UTC)
+		if err != nil {
+			writeError(r.Context(), w, h.log, err)
+			return
+		}
+
+		events, err := query(r.Context(), userID, date)
+		if err != nil {
+			writeError(r.Context(), w, h.log, err)
+			return
+		}
+		writeResult(w, events)
+	}
+}
@@ -0,0 +1,352 @@
+package handler_test
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/handler"
+	"Calendar/internal/middleware"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	r := chi.NewRouter()
+	svc := service.New(memory.New())
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewUsers(svc, log).Init(r)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func decode(t *testing.T, resp *http.Response) map[string]any {
+	t.Helper()
+	defer resp.Body.Close()
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body
+}
+
+func TestEventsHandler(t *testing.T) {
+	srv := newServer(t)
+
+	// создание через form-urlencoded
+	resp, err := http.PostForm(srv.URL+"/create_event", url.Values{
+		"user_id": {"1"},
+		"date":    {"2025-01-15"},
+		"title":   {"standup"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	created := decode(t, resp)["result"].(map[string]any)
+	require.Equal(t, "standup", created["title"])
+
+	// изменение через JSON
+	resp, err = http.Post(srv.URL+"/update_event", "application/json", strings.NewReader(
+		`{"event_id": 1, "user_id": "1", "date": "2025-01-16", "title": "retro"}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "retro", decode(t, resp)["result"].(map[string]any)["title"])
+
+	resp, err = http.Get(srv.URL + "/events_for_week?user_id=1&date=2025-01-13")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, decode(t, resp)["result"], 1)
+
+	resp, err = http.Get(srv.URL + "/events_for_day?user_id=1&date=2025-01-15")
+	require.NoError(t, err)
+	require.Empty(t, decode(t, resp)["result"])
+
+	resp, err = http.PostForm(srv.URL+"/delete_event", url.Values{"user_id": {"1"}, "event_id": {"1"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	srv := newServer(t)
+
+	post := func(path, body, ifMatch string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post("/create_event", `{"user_id": 1, "date": "2025-01-15", "title": "standup"}`, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `"1"`, resp.Header.Get("ETag"))
+	require.EqualValues(t, 1, decode(t, resp)["result"].(map[string]any)["version"])
+
+	resp = post("/update_event", `{"event_id": 1, "user_id": 1, "date": "2025-01-15", "title": "retro"}`, `"1"`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	resp.Body.Close()
+
+	// изменение по устаревшей версии не перезаписывает событие
+	resp = post("/update_event", `{"event_id": 1, "user_id": 1, "date": "2025-01-15", "title": "lost"}`, `"1"`)
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	require.Equal(t, "event version mismatch", decode(t, resp)["error"])
+	resp = post("/delete_event", `{"event_id": 1, "user_id": 1, "version": 1}`, "")
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = post("/delete_event", `{"event_id": 1, "user_id": 1}`, "bad")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// If-Match заменяет версию из тела, * - любая версия
+	resp = post("/update_event", `{"event_id": 1, "user_id": 1, "date": "2025-01-15", "title": "sync", "version": 1}`, "*")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	resp.Body.Close()
+
+	resp, err := http.PostForm(srv.URL+"/delete_event", url.Values{"user_id": {"1"}, "event_id": {"1"}, "version": {"3"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestTimeZoneHandler(t *testing.T) {
+	srv := newServer(t)
+
+	resp, err := http.PostForm(srv.URL+"/set_time_zone", url.Values{"user_id": {"1"}, "time_zone": {"Nowhere/City"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(srv.URL+"/set_time_zone", "application/json", strings.NewReader(
+		`{"user_id": 1, "time_zone": "Europe/Moscow"}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/time_zone?user_id=1")
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Moscow", decode(t, resp)["result"].(map[string]any)["time_zone"])
+
+	// время без смещения - местное время пользователя, хранится в UTC
+	resp, err = http.PostForm(srv.URL+"/create_event", url.Values{
+		"user_id": {"1"}, "date": {"2025-01-15T10:00"}, "title": {"standup"},
+	})
+	require.NoError(t, err)
+	created := decode(t, resp)["result"].(map[string]any)
+	require.Equal(t, "2025-01-15T07:00:00Z", created["start"])
+	require.Equal(t, "Europe/Moscow", created["time_zone"])
+
+	// явная зона события
+	resp, err = http.PostForm(srv.URL+"/create_event", url.Values{
+		"user_id": {"1"}, "date": {"2025-01-15T10:00"}, "title": {"call"}, "time_zone": {"Asia/Novosibirsk"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "2025-01-15T03:00:00Z", decode(t, resp)["result"].(map[string]any)["start"])
+}
+
+func TestEventsHandlerErrors(t *testing.T) {
+	srv := newServer(t)
+
+	testCases := []struct {
+		name   string
+		do     func() (*http.Response, error)
+		status int
+	}{
+		{
+			name: "invalid date",
+			do: func() (*http.Response, error) {
+				return http.PostForm(srv.URL+"/create_event", url.Values{
+					"user_id": {"1"}, "date": {"15.01.2025"}, "title": {"x"},
+				})
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "empty title",
+			do: func() (*http.Response, error) {
+				return http.PostForm(srv.URL+"/create_event", url.Values{
+					"user_id": {"1"}, "date": {"2025-01-15"},
+				})
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "malformed json",
+			do: func() (*http.Response, error) {
+				return http.Post(srv.URL+"/create_event", "application/json", strings.NewReader("{"))
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "invalid user id in query",
+			do: func() (*http.Response, error) {
+				return http.Get(srv.URL + "/events_for_month?user_id=abc&date=2025-01-15")
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "unknown event",
+			do: func() (*http.Response, error) {
+				return http.PostForm(srv.URL+"/delete_event", url.Values{"user_id": {"1"}, "event_id": {"42"}})
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.do()
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+			require.NotEmpty(t, decode(t, resp)["error"])
+		})
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := chi.NewRouter()
+	r.Use(middleware.MaxBodySize(64))
+	svc := service.New(memory.New())
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewUsers(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	form := url.Values{"user_id": {"1"}, "date": {"2025-01-15"}, "title": {strings.Repeat("x", 100)}}.Encode()
+	testCases := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+	}{
+		{"form", "/create_event", "application/x-www-form-urlencoded", form},
+		{"json", "/create_event", "application/json", `{"user_id": 1, "title": "` + strings.Repeat("x", 100) + `"}`},
+		{"api key form", "/create_api_key", "application/x-www-form-urlencoded", "user_id=1&name=" + strings.Repeat("x", 100)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// длина тела неизвестна заранее: клиент отправляет его по частям
+			req, err := http.NewRequest(http.MethodPost, srv.URL+tt.path, io.MultiReader(strings.NewReader(tt.body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			require.Equal(t, int64(0), req.ContentLength)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+			require.NotEmpty(t, decode(t, resp)["error"])
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	srv := newServer(t)
+
+	resp, err := http.Post(srv.URL+"/create_event", "application/json", strings.NewReader(
+		`{"user_id": "1", "title": "", "date": "tomorrow", "colour": "red"}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	body := decode(t, resp)
+	require.Contains(t, body["error"], "validation failed")
+	require.Equal(t, []any{
+		map[string]any{"field": "colour", "message": "unknown field"},
+		map[string]any{"field": "date", "message": "has invalid format"},
+		map[string]any{"field": "title", "message": "must not be empty"},
+	}, body["fields"])
+
+	// форма проверяется по той же схеме
+	resp, err = http.PostForm(srv.URL+"/update_event", url.Values{"user_id": {"1"}, "title": {"x"}, "date": {"2025-01-15"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, []any{map[string]any{"field": "event_id", "message": "is required"}}, decode(t, resp)["fields"])
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	r := chi.NewRouter()
+	handler.NewOpenAPI().Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/openapi.json")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Equal(t, "3.1.0", decode(t, resp)["openapi"])
+}
+
+func TestAuthenticatedRequests(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	repo := memory.New()
+	svc := service.New(repo)
+
+	r := chi.NewRouter()
+	r.Use(middleware.Auth(auth.NewAuthenticator(secret, repo), log))
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewUsers(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	do := func(method, path, token string, body url.Values) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	alice, err := auth.IssueToken(secret, 1, time.Hour)
+	require.NoError(t, err)
+
+	resp := do(http.MethodGet, "/events_for_day?date=2025-01-15", "bad", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp.Body.Close()
+
+	// user_id берется из токена
+	resp = do(http.MethodPost, "/create_event", alice, url.Values{"date": {"2025-01-15"}, "title": {"standup"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 1, decode(t, resp)["result"].(map[string]any)["user_id"])
+
+	// чужой user_id запрещен
+	resp = do(http.MethodGet, "/events_for_day?user_id=2&date=2025-01-15", alice, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp.Body.Close()
+
+	// API ключ, выпущенный по JWT, работает как bearer токен
+	resp = do(http.MethodPost, "/create_api_key", alice, url.Values{"name": {"cli"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	key := decode(t, resp)["result"].(map[string]any)
+	require.NotContains(t, key, "hash")
+
+	resp = do(http.MethodGet, "/events_for_day?date=2025-01-15", key["token"].(string), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, decode(t, resp)["result"], 1)
+}
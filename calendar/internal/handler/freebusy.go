@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// FreeBusyService - занятость пользователей и поиск времени для встречи
+type FreeBusyService interface {
+	FreeBusy(ctx context.Context, requesterID int64, userIDs []int64, from, to time.Time) ([]domain.BusyTimes, error)
+	FindSlots(ctx context.Context, requesterID int64, q domain.SlotQuery) ([]domain.Interval, error)
+	UserLocation(ctx context.Context, userID int64) (*time.Location, error)
+}
+
+// FreeBusy - HTTP обработчики занятости и поиска времени для встречи
+type FreeBusy struct {
+	svc FreeBusyService
+	log *slog.Logger
+}
+
+// NewFreeBusy - конструктор
+func NewFreeBusy(svc FreeBusyService, log *slog.Logger) *FreeBusy {
+	return &FreeBusy{svc: svc, log: log}
+}
+
+// Init - регистрирует маршруты
+func (h *FreeBusy) Init(r chi.Router) {
+	r.Get("/free_busy", h.freeBusy)
+	r.Get("/find_slots", h.findSlots)
+}
+
+// rangeQuery - общие параметры: кто спрашивает, о ком и за какой период
+type rangeQuery struct {
+	requesterID int64
+	userIDs     []int64
+	from, to    time.Time
+}
+
+// parseRangeQuery - читает user_id, user_ids (через запятую), from и to.
+// Время без смещения считается местным в зоне запрашивающего.
+func (h *FreeBusy) parseRangeQuery(r *http.Request) (rangeQuery, error) {
+	var (
+		q     rangeQuery
+		err   error
+		query = r.URL.Query()
+	)
+
+	if q.requesterID, err = requestUserID(r.Context(), query.Get("user_id")); err != nil {
+		return q, err
+	}
+	if q.userIDs, err = parseUserIDs(query.Get("user_ids")); err != nil {
+		return q, err
+	}
+
+	loc, err := h.svc.UserLocation(r.Context(), q.requesterID)
+	if err != nil {
+		return q, err
+	}
+	if q.from, err = parseTime(query.Get("from"), loc); err != nil {
+		return q, err
+	}
+	if q.to, err = parseTime(query.Get("to"), loc); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+func (h *FreeBusy) freeBusy(w http.ResponseWriter, r *http.Request) {
+	q, err := h.parseRangeQuery(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	busy, err := h.svc.FreeBusy(r.Context(), q.requesterID, q.userIDs, q.from, q.to)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, busy)
+}
+
+func (h *FreeBusy) findSlots(w http.ResponseWriter, r *http.Request) {
+	q, err := h.parseRangeQuery(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	hours, err := parseWorkingHours(r.URL.Query())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(r.URL.Query().Get("duration")))
+	if err != nil {
+		writeError(r.Context(), w, h.log, fmt.Errorf("%w: invalid duration", domain.ErrInvalidSlotQuery))
+		return
+	}
+
+	slots, err := h.svc.FindSlots(r.Context(), q.requesterID, domain.SlotQuery{
+		UserIDs:      q.userIDs,
+		From:         q.from,
+		To:           q.to,
+		Duration:     duration,
+		WorkingHours: hours,
+	})
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, slots)
+}
+
+// parseUserIDs - список идентификаторов через запятую
+func parseUserIDs(s string) ([]int64, error) {
+	var ids []int64
+	for part := range strings.SplitSeq(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		id, err := parseUserID(part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, domain.ErrInvalidUserID
+	}
+	return ids, nil
+}
+
+// parseWorkingHours - work_start и work_end в формате "09:00" и флаг weekends;
+// незаданные параметры берутся из domain.DefaultWorkingHours
+func parseWorkingHours(query url.Values) (domain.WorkingHours, error) {
+	var (
+		hours = domain.DefaultWorkingHours
+		err   error
+	)
+
+	if s := query.Get("work_start"); s != "" {
+		if hours.Start, err = parseClock(s); err != nil {
+			return hours, err
+		}
+	}
+	if s := query.Get("work_end"); s != "" {
+		if hours.End, err = parseClock(s); err != nil {
+			return hours, err
+		}
+	}
+	if s := query.Get("weekends"); s != "" {
+		if hours.Weekends, err = strconv.ParseBool(s); err != nil {
+			return hours, fmt.Errorf("%w: invalid weekends", domain.ErrInvalidSlotQuery)
+		}
+	}
+
+	return hours, nil
+}
+
+// parseClock - время суток "15:04" как смещение от полуночи; "24:00" - конец дня
+func parseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time of day %q", domain.ErrInvalidSlotQuery, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,69 @@
+package handler_test
+
+import (
+	"Calendar/internal/handler"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeBusyHandler(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewUsers(svc, log).Init(r)
+	handler.NewFreeBusy(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.PostForm(srv.URL+"/set_time_zone", url.Values{"user_id": {"1"}, "time_zone": {"Europe/Moscow"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.PostForm(srv.URL+"/create_event", url.Values{
+		"user_id": {"2"}, "title": {"review"}, "date": {"2025-01-13T12:00:00Z"}, "end": {"2025-01-13T13:00:00Z"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/free_busy?user_id=1&user_ids=2&from=2025-01-13T00:00:00Z&to=2025-01-14T00:00:00Z")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	busy := decode(t, resp)["result"].([]any)[0].(map[string]any)["busy"].([]any)
+	require.Len(t, busy, 1)
+	require.Equal(t, "2025-01-13T12:00:00Z", busy[0].(map[string]any)["start"])
+
+	// время без смещения читается в зоне запрашивающего: понедельник по Москве
+	resp, err = http.Get(srv.URL + "/find_slots?user_id=1&user_ids=1,2&from=2025-01-13&to=2025-01-14&duration=150m")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	slots := decode(t, resp)["result"].([]any)
+	require.Len(t, slots, 1)
+	require.Equal(t, "2025-01-13T09:00:00Z", slots[0].(map[string]any)["start"])
+	require.Equal(t, "2025-01-13T12:00:00Z", slots[0].(map[string]any)["end"])
+
+	for _, query := range []string{
+		"user_id=1&user_ids=2&from=2025-01-13&to=2025-01-14&duration=soon",
+		"user_id=1&user_ids=2&from=2025-01-13&to=2025-01-14&duration=1h&work_start=25:00",
+		"user_id=1&user_ids=2&from=2025-01-13&to=2025-01-14&duration=1h&work_start=18:00&work_end=09:00",
+		"user_id=1&user_ids=x&from=2025-01-13&to=2025-01-14&duration=1h",
+		"user_id=1&user_ids=2&from=2025-01-14&to=2025-01-13&duration=1h",
+	} {
+		resp, err = http.Get(srv.URL + "/find_slots?" + query)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Events) restoreEvent(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeEventRequest(r, schemaRestoreEvent)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	e, err := h.restore(r.Context(), req)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	setETag(w, e)
+	writeResult(w, e)
+}
+
+// restore - восстанавливает удаленное событие по разобранному запросу
+func (h *Events) restore(ctx context.Context, req eventRequest) (domain.Event, error) {
+	userID, err := parseUserID(req.UserID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	eventID, err := parseEventID(req.EventID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return h.svc.RestoreEvent(ctx, userID, eventID)
+}
+
+// history - журнал изменений события: кто, когда и что изменил, с состоянием до и после
+func (h *Events) history(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	eventID, err := parseEventID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	entries, err := h.svc.EventHistory(r.Context(), userID, eventID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, entries)
+}
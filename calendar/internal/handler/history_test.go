@@ -0,0 +1,73 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreAndHistoryHandler(t *testing.T) {
+	srv := newServer(t)
+
+	resp, err := http.Post(srv.URL+"/create_event", "application/json", strings.NewReader(
+		`{"user_id": 1, "title": "Review", "date": "2025-01-15T14:00:00Z"}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	id := int64(decode(t, resp)["result"].(map[string]any)["id"].(float64))
+	event := url.Values{"user_id": {"1"}, "event_id": {fmt.Sprint(id)}}
+
+	resp, err = http.PostForm(srv.URL+"/restore_event", event)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "event is not deleted", decode(t, resp)["error"])
+
+	resp, err = http.PostForm(srv.URL+"/delete_event", event)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	history := func(query string) []any {
+		t.Helper()
+		resp, err := http.Get(fmt.Sprintf("%s/events/%d/history?%s", srv.URL, id, query))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return decode(t, resp)["result"].([]any)
+	}
+	entries := history("user_id=1")
+	require.Len(t, entries, 2)
+	deleted := entries[1].(map[string]any)
+	require.Equal(t, "deleted", deleted["action"])
+	require.Equal(t, "Review", deleted["before"].(map[string]any)["title"])
+	require.NotContains(t, deleted, "after")
+
+	resp, err = http.Post(srv.URL+"/restore_event", "application/json", strings.NewReader(
+		fmt.Sprintf(`{"user_id": 1, "event_id": %d}`, id),
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Review", decode(t, resp)["result"].(map[string]any)["title"])
+	require.Equal(t, "restored", history("user_id=1")[2].(map[string]any)["action"])
+
+	for path, status := range map[string]int{
+		"/events/abc/history?user_id=1":                 http.StatusBadRequest,
+		fmt.Sprintf("/events/%d/history?user_id=2", id): http.StatusServiceUnavailable,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode, path)
+		resp.Body.Close()
+	}
+
+	// схема запроса не допускает лишних полей
+	resp, err = http.Post(srv.URL+"/restore_event", "application/json", strings.NewReader(
+		fmt.Sprintf(`{"user_id": 1, "event_id": %d, "title": "x"}`, id),
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/ical"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportSize - максимальный размер импортируемого файла
+const maxImportSize = 10 << 20
+
+// ICalService - импорт и экспорт событий
+type ICalService interface {
+	ExportEvents(ctx context.Context, userID int64) ([]domain.Event, error)
+	ImportEvents(ctx context.Context, userID int64, events []domain.Event) (domain.ImportResult, error)
+}
+
+// ICal - HTTP обработчики импорта и экспорта в формате iCalendar
+type ICal struct {
+	svc ICalService
+	log *slog.Logger
+}
+
+// NewICal - конструктор
+func NewICal(svc ICalService, log *slog.Logger) *ICal {
+	return &ICal{svc: svc, log: log}
+}
+
+// Init - регистрирует маршруты
+func (h *ICal) Init(r chi.Router) {
+	r.Get("/export.ics", h.export)
+	r.Post("/import", h.importEvents)
+}
+
+func (h *ICal) export(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	events, err := h.svc.ExportEvents(r.Context(), userID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	if err := ical.Encode(w, events); err != nil {
+		h.log.ErrorContext(r.Context(), "failed to write calendar", slog.String("err", err.Error()))
+	}
+}
+
+// importEvents - принимает файл .ics телом запроса или полем file формы multipart
+func (h *ICal) importEvents(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(r.Context(), w, h.log, fmt.Errorf("%w: file: %w", errBadRequest, err))
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	rawUserID := r.URL.Query().Get("user_id")
+	if rawUserID == "" && mediaType == "multipart/form-data" {
+		rawUserID = r.FormValue("user_id")
+	}
+	userID, err := requestUserID(r.Context(), rawUserID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	events, skipped, err := ical.Decode(body)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	res, err := h.svc.ImportEvents(r.Context(), userID, events)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	// события, которые не удалось прочитать, идут первыми
+	res.Skipped = append(skipped, res.Skipped...)
+	writeResult(w, res)
+}
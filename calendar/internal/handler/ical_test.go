@@ -0,0 +1,109 @@
+package handler_test
+
+import (
+	"Calendar/internal/handler"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"bytes"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const calendarFile = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:retro@example.com\r\n" +
+	"DTSTART:20250110T150000Z\r\n" +
+	"DTEND:20250110T160000Z\r\n" +
+	"SUMMARY:Retro\r\n" +
+	"RRULE:FREQ=WEEKLY;INTERVAL=2\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:retro@example.com\r\n" +
+	"RECURRENCE-ID:20250124T150000Z\r\n" +
+	"DTSTART:20250124T170000Z\r\n" +
+	"DTEND:20250124T180000Z\r\n" +
+	"SUMMARY:Retro (late)\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestICalImportExport(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	handler.NewEvents(svc, log).Init(r)
+	handler.NewICal(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/import?user_id=1", "text/calendar", strings.NewReader(calendarFile))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, map[string]any{"created": 2.0, "updated": 0.0}, decode(t, resp)["result"])
+
+	// повторный импорт файлом формы обновляет те же события
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("user_id", "1"))
+	fw, err := mw.CreateFormFile("file", "calendar.ics")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(calendarFile))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	resp, err = http.Post(srv.URL+"/import", mw.FormDataContentType(), &body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, map[string]any{"created": 0.0, "updated": 2.0}, decode(t, resp)["result"])
+
+	resp, err = http.Get(srv.URL + "/events_for_month?user_id=1&date=2025-01-01")
+	require.NoError(t, err)
+	events := decode(t, resp)["result"].([]any)
+	require.Len(t, events, 2)
+	require.Equal(t, "Retro (late)", events[1].(map[string]any)["title"])
+
+	resp, err = http.Get(srv.URL + "/export.ics?user_id=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "text/calendar")
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "UID:retro@example.com")
+	require.Contains(t, string(data), "RRULE:FREQ=WEEKLY;INTERVAL=2")
+	require.Contains(t, string(data), "EXDATE:20250124T150000Z")
+	require.Contains(t, string(data), "RECURRENCE-ID:20250124T150000Z")
+
+	// неподдерживаемые события пропускаются, остальные импортируются
+	partial := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:zone@example.com\r\nDTSTART;TZID=Mars/Olympus:20250301T100000\r\nSUMMARY:Mars\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:rule@example.com\r\nDTSTART:20250301T100000Z\r\nRRULE:FREQ=HOURLY\r\nSUMMARY:Hourly\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:ok@example.com\r\nDTSTART:20250301T100000Z\r\nSUMMARY:Planning\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	resp, err = http.Post(srv.URL+"/import?user_id=1", "text/calendar", strings.NewReader(partial))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	result := decode(t, resp)["result"].(map[string]any)
+	require.Equal(t, 1.0, result["created"])
+	skipped := result["skipped"].([]any)
+	require.Len(t, skipped, 2)
+	require.Equal(t, "zone@example.com", skipped[0].(map[string]any)["uid"])
+	require.Contains(t, skipped[0].(map[string]any)["reason"], "unknown TZID")
+	require.Equal(t, "rule@example.com", skipped[1].(map[string]any)["uid"])
+	require.Contains(t, skipped[1].(map[string]any)["reason"], "HOURLY")
+
+	resp, err = http.Post(srv.URL+"/import?user_id=1", "text/calendar", strings.NewReader("BEGIN:VEVENT"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
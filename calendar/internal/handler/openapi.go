@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"Calendar/internal/openapi"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// OpenAPI - отдает описание API
+type OpenAPI struct{}
+
+// NewOpenAPI - конструктор
+func NewOpenAPI() *OpenAPI {
+	return &OpenAPI{}
+}
+
+// Init - регистрирует маршруты
+func (h *OpenAPI) Init(r chi.Router) {
+	r.Get("/openapi.json", h.document)
+}
+
+func (h *OpenAPI) document(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openapi.Document())
+}
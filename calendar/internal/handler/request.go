@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/internal/openapi"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventRequest - тело запроса на создание/изменение/удаление события
+type eventRequest struct {
+	EventID     string `json:"event_id"`
+	UserID      string `json:"user_id"`
+	CalendarID  string `json:"calendar_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Date        string `json:"date"`
+	End         string `json:"end"`
+	// длительность в формате Go, например "15m"
+	RemindBefore string `json:"remind_before"`
+	// правило повторения, например "FREQ=WEEKLY;BYDAY=MO,WE"
+	RRule string `json:"rrule"`
+	// начало вхождения серии, к которому относится изменение или удаление
+	RecurrenceID string `json:"recurrence_id"`
+	// IANA зона события, например "Europe/Moscow"; по умолчанию - зона пользователя
+	TimeZone string `json:"time_zone"`
+	// теги массивом или строкой через запятую
+	Tags tagList `json:"tags"`
+	// версия события, которую ожидает клиент; заголовок If-Match ее заменяет
+	Version string `json:"version"`
+}
+
+// tagList - теги события: массив строк JSON или строка с тегами через запятую
+type tagList []string
+
+// UnmarshalJSON - принимает массив или строку через запятую
+func (t *tagList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = splitTags(s)
+		return nil
+	}
+
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return err
+	}
+	*t = tags
+	return nil
+}
+
+// splitTags - теги из строк через запятую; пустые элементы пропускаются
+func splitTags(values ...string) []string {
+	var tags []string
+	for _, v := range values {
+		for tag := range strings.SplitSeq(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// UnmarshalJSON - принимает идентификаторы как строкой, так и числом
+func (r *eventRequest) UnmarshalJSON(data []byte) error {
+	type alias eventRequest
+	var raw struct {
+		alias
+		EventID    json.Number `json:"event_id"`
+		UserID     json.Number `json:"user_id"`
+		CalendarID json.Number `json:"calendar_id"`
+		Version    json.Number `json:"version"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = eventRequest(raw.alias)
+	r.EventID = raw.EventID.String()
+	r.UserID = raw.UserID.String()
+	r.CalendarID = raw.CalendarID.String()
+	r.Version = raw.Version.String()
+	return nil
+}
+
+// Схемы тел запросов из документа OpenAPI
+const (
+	schemaCreateEvent  = "CreateEventRequest"
+	schemaUpdateEvent  = "UpdateEventRequest"
+	schemaDeleteEvent  = "DeleteEventRequest"
+	schemaRestoreEvent = "RestoreEventRequest"
+	schemaPeriod       = "PeriodRequest"
+)
+
+// decodeEventRequest - читает тело запроса в формате JSON или form-urlencoded
+// и, если задана схема, проверяет его по схеме OpenAPI (ошибка - *openapi.ValidationError).
+// Если user_id не передан, подставляется аутентифицированный пользователь.
+func decodeEventRequest(r *http.Request, schema string) (eventRequest, error) {
+	req, err := decodeEventBody(r, schema)
+	if err != nil {
+		return req, err
+	}
+
+	req.withContextUser(r.Context())
+	return req, nil
+}
+
+// decodeEventParams - то же для параметров вызова JSON-RPC (объект JSON)
+func decodeEventParams(ctx context.Context, params []byte, schema string) (eventRequest, error) {
+	var req eventRequest
+
+	if err := validateJSON(schema, params); err != nil {
+		return req, err
+	}
+	if err := json.Unmarshal(params, &req); err != nil {
+		return req, fmt.Errorf("%w: decode params: %w", errBadRequest, err)
+	}
+
+	req.withContextUser(ctx)
+	return req, nil
+}
+
+// withContextUser - подставляет аутентифицированного пользователя, если user_id не передан
+func (r *eventRequest) withContextUser(ctx context.Context) {
+	if strings.TrimSpace(r.UserID) == "" {
+		if id, ok := auth.UserID(ctx); ok {
+			r.UserID = strconv.FormatInt(id, 10)
+		}
+	}
+}
+
+func decodeEventBody(r *http.Request, schema string) (eventRequest, error) {
+	var req eventRequest
+
+	if isJSON(r) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return req, fmt.Errorf("read body: %w", err)
+		}
+		if schema != "" {
+			if err := validateJSON(schema, data); err != nil {
+				return req, err
+			}
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return req, fmt.Errorf("%w: decode json: %w", errBadRequest, err)
+		}
+		return req, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return req, fmt.Errorf("%w: parse form: %w", errBadRequest, err)
+	}
+	if schema != "" {
+		fields := make(map[string]any, len(r.PostForm))
+		for name := range r.PostForm {
+			fields[name] = r.PostForm.Get(name)
+		}
+		if err := openapi.Validate(schema, fields); err != nil {
+			return req, err
+		}
+	}
+	req.EventID = r.PostForm.Get("event_id")
+	req.UserID = r.PostForm.Get("user_id")
+	req.CalendarID = r.PostForm.Get("calendar_id")
+	req.Title = r.PostForm.Get("title")
+	req.Description = r.PostForm.Get("description")
+	req.Date = r.PostForm.Get("date")
+	req.End = r.PostForm.Get("end")
+	req.RemindBefore = r.PostForm.Get("remind_before")
+	req.RRule = r.PostForm.Get("rrule")
+	req.RecurrenceID = r.PostForm.Get("recurrence_id")
+	req.TimeZone = r.PostForm.Get("time_zone")
+	req.Version = r.PostForm.Get("version")
+	// теги можно передать несколькими полями или одним через запятую
+	req.Tags = splitTags(r.PostForm["tags"]...)
+
+	return req, nil
+}
+
+// withIfMatch - версия из заголовка If-Match ("3" или 3) заменяет поле version;
+// "*" - подойдет любая версия существующего события
+func (r *eventRequest) withIfMatch(h http.Header) error {
+	tag := strings.TrimSpace(h.Get("If-Match"))
+	switch tag {
+	case "":
+		return nil
+	case "*":
+		r.Version = ""
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+	if _, err := parseVersion(tag); err != nil {
+		return fmt.Errorf("%w: invalid If-Match", errBadRequest)
+	}
+	r.Version = tag
+	return nil
+}
+
+// version - ожидаемая клиентом версия события, 0 - любая
+func (r eventRequest) version() (int64, error) {
+	if strings.TrimSpace(r.Version) == "" {
+		return 0, nil
+	}
+	return parseVersion(r.Version)
+}
+
+// validateJSON - проверяет тело JSON по схеме; числа сохраняются как json.Number
+func validateJSON(schema string, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var body any
+	if err := dec.Decode(&body); err != nil {
+		return fmt.Errorf("%w: decode json: %w", errBadRequest, err)
+	}
+	return openapi.Validate(schema, body)
+}
+
+// event - преобразует запрос в доменное событие.
+// Время без смещения считается местным временем в зоне loc.
+func (r eventRequest) event(loc *time.Location) (domain.Event, error) {
+	var (
+		e   domain.Event
+		err error
+	)
+
+	if e.UserID, err = parseUserID(r.UserID); err != nil {
+		return e, err
+	}
+	if r.EventID != "" {
+		if e.ID, err = parseEventID(r.EventID); err != nil {
+			return e, err
+		}
+	}
+	if r.CalendarID != "" {
+		if e.CalendarID, err = parseID(r.CalendarID, "calendar id"); err != nil {
+			return e, err
+		}
+	}
+	if e.Version, err = r.version(); err != nil {
+		return e, err
+	}
+	if e.Start, err = parseTime(r.Date, loc); err != nil {
+		return e, err
+	}
+	if r.End != "" {
+		if e.End, err = parseTime(r.End, loc); err != nil {
+			return e, err
+		}
+	}
+	if r.RemindBefore != "" {
+		if e.RemindBefore, err = time.ParseDuration(strings.TrimSpace(r.RemindBefore)); err != nil {
+			return e, domain.ErrInvalidReminder
+		}
+	}
+	if r.RecurrenceID != "" {
+		if e.RecurrenceID, err = parseTime(r.RecurrenceID, loc); err != nil {
+			return e, domain.ErrInvalidRecurrence
+		}
+	}
+	e.RRule = strings.TrimSpace(r.RRule)
+	e.TimeZone = strings.TrimSpace(r.TimeZone)
+	e.Title = r.Title
+	e.Description = r.Description
+	e.Tags = r.Tags
+
+	return e, nil
+}
+
+// isJSON - передано ли тело запроса в формате JSON
+func isJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// requestUserID - идентификатор пользователя из параметра запроса,
+// а если он не задан - аутентифицированный пользователь
+func requestUserID(ctx context.Context, s string) (int64, error) {
+	if strings.TrimSpace(s) == "" {
+		if id, ok := auth.UserID(ctx); ok {
+			return id, nil
+		}
+	}
+	return parseUserID(s)
+}
+
+func parseUserID(s string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, domain.ErrInvalidUserID
+	}
+	return id, nil
+}
+
+func parseEventID(s string) (int64, error) {
+	return parseID(s, "event id")
+}
+
+func parseVersion(s string) (int64, error) {
+	return parseID(s, "version")
+}
+
+// parseID - положительный идентификатор; name попадает в текст ошибки
+func parseID(s, name string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid %s", errBadRequest, name)
+	}
+	return id, nil
+}
+
+// timeLayouts - поддерживаемые форматы даты
+var timeLayouts = []string{
+	time.DateOnly,
+	time.RFC3339,
+	"2006-01-02T15:04",
+	time.DateTime,
+}
+
+// parseTime - разбирает время; время без смещения считается местным в зоне loc
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, domain.ErrInvalidDate
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/ical"
+	"Calendar/internal/openapi"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// errBadRequest - некорректное тело или параметры запроса
+var errBadRequest = errors.New("bad request")
+
+type resultResponse struct {
+	Result any `json:"result"`
+	// события, пересекающиеся с созданным или измененным (предупреждение)
+	Conflicts []domain.Event `json:"conflicts,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	// ошибки по полям тела запроса, если оно не прошло проверку по схеме
+	Fields []openapi.FieldError `json:"fields,omitempty"`
+	// события, из-за пересечения с которыми запрос отклонен
+	Conflicts []domain.Event `json:"conflicts,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	writeJSON(w, http.StatusOK, resultResponse{Result: result})
+}
+
+// setETag - версия события в заголовке ETag; клиент возвращает ее в If-Match при изменении
+func setETag(w http.ResponseWriter, e domain.Event) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(e.Version, 10)))
+}
+
+// writeError - отдает ошибку с кодом, соответствующим ее типу; внутренние ошибки логируются с полями запроса из ctx
+func writeError(ctx context.Context, w http.ResponseWriter, log *slog.Logger, err error) {
+	status := errorStatus(err)
+
+	var validationErr *openapi.ValidationError
+	if errors.As(err, &validationErr) {
+		writeJSON(w, status, errorResponse{Error: err.Error(), Fields: validationErr.Fields})
+		return
+	}
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		writeJSON(w, status, errorResponse{Error: err.Error(), Conflicts: conflictErr.Events})
+		return
+	}
+
+	msg := err.Error()
+	if status == http.StatusInternalServerError {
+		log.ErrorContext(ctx, "internal error", slog.String("err", msg))
+		msg = "internal error"
+	}
+
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// errorStatus - HTTP статус, соответствующий типу ошибки
+func errorStatus(err error) int {
+	var (
+		maxBytesErr   *http.MaxBytesError
+		validationErr *openapi.ValidationError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &validationErr),
+		errors.Is(err, errBadRequest),
+		errors.Is(err, domain.ErrInvalidDate),
+		errors.Is(err, domain.ErrEmptyTitle),
+		errors.Is(err, domain.ErrInvalidUserID),
+		errors.Is(err, domain.ErrInvalidReminder),
+		errors.Is(err, domain.ErrInvalidRecurrence),
+		errors.Is(err, domain.ErrInvalidTimeZone),
+		errors.Is(err, domain.ErrEmptyName),
+		errors.Is(err, domain.ErrInvalidPermission),
+		errors.Is(err, domain.ErrInvalidRSVP),
+		errors.Is(err, domain.ErrInvalidSlotQuery),
+		errors.Is(err, domain.ErrInvalidTags),
+		errors.Is(err, domain.ErrInvalidSearch),
+		errors.Is(err, domain.ErrInvalidConflictPolicy),
+		errors.Is(err, ical.ErrInvalidCalendar):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrEventNotFound),
+		errors.Is(err, domain.ErrAPIKeyNotFound),
+		errors.Is(err, domain.ErrCalendarNotFound),
+		errors.Is(err, domain.ErrNotShared),
+		errors.Is(err, domain.ErrInvitationNotFound),
+		errors.Is(err, domain.ErrNotDeleted),
+		errors.Is(err, domain.ErrRestoreExpired),
+		errors.Is(err, domain.ErrStreamClosed):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
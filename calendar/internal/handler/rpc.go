@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/openapi"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Коды ошибок JSON-RPC 2.0: стандартные и ошибки сервиса из диапазона -32000..-32099
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+
+	rpcUnauthorized = -32001
+	rpcForbidden    = -32003
+	// бизнес-ошибка, в HTTP - 503 (например, событие не найдено)
+	rpcUnavailable = -32004
+	// событие пересекается с другими, в HTTP - 409; data - пересекающиеся события
+	rpcConflict = -32009
+	// событие изменено после того, как клиент получил его версию, в HTTP - 412
+	rpcPreconditionFailed = -32012
+)
+
+// RPC - операции с событиями по JSON-RPC 2.0 поверх HTTP.
+// Методы и параметры совпадают с HTTP API: параметры - объект с теми же полями, что тело запроса.
+type RPC struct {
+	events  *Events
+	log     *slog.Logger
+	methods map[string]rpcMethod
+}
+
+type rpcMethod func(ctx context.Context, params json.RawMessage) (any, error)
+
+// NewRPC - конструктор
+func NewRPC(svc EventService, log *slog.Logger) *RPC {
+	h := &RPC{events: NewEvents(svc, log), log: log}
+	h.methods = map[string]rpcMethod{
+		"events.create":   h.create,
+		"events.update":   h.update,
+		"events.delete":   h.delete,
+		"events.restore":  h.restore,
+		"events.forDay":   h.period(svc.EventsForDay),
+		"events.forWeek":  h.period(svc.EventsForWeek),
+		"events.forMonth": h.period(svc.EventsForMonth),
+	}
+	return h
+}
+
+// Init - регистрирует маршруты
+func (h *RPC) Init(r chi.Router) {
+	r.Post("/rpc", h.serve)
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	// отсутствует у уведомлений, на которые ответ не отправляется
+	ID json.RawMessage `json:"id"`
+}
+
+// rpcResponse - ответ на вызов: с Error - ошибка, иначе - успех с Result
+type rpcResponse struct {
+	JSONRPC string
+	Result  any
+	Error   *rpcError
+	ID      json.RawMessage
+}
+
+// MarshalJSON - успешный ответ всегда содержит result (null, если метод ничего не вернул),
+// ответ с ошибкой - только error
+func (r rpcResponse) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Error   *rpcError       `json:"error"`
+			ID      json.RawMessage `json:"id"`
+		}{r.JSONRPC, r.Error, r.ID})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  any             `json:"result"`
+		ID      json.RawMessage `json:"id"`
+	}{r.JSONRPC, r.Result, r.ID})
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// nullID - id ответа, если id запроса определить не удалось
+var nullID = json.RawMessage("null")
+
+// serve - принимает одиночный вызов или пакет (массив) вызовов
+func (h *RPC) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(r.Context(), w, h.log, fmt.Errorf("read body: %w", err))
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			h.write(w, rpcFailure(nullID, rpcParseError, "parse error", nil))
+			return
+		}
+		if len(batch) == 0 {
+			h.write(w, rpcFailure(nullID, rpcInvalidRequest, "invalid request", nil))
+			return
+		}
+
+		responses := make([]rpcResponse, 0, len(batch))
+		for _, raw := range batch {
+			if resp, ok := h.call(r.Context(), raw); ok {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.write(w, responses)
+		return
+	}
+
+	if !json.Valid(body) {
+		h.write(w, rpcFailure(nullID, rpcParseError, "parse error", nil))
+		return
+	}
+	resp, ok := h.call(r.Context(), body)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	h.write(w, resp)
+}
+
+// call - выполняет один вызов; false - это уведомление и ответ не нужен
+func (h *RPC) call(ctx context.Context, raw json.RawMessage) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcFailure(nullID, rpcInvalidRequest, "invalid request", nil), true
+	}
+
+	id := req.ID
+	notification := id == nil
+	if notification {
+		id = nullID
+	}
+
+	method, ok := h.methods[req.Method]
+	if !ok {
+		return rpcFailure(id, rpcMethodNotFound, "method not found", nil), !notification
+	}
+
+	// параметры передаются только по имени
+	params := bytes.TrimSpace(req.Params)
+	if len(params) == 0 || bytes.Equal(params, nullID) {
+		params = []byte("{}")
+	}
+	if params[0] != '{' {
+		return rpcFailure(id, rpcInvalidParams, "params must be an object", nil), !notification
+	}
+
+	result, err := method(ctx, params)
+	if err != nil {
+		return h.failure(ctx, id, err), !notification
+	}
+	return rpcResponse{JSONRPC: "2.0", Result: result, ID: id}, !notification
+}
+
+// failure - ошибка сервиса в терминах JSON-RPC; соответствие то же, что у HTTP статусов
+func (h *RPC) failure(ctx context.Context, id json.RawMessage, err error) rpcResponse {
+	var validationErr *openapi.ValidationError
+	if errors.As(err, &validationErr) {
+		return rpcFailure(id, rpcInvalidParams, err.Error(), validationErr.Fields)
+	}
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		return rpcFailure(id, rpcConflict, err.Error(), conflictErr.Events)
+	}
+
+	switch errorStatus(err) {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return rpcFailure(id, rpcInvalidParams, err.Error(), nil)
+	case http.StatusUnauthorized:
+		return rpcFailure(id, rpcUnauthorized, err.Error(), nil)
+	case http.StatusForbidden:
+		return rpcFailure(id, rpcForbidden, err.Error(), nil)
+	case http.StatusPreconditionFailed:
+		return rpcFailure(id, rpcPreconditionFailed, err.Error(), nil)
+	case http.StatusServiceUnavailable:
+		return rpcFailure(id, rpcUnavailable, err.Error(), nil)
+	}
+
+	h.log.ErrorContext(ctx, "internal error", slog.String("err", err.Error()))
+	return rpcFailure(id, rpcInternalError, "internal error", nil)
+}
+
+func rpcFailure(id json.RawMessage, code int, msg string, data any) rpcResponse {
+	return rpcResponse{JSONRPC: "2.0", Error: &rpcError{Code: code, Message: msg, Data: data}, ID: id}
+}
+
+// write - ответы JSON-RPC всегда 200: ошибка передается в теле
+func (h *RPC) write(w http.ResponseWriter, v any) {
+	writeJSON(w, http.StatusOK, v)
+}
+
+func (h *RPC) create(ctx context.Context, params json.RawMessage) (any, error) {
+	req, err := decodeEventParams(ctx, params, schemaCreateEvent)
+	if err != nil {
+		return nil, err
+	}
+	return h.events.create(ctx, req)
+}
+
+func (h *RPC) update(ctx context.Context, params json.RawMessage) (any, error) {
+	req, err := decodeEventParams(ctx, params, schemaUpdateEvent)
+	if err != nil {
+		return nil, err
+	}
+	return h.events.update(ctx, req)
+}
+
+func (h *RPC) delete(ctx context.Context, params json.RawMessage) (any, error) {
+	req, err := decodeEventParams(ctx, params, schemaDeleteEvent)
+	if err != nil {
+		return nil, err
+	}
+	return h.events.delete(ctx, req)
+}
+
+func (h *RPC) restore(ctx context.Context, params json.RawMessage) (any, error) {
+	req, err := decodeEventParams(ctx, params, schemaRestoreEvent)
+	if err != nil {
+		return nil, err
+	}
+	return h.events.restore(ctx, req)
+}
+
+// period - метод выборки событий за период: параметры user_id и date
+func (h *RPC) period(query periodQuery) rpcMethod {
+	return func(ctx context.Context, params json.RawMessage) (any, error) {
+		req, err := decodeEventParams(ctx, params, schemaPeriod)
+		if err != nil {
+			return nil, err
+		}
+
+		userID, err := parseUserID(req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		date, err := parseTime(req.Date, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+
+		events, err := query(ctx, userID, date)
+		if err != nil {
+			return nil, err
+		}
+		// пустой день - пустой массив, а не null
+		if events == nil {
+			events = []domain.Event{}
+		}
+		return events, nil
+	}
+}
@@ -0,0 +1,138 @@
+package handler_test
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/internal/handler"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPC(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	handler.NewRPC(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	call := func(body string) (int, any) {
+		resp, err := http.Post(srv.URL+"/rpc", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var v any
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+		}
+		return resp.StatusCode, v
+	}
+
+	_, resp := call(`{"jsonrpc": "2.0", "id": 1, "method": "events.create",
+		"params": {"user_id": 1, "title": "standup", "date": "2025-01-15T10:00:00Z"}}`)
+	result := resp.(map[string]any)
+	require.EqualValues(t, 1, result["id"])
+	require.Equal(t, "standup", result["result"].(map[string]any)["title"])
+
+	_, resp = call(`{"jsonrpc": "2.0", "id": "day", "method": "events.forDay", "params": {"user_id": 1, "date": "2025-01-15"}}`)
+	result = resp.(map[string]any)
+	require.Equal(t, "day", result["id"])
+	require.Len(t, result["result"], 1)
+
+	// успешный ответ содержит result, даже если событий нет
+	_, resp = call(`{"jsonrpc": "2.0", "id": 3, "method": "events.forDay", "params": {"user_id": 1, "date": "2025-01-16"}}`)
+	result = resp.(map[string]any)
+	require.Contains(t, result, "result")
+	require.Equal(t, []any{}, result["result"])
+	require.NotContains(t, result, "error")
+
+	// ошибка проверки параметров - с полями, как в HTTP API
+	_, resp = call(`{"jsonrpc": "2.0", "id": 2, "method": "events.update", "params": {"user_id": 1, "title": "x"}}`)
+	require.NotContains(t, resp.(map[string]any), "result")
+	rpcErr := resp.(map[string]any)["error"].(map[string]any)
+	require.EqualValues(t, -32602, rpcErr["code"])
+	require.Equal(t, []any{
+		map[string]any{"field": "event_id", "message": "is required"},
+		map[string]any{"field": "date", "message": "is required"},
+	}, rpcErr["data"])
+
+	// пакет: уведомление без ответа, бизнес-ошибка и неизвестный метод
+	_, resp = call(`[
+		{"jsonrpc": "2.0", "method": "events.create", "params": {"user_id": 1, "title": "note", "date": "2025-01-16"}},
+		{"jsonrpc": "2.0", "id": 3, "method": "events.delete", "params": {"user_id": 1, "event_id": 42}},
+		{"jsonrpc": "2.0", "id": 4, "method": "events.rename"},
+		{"jsonrpc": "1.0", "id": 5, "method": "events.forDay"}
+	]`)
+	batch := resp.([]any)
+	require.Len(t, batch, 3)
+	require.EqualValues(t, -32004, batch[0].(map[string]any)["error"].(map[string]any)["code"])
+	require.EqualValues(t, -32601, batch[1].(map[string]any)["error"].(map[string]any)["code"])
+	require.EqualValues(t, -32600, batch[2].(map[string]any)["error"].(map[string]any)["code"])
+
+	status, _ := call(`{"jsonrpc": "2.0", "method": "events.delete", "params": {"user_id": 1, "event_id": 2}}`)
+	require.Equal(t, http.StatusNoContent, status)
+	_, resp = call(`{"jsonrpc": "2.0", "id": 6, "method": "events.delete", "params": {"user_id": 1, "event_id": 2}}`)
+	require.EqualValues(t, -32004, resp.(map[string]any)["error"].(map[string]any)["code"])
+
+	_, resp = call(`{"jsonrpc": "2.0", "id": 7, "method": "events.forDay", "params": [1, "2025-01-15"]}`)
+	require.EqualValues(t, -32602, resp.(map[string]any)["error"].(map[string]any)["code"])
+	_, resp = call(`{"jsonrpc": "2.0", "id": 8,`)
+	require.EqualValues(t, -32700, resp.(map[string]any)["error"].(map[string]any)["code"])
+	require.Nil(t, resp.(map[string]any)["id"])
+
+	// отклоненное пересечение - пересекающиеся события в data
+	_, err := svc.SetUserConflictPolicy(context.Background(), 1, domain.ConflictReject)
+	require.NoError(t, err)
+	call(`{"jsonrpc": "2.0", "id": 9, "method": "events.create",
+		"params": {"user_id": 1, "title": "review", "date": "2025-01-20T10:00:00Z", "end": "2025-01-20T11:00:00Z"}}`)
+	_, resp = call(`{"jsonrpc": "2.0", "id": 10, "method": "events.create",
+		"params": {"user_id": 1, "title": "call", "date": "2025-01-20T10:30:00Z", "end": "2025-01-20T12:00:00Z"}}`)
+	rpcErr = resp.(map[string]any)["error"].(map[string]any)
+	require.EqualValues(t, -32009, rpcErr["code"])
+	require.Len(t, rpcErr["data"], 1)
+
+	// изменение по устаревшей версии
+	_, resp = call(`{"jsonrpc": "2.0", "id": 11, "method": "events.update",
+		"params": {"user_id": 1, "event_id": 1, "title": "standup", "date": "2025-01-15T10:00:00Z", "version": 2}}`)
+	require.EqualValues(t, -32012, resp.(map[string]any)["error"].(map[string]any)["code"])
+}
+
+func TestRPCAuthenticatedUser(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), 2)))
+		})
+	})
+	handler.NewRPC(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	// пользователь берется из аутентификации, чужой - запрещен
+	resp, err := http.Post(srv.URL+"/rpc", "application/json", strings.NewReader(
+		`[{"jsonrpc": "2.0", "id": 1, "method": "events.create", "params": {"title": "mine", "date": "2025-01-15"}},
+		  {"jsonrpc": "2.0", "id": 2, "method": "events.forDay", "params": {"user_id": 1, "date": "2025-01-15"}}]`,
+	))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var batch []map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&batch))
+	require.EqualValues(t, 2, batch[0]["result"].(map[string]any)["user_id"])
+	require.EqualValues(t, -32003, batch[1]["error"].(map[string]any)["code"])
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// search - поиск событий: q - текст в названии или описании, from и to - границы начала события
+// (время без смещения - в зоне пользователя), tags - теги через запятую (или несколько параметров),
+// limit - размер страницы, cursor - курсор следующей страницы из ответа
+func (h *Events) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := requestUserID(r.Context(), query.Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	q := domain.SearchQuery{
+		UserID: userID,
+		Text:   query.Get("q"),
+		Tags:   splitTags(query["tags"]...),
+		Cursor: strings.TrimSpace(query.Get("cursor")),
+	}
+	if s := strings.TrimSpace(query.Get("limit")); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil {
+			writeError(r.Context(), w, h.log, fmt.Errorf("%w: invalid limit", domain.ErrInvalidSearch))
+			return
+		}
+	}
+	if query.Get("from") != "" || query.Get("to") != "" {
+		if q.From, q.To, err = h.searchRange(r, userID); err != nil {
+			writeError(r.Context(), w, h.log, err)
+			return
+		}
+	}
+
+	page, err := h.svc.SearchEvents(r.Context(), q)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, page)
+}
+
+// searchRange - границы from и to; незаданная граница остается нулевой
+func (h *Events) searchRange(r *http.Request, userID int64) (from, to time.Time, err error) {
+	loc, err := h.svc.UserLocation(r.Context(), userID)
+	if err != nil {
+		return from, to, err
+	}
+
+	query := r.URL.Query()
+	if s := query.Get("from"); s != "" {
+		if from, err = parseTime(s, loc); err != nil {
+			return from, to, err
+		}
+	}
+	if s := query.Get("to"); s != "" {
+		if to, err = parseTime(s, loc); err != nil {
+			return from, to, err
+		}
+	}
+	return from, to, nil
+}
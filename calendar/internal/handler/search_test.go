@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchHandler(t *testing.T) {
+	srv := newServer(t)
+
+	// теги через JSON массивом, через форму - строкой через запятую
+	resp, err := http.Post(srv.URL+"/create_event", "application/json", strings.NewReader(
+		`{"user_id": 1, "title": "Standup", "date": "2025-01-15T09:00:00Z", "tags": ["Work", "daily"]}`,
+	))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []any{"daily", "work"}, decode(t, resp)["result"].(map[string]any)["tags"])
+
+	for _, e := range []url.Values{
+		{"user_id": {"1"}, "title": {"Review"}, "description": {"after standup"}, "date": {"2025-01-15T14:00:00Z"}, "tags": {"work, review"}},
+		{"user_id": {"1"}, "title": {"Dentist"}, "date": {"2025-01-20T09:00:00Z"}},
+	} {
+		resp, err := http.PostForm(srv.URL+"/create_event", e)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		resp.Body.Close()
+	}
+
+	search := func(query string) map[string]any {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/events/search?" + query)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return decode(t, resp)["result"].(map[string]any)
+	}
+	titles := func(result map[string]any) []string {
+		var titles []string
+		for _, e := range result["events"].([]any) {
+			titles = append(titles, e.(map[string]any)["title"].(string))
+		}
+		return titles
+	}
+
+	require.Equal(t, []string{"Standup", "Review"}, titles(search("user_id=1&q=STANDUP")))
+	require.Equal(t, []string{"Review"}, titles(search("user_id=1&tags=work,review")))
+	require.Equal(t, []string{"Review"}, titles(search("user_id=1&tags=work&tags=review")))
+	require.Equal(t, []string{"Review", "Dentist"}, titles(search("user_id=1&from=2025-01-15T12:00:00Z")))
+	require.Empty(t, search("user_id=1&q=nothing")["events"])
+
+	first := search("user_id=1&limit=2")
+	require.Equal(t, []string{"Standup", "Review"}, titles(first))
+	cursor := first["next_cursor"].(string)
+	second := search("user_id=1&limit=2&cursor=" + url.QueryEscape(cursor))
+	require.Equal(t, []string{"Dentist"}, titles(second))
+	require.Nil(t, second["next_cursor"])
+
+	for _, query := range []string{
+		"user_id=1&limit=many",
+		"user_id=1&limit=0&cursor=bad!",
+		"user_id=1&from=yesterday",
+		"user_id=1&from=2025-01-16&to=2025-01-15",
+	} {
+		resp, err := http.Get(srv.URL + "/events/search?" + query)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// streamKeepAlive - период комментариев-пингов, чтобы прокси не закрывали простаивающий поток
+const streamKeepAlive = 25 * time.Second
+
+// streamRetry - через сколько миллисекунд браузер переподключается после обрыва
+const streamRetry = 3000
+
+// stream - поток Server-Sent Events с изменениями событий пользователя.
+// Каждое уведомление - событие SSE с именем created, updated или deleted и domain.Change в data.
+func (h *Events) stream(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	changes, cancel, err := h.svc.Subscribe(r.Context(), userID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	defer cancel()
+
+	// поток живет дольше WriteTimeout сервера
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-changes:
+			// подписку закрыли: сервер останавливается или клиент не успевал читать
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				h.log.ErrorContext(r.Context(), "failed to encode change", slog.String("err", err.Error()))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Type, data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"Calendar/internal/handler"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"bufio"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventsStream(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	svc := service.New(memory.New())
+
+	r := chi.NewRouter()
+	handler.NewEvents(svc, log).Init(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/events/stream?user_id=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		require.True(t, lines.Scan())
+		return lines.Text()
+	}
+	require.Equal(t, "retry: 3000", next())
+	require.Empty(t, next())
+
+	created, err := http.PostForm(srv.URL+"/create_event", url.Values{"user_id": {"1"}, "date": {"2025-01-15"}, "title": {"standup"}})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, created.StatusCode)
+	created.Body.Close()
+
+	require.Equal(t, "event: created", next())
+	data, ok := strings.CutPrefix(next(), "data: ")
+	require.True(t, ok)
+	var change map[string]any
+	require.NoError(t, json.Unmarshal([]byte(data), &change))
+	require.Equal(t, "standup", change["event"].(map[string]any)["title"])
+	require.Empty(t, next())
+
+	// остановка сервиса завершает поток
+	svc.CloseSubscriptions()
+	require.False(t, lines.Scan())
+
+	resp, err = http.Get(srv.URL + "/events/stream?user_id=1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp.Body.Close()
+}
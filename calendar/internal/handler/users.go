@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// UserService - настройки пользователей
+type UserService interface {
+	User(ctx context.Context, userID int64) (domain.User, error)
+	SetUserTimeZone(ctx context.Context, userID int64, timeZone string) (domain.User, error)
+	CreateAPIKey(ctx context.Context, userID int64, name string) (domain.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, userID int64, keyID string) error
+}
+
+// Users - HTTP обработчики настроек пользователя
+type Users struct {
+	svc UserService
+	log *slog.Logger
+}
+
+// NewUsers - конструктор
+func NewUsers(svc UserService, log *slog.Logger) *Users {
+	return &Users{svc: svc, log: log}
+}
+
+// Init - регистрирует маршруты
+func (h *Users) Init(r chi.Router) {
+	r.Get("/time_zone", h.timeZone)
+	r.Post("/set_time_zone", h.setTimeZone)
+	r.Post("/create_api_key", h.createAPIKey)
+	r.Post("/revoke_api_key", h.revokeAPIKey)
+}
+
+func (h *Users) timeZone(w http.ResponseWriter, r *http.Request) {
+	userID, err := requestUserID(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	u, err := h.svc.User(r.Context(), userID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, u)
+}
+
+func (h *Users) setTimeZone(w http.ResponseWriter, r *http.Request) {
+	// тело в том же формате, что и у событий: user_id и time_zone
+	req, err := decodeEventRequest(r, "")
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	userID, err := parseUserID(req.UserID)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	u, err := h.svc.SetUserTimeZone(r.Context(), userID, strings.TrimSpace(req.TimeZone))
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, u)
+}
+
+// apiKeyRequest - тело запроса на выпуск или отзыв API ключа
+type apiKeyRequest struct {
+	UserID json.Number `json:"user_id"`
+	Name   string      `json:"name"`
+	KeyID  string      `json:"key_id"`
+}
+
+// apiKeyResponse - выпущенный ключ; token показывается только один раз
+type apiKeyResponse struct {
+	domain.APIKey
+	Token string `json:"token"`
+}
+
+func decodeAPIKeyRequest(r *http.Request) (apiKeyRequest, error) {
+	var req apiKeyRequest
+
+	if isJSON(r) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, fmt.Errorf("%w: decode json: %w", errBadRequest, err)
+		}
+		return req, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return req, fmt.Errorf("%w: parse form: %w", errBadRequest, err)
+	}
+	req.UserID = json.Number(r.PostForm.Get("user_id"))
+	req.Name = r.PostForm.Get("name")
+	req.KeyID = r.PostForm.Get("key_id")
+
+	return req, nil
+}
+
+func (h *Users) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAPIKeyRequest(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	userID, err := requestUserID(r.Context(), req.UserID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	key, token, err := h.svc.CreateAPIKey(r.Context(), userID, req.Name)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, apiKeyResponse{APIKey: key, Token: token})
+}
+
+func (h *Users) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAPIKeyRequest(r)
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+
+	userID, err := requestUserID(r.Context(), req.UserID.String())
+	if err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	keyID := strings.TrimSpace(req.KeyID)
+	if keyID == "" {
+		writeError(r.Context(), w, h.log, fmt.Errorf("%w: key_id is required", errBadRequest))
+		return
+	}
+
+	if err := h.svc.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		writeError(r.Context(), w, h.log, err)
+		return
+	}
+	writeResult(w, "api key revoked")
+}
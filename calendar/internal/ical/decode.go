@@ -0,0 +1,300 @@
+package ical
+
+import (
+	"Calendar/internal/domain"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCalendar - некорректный файл iCalendar
+var ErrInvalidCalendar = errors.New("invalid icalendar")
+
+// property - строка контента: NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode - читает события VEVENT из файла iCalendar. UserID в событиях не заполняется.
+// VEVENT с неподдерживаемым значением свойства (например, неизвестным TZID) не прерывает
+// чтение, а возвращается в skipped; ошибка - только для нарушенной структуры файла.
+func Decode(r io.Reader) (events []domain.Event, skipped []domain.ImportSkip, err error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		current *domain.Event
+		// первая ошибка в свойствах текущего VEVENT
+		currentErr error
+		inAlarm    bool
+		depth      int
+	)
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCalendar, i+1, err)
+		}
+
+		switch {
+		case prop.name == "BEGIN":
+			depth++
+			switch strings.ToUpper(prop.value) {
+			case "VEVENT":
+				current, currentErr = &domain.Event{}, nil
+			case "VALARM":
+				inAlarm = current != nil
+			}
+			continue
+		case prop.name == "END":
+			depth--
+			switch strings.ToUpper(prop.value) {
+			case "VEVENT":
+				if current == nil {
+					return nil, nil, fmt.Errorf("%w: line %d: unexpected END:VEVENT", ErrInvalidCalendar, i+1)
+				}
+				if currentErr != nil {
+					skipped = append(skipped, domain.ImportSkip{UID: current.UID, Reason: currentErr.Error()})
+					current = nil
+					continue
+				}
+				if current.End.IsZero() {
+					current.End = current.Start
+					// событие на день без DTEND длится этот день (RFC 5545, 3.6.1)
+					if current.AllDay {
+						current.End = current.Start.AddDate(0, 0, 1)
+					}
+				}
+				events = append(events, *current)
+				current = nil
+			case "VALARM":
+				inAlarm = false
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		if inAlarm {
+			if prop.name == "TRIGGER" {
+				// поддерживается только напоминание относительно начала события
+				if d, err := parseDuration(prop.value); err == nil && d <= 0 && prop.params["RELATED"] != "END" {
+					current.RemindBefore = -d
+				}
+			}
+			continue
+		}
+
+		if err := applyProperty(current, prop); err != nil && currentErr == nil {
+			currentErr = fmt.Errorf("line %d: %v", i+1, err)
+		}
+	}
+
+	if depth != 0 || current != nil {
+		return nil, nil, fmt.Errorf("%w: unbalanced BEGIN/END", ErrInvalidCalendar)
+	}
+
+	return events, skipped, nil
+}
+
+// applyProperty - переносит свойство VEVENT в событие
+func applyProperty(e *domain.Event, prop property) error {
+	var err error
+
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Title = unescape(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescape(prop.value)
+	case "DTSTART":
+		e.Start, err = parseTime(prop)
+		e.TimeZone = prop.params["TZID"]
+		e.AllDay = isDate(prop)
+	case "DTEND":
+		e.End, err = parseTime(prop)
+	case "DURATION":
+		if e.Start.IsZero() {
+			return errors.New("DURATION before DTSTART")
+		}
+		var d time.Duration
+		if d, err = parseDuration(prop.value); err == nil {
+			e.End = e.Start.Add(d)
+		}
+	case "RRULE":
+		e.RRule = prop.value
+	case "EXDATE":
+		for _, v := range strings.Split(prop.value, ",") {
+			var t time.Time
+			if t, err = parseTime(property{params: prop.params, value: v}); err != nil {
+				break
+			}
+			e.ExDates = append(e.ExDates, t)
+		}
+	case "RECURRENCE-ID":
+		e.RecurrenceID, err = parseTime(prop)
+	case "CATEGORIES":
+		// свойство может повторяться, значения накапливаются
+		for _, v := range splitList(prop.value) {
+			if v = strings.TrimSpace(unescape(v)); v != "" {
+				e.Tags = append(e.Tags, v)
+			}
+		}
+	}
+
+	return err
+}
+
+// unfold - читает строки, склеивая перенесенные (начинающиеся с пробела или табуляции)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read calendar: %w", err)
+	}
+
+	return lines, nil
+}
+
+// parseProperty - разбирает строку контента с учетом значений параметров в кавычках
+func parseProperty(line string) (property, error) {
+	prop := property{params: make(map[string]string)}
+
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return prop, fmt.Errorf("no value in %q", line)
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		prop.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	prop.value = value
+
+	return prop, nil
+}
+
+// parseTime - разбирает DATE, DATE-TIME в UTC, DATE-TIME с TZID или "плавающее" время (как UTC)
+func parseTime(prop property) (time.Time, error) {
+	value := strings.TrimSpace(prop.value)
+
+	if isDate(prop) {
+		return time.Parse(layoutDate, value)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(layoutUTC, value)
+	}
+
+	loc := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = domain.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	return time.ParseInLocation(layoutLocal, value, loc)
+}
+
+// isDate - значение свойства - дата без времени
+func isDate(prop property) bool {
+	return prop.params["VALUE"] == "DATE" || len(strings.TrimSpace(prop.value)) == len(layoutDate)
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration - разбирает длительность RFC 5545, например -PT15M или P1DT2H
+func parseDuration(s string) (time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+
+	return d, nil
+}
+
+var unescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+// splitList - разбивает список значений по запятым, кроме экранированных
+func splitList(s string) []string {
+	var (
+		values  []string
+		start   int
+		escaped bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == ',':
+			values = append(values, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, s[start:])
+}
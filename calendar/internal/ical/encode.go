@@ -0,0 +1,210 @@
+package ical
+
+import (
+	"Calendar/internal/domain"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	prodID = "-//wbtechschool//Calendar//RU"
+	// layoutUTC - формат DATE-TIME в UTC
+	layoutUTC = "20060102T150405Z"
+	// layoutLocal - формат DATE-TIME без зоны (используется с TZID)
+	layoutLocal = "20060102T150405"
+	// layoutDate - формат DATE
+	layoutDate = "20060102"
+	// maxLineLen - максимальная длина строки в октетах до переноса
+	maxLineLen = 75
+)
+
+// UID - идентификатор события для экспорта: сохраненный при импорте или производный от ID
+func UID(e domain.Event) string {
+	if e.UID != "" {
+		return e.UID
+	}
+	return "event-" + strconv.FormatInt(e.ID, 10) + "@calendar"
+}
+
+// Encode - пишет события в формате iCalendar (RFC 5545).
+// Выделенные вхождения серий получают UID серии и RECURRENCE-ID.
+// События на весь день пишутся датами без времени (VALUE=DATE).
+func Encode(w io.Writer, events []domain.Event) error {
+	uids := make(map[int64]string, len(events))
+	allDay := make(map[int64]bool, len(events))
+	for _, e := range events {
+		uids[e.ID] = UID(e)
+		allDay[e.ID] = e.AllDay
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := &encoder{w: bw}
+
+	enc.line("BEGIN:VCALENDAR")
+	enc.line("VERSION:2.0")
+	enc.line("PRODID:" + prodID)
+	enc.line("CALSCALE:GREGORIAN")
+
+	stamp := time.Now().UTC().Format(layoutUTC)
+	for _, e := range events {
+		uid := UID(e)
+		if e.SeriesID != 0 {
+			if seriesUID, ok := uids[e.SeriesID]; ok {
+				uid = seriesUID
+			}
+		}
+
+		enc.line("BEGIN:VEVENT")
+		enc.line("UID:" + escape(uid))
+		enc.line("DTSTAMP:" + stamp)
+		if e.AllDay {
+			enc.line("DTSTART" + formatDate(e.Start))
+			enc.line("DTEND" + formatDate(e.End))
+		} else {
+			enc.line("DTSTART" + formatZoned(e.Start, e.TimeZone))
+			enc.line("DTEND" + formatZoned(e.End, e.TimeZone))
+		}
+		enc.line("SUMMARY:" + escape(e.Title))
+		if e.Description != "" {
+			enc.line("DESCRIPTION:" + escape(e.Description))
+		}
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, tag := range e.Tags {
+				tags[i] = escape(tag)
+			}
+			enc.line("CATEGORIES:" + strings.Join(tags, ","))
+		}
+		if e.RRule != "" {
+			enc.line("RRULE:" + e.RRule)
+		}
+		for _, ex := range e.ExDates {
+			if e.AllDay {
+				enc.line("EXDATE" + formatDate(ex))
+			} else {
+				enc.line("EXDATE" + formatTime(ex))
+			}
+		}
+		if e.SeriesID != 0 && !e.RecurrenceID.IsZero() {
+			// RECURRENCE-ID того же типа, что DTSTART серии
+			if allDay[e.SeriesID] {
+				enc.line("RECURRENCE-ID" + formatDate(e.RecurrenceID))
+			} else {
+				enc.line("RECURRENCE-ID" + formatTime(e.RecurrenceID))
+			}
+		}
+		if e.RemindBefore > 0 {
+			enc.line("BEGIN:VALARM")
+			enc.line("ACTION:DISPLAY")
+			enc.line("DESCRIPTION:" + escape(e.Title))
+			enc.line("TRIGGER:-" + formatDuration(e.RemindBefore))
+			enc.line("END:VALARM")
+		}
+		enc.line("END:VEVENT")
+	}
+
+	enc.line("END:VCALENDAR")
+	if enc.err != nil {
+		return enc.err
+	}
+	return bw.Flush()
+}
+
+// encoder - пишет строки контента с переносом длинных строк и CRLF
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) line(s string) {
+	if e.err != nil {
+		return
+	}
+
+	// строки продолжения начинаются с пробела, он входит в лимит
+	limit := maxLineLen
+	for len(s) > limit {
+		// не разрываем многобайтовые символы UTF-8
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, e.err = e.w.WriteString(s[:cut] + "\r\n "); e.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = maxLineLen - 1
+	}
+	_, e.err = e.w.WriteString(s + "\r\n")
+}
+
+// formatTime - ":значение" свойства даты-времени в UTC
+func formatTime(t time.Time) string {
+	return ":" + t.UTC().Format(layoutUTC)
+}
+
+// formatDate - ";VALUE=DATE:значение" свойства даты без времени
+func formatDate(t time.Time) string {
+	return ";VALUE=DATE:" + t.UTC().Format(layoutDate)
+}
+
+// formatZoned - ";TZID=зона:значение" в местном времени зоны события, без зоны - в UTC.
+// Повторения разворачиваются клиентом в этой зоне, поэтому местное время серии сохраняется.
+func formatZoned(t time.Time, zone string) string {
+	if zone == "" || zone == "UTC" {
+		return formatTime(t)
+	}
+	loc, err := domain.LoadLocation(zone)
+	if err != nil {
+		return formatTime(t)
+	}
+	return ";TZID=" + zone + ":" + t.In(loc).Format(layoutLocal)
+}
+
+// formatDuration - длительность в формате RFC 5545, например PT1H30M
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+	b.WriteString("P")
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if d == 0 {
+		if days == 0 {
+			return "PT0S"
+		}
+		return b.String()
+	}
+
+	b.WriteString("T")
+	if h := d / time.Hour; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+		d -= h * time.Hour
+	}
+	if m := d / time.Minute; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+		d -= m * time.Minute
+	}
+	if s := d / time.Second; s > 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,190 @@
+package ical_test
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/ical"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	const data = "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTIMEZONE\r\n" +
+		"TZID:Europe/Moscow\r\n" +
+		"END:VTIMEZONE\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup-1@example.com\r\n" +
+		"DTSTART;TZID=Europe/Moscow:20250106T100000\r\n" +
+		"DURATION:PT15M\r\n" +
+		"SUMMARY:Daily standup\\, team A\r\n" +
+		"DESCRIPTION:first line\\nsecond line that is long enough to be folded by\r\n" +
+		"  the client\r\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR\r\n" +
+		"CATEGORIES:Work,Team A\\, B\r\n" +
+		"CATEGORIES:daily\r\n" +
+		"EXDATE:20250108T070000Z,20250110T070000Z\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"TRIGGER:-PT10M\r\n" +
+		"END:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup-1@example.com\r\n" +
+		"RECURRENCE-ID:20250113T070000Z\r\n" +
+		"DTSTART:20250113T090000Z\r\n" +
+		"DTEND:20250113T091500Z\r\n" +
+		"SUMMARY:Moved standup\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:holiday\r\n" +
+		"DTSTART;VALUE=DATE:20250101\r\n" +
+		"DTEND;VALUE=DATE:20250102\r\n" +
+		"SUMMARY:New year\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, skipped, err := ical.Decode(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Empty(t, skipped)
+	require.Len(t, events, 3)
+
+	standup := events[0]
+	require.Equal(t, "standup-1@example.com", standup.UID)
+	require.Equal(t, "Daily standup, team A", standup.Title)
+	require.Equal(t, "first line\nsecond line that is long enough to be folded by the client", standup.Description)
+	require.True(t, standup.Start.Equal(time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC)))
+	require.Equal(t, "Europe/Moscow", standup.TimeZone)
+	require.Equal(t, 15*time.Minute, standup.End.Sub(standup.Start))
+	require.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE,FR", standup.RRule)
+	require.Equal(t, []string{"Work", "Team A, B", "daily"}, standup.Tags)
+	require.Len(t, standup.ExDates, 2)
+	require.Equal(t, 10*time.Minute, standup.RemindBefore)
+
+	moved := events[1]
+	require.Equal(t, time.Date(2025, 1, 13, 7, 0, 0, 0, time.UTC), moved.RecurrenceID)
+
+	holiday := events[2]
+	require.True(t, holiday.AllDay)
+	require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), holiday.Start)
+	require.Equal(t, 24*time.Hour, holiday.End.Sub(holiday.Start))
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, data := range []string{
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n",
+		"garbage",
+	} {
+		_, _, err := ical.Decode(strings.NewReader(data))
+		require.ErrorIs(t, err, ical.ErrInvalidCalendar, data)
+	}
+}
+
+func TestDecodeSkipsInvalidEvents(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:bad-date\r\nDTSTART:tomorrow\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;TZID=Mars/Olympus:20250101T100000\r\nUID:bad-zone\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:good\r\nDTSTART:20250101T100000Z\r\nSUMMARY:good\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, skipped, err := ical.Decode(strings.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "good", events[0].UID)
+
+	// UID известен, даже если он идет после ошибочного свойства
+	require.Len(t, skipped, 2)
+	require.Equal(t, "bad-date", skipped[0].UID)
+	require.Contains(t, skipped[0].Reason, "line 4")
+	require.Equal(t, "bad-zone", skipped[1].UID)
+	require.Contains(t, skipped[1].Reason, `unknown TZID "Mars/Olympus"`)
+}
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{
+			ID:           1,
+			Title:        "Планерка; обсуждение, итоги",
+			Description:  strings.Repeat("очень длинное описание ", 10),
+			Start:        start,
+			End:          start.Add(time.Hour),
+			RemindBefore: 90 * time.Minute,
+			RRule:        "FREQ=DAILY;COUNT=5",
+			ExDates:      []time.Time{start.AddDate(0, 0, 1)},
+			TimeZone:     "Europe/Moscow",
+			Tags:         []string{"meetings", "работа"},
+		},
+		{
+			ID:           2,
+			Title:        "moved",
+			Start:        start.AddDate(0, 0, 2).Add(time.Hour),
+			End:          start.AddDate(0, 0, 2).Add(2 * time.Hour),
+			SeriesID:     1,
+			RecurrenceID: start.AddDate(0, 0, 2),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ical.Encode(&buf, events))
+	require.Contains(t, buf.String(), "DTSTART;TZID=Europe/Moscow:20250106T100000\r\n")
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		require.LessOrEqual(t, len(line), 75)
+	}
+
+	decoded, _, err := ical.Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+
+	require.Equal(t, "event-1@calendar", decoded[0].UID)
+	require.Equal(t, events[0].Title, decoded[0].Title)
+	require.Equal(t, events[0].Description, decoded[0].Description)
+	require.True(t, events[0].Start.Equal(decoded[0].Start))
+	require.True(t, events[0].End.Equal(decoded[0].End))
+	require.Equal(t, events[0].RemindBefore, decoded[0].RemindBefore)
+	require.Equal(t, events[0].RRule, decoded[0].RRule)
+	require.Equal(t, events[0].ExDates, decoded[0].ExDates)
+	require.Equal(t, events[0].TimeZone, decoded[0].TimeZone)
+	require.Equal(t, events[0].Tags, decoded[0].Tags)
+
+	// выделенное вхождение получает UID серии
+	require.Equal(t, "event-1@calendar", decoded[1].UID)
+	require.True(t, events[1].RecurrenceID.Equal(decoded[1].RecurrenceID))
+}
+
+func TestRoundTripAllDay(t *testing.T) {
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{ID: 1, Title: "vacation", Start: day, End: day.AddDate(0, 0, 5), AllDay: true, TimeZone: "Europe/Moscow",
+			RRule: "FREQ=YEARLY", ExDates: []time.Time{day.AddDate(1, 0, 0)}},
+		{ID: 2, Title: "short vacation", Start: day.AddDate(2, 0, 0), End: day.AddDate(2, 0, 2), AllDay: true,
+			SeriesID: 1, RecurrenceID: day.AddDate(2, 0, 0)},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ical.Encode(&buf, events))
+	data := buf.String()
+	require.Contains(t, data, "DTSTART;VALUE=DATE:20250106\r\n")
+	require.Contains(t, data, "DTEND;VALUE=DATE:20250111\r\n")
+	require.Contains(t, data, "EXDATE;VALUE=DATE:20260106\r\n")
+	require.Contains(t, data, "RECURRENCE-ID;VALUE=DATE:20270106\r\n")
+
+	decoded, _, err := ical.Decode(&buf)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+	for i, e := range decoded {
+		require.True(t, e.AllDay)
+		require.Equal(t, events[i].Start, e.Start)
+		require.Equal(t, events[i].End, e.End)
+	}
+	require.Equal(t, events[0].ExDates, decoded[0].ExDates)
+	require.Equal(t, events[1].RecurrenceID, decoded[1].RecurrenceID)
+
+	// событие на день без DTEND длится этот день
+	decoded, _, err = ical.Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250101\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	require.NoError(t, err)
+	require.Equal(t, 24*time.Hour, decoded[0].End.Sub(decoded[0].Start))
+}
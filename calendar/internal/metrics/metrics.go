@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBuckets - границы гистограммы длительности запросов в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// ContentType - тип ответа в текстовом формате Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// HTTP - метрики HTTP запросов: количество и длительность по маршруту и статусу,
+// число обрабатываемых запросов. Выгружаются в текстовом формате Prometheus.
+type HTTP struct {
+	buckets  []float64
+	inFlight atomic.Int64
+
+	mu     sync.Mutex
+	series map[label]*series
+}
+
+// label - набор меток одного ряда
+type label struct {
+	method string
+	route  string
+	status int
+}
+
+// series - счетчик и гистограмма одного ряда
+type series struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// NewHTTP - конструктор; buckets должны идти по возрастанию, nil - DefaultBuckets
+func NewHTTP(buckets []float64) *HTTP {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return &HTTP{buckets: buckets, series: make(map[label]*series)}
+}
+
+// Start - запрос начал обрабатываться
+func (m *HTTP) Start() {
+	m.inFlight.Add(1)
+}
+
+// Done - запрос обработан; route - шаблон маршрута, а не путь, чтобы число рядов было ограничено
+func (m *HTTP) Done(method, route string, status int, d time.Duration) {
+	m.inFlight.Add(-1)
+
+	l := label{method: method, route: route, status: status}
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.series[l]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(m.buckets))}
+		m.series[l] = s
+	}
+	s.count++
+	s.sum += seconds
+	for i, upper := range m.buckets {
+		if seconds <= upper {
+			s.buckets[i]++
+		}
+	}
+}
+
+// WriteTo - выгружает метрики в текстовом формате Prometheus
+func (m *HTTP) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	labels := make([]label, 0, len(m.series))
+	snapshot := make(map[label]series, len(m.series))
+	for l, s := range m.series {
+		labels = append(labels, l)
+		snapshot[l] = series{count: s.count, sum: s.sum, buckets: append([]uint64(nil), s.buckets...)}
+	}
+	m.mu.Unlock()
+
+	// стабильный порядок рядов
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	fmt.Fprintln(cw, "# HELP calendar_http_requests_in_flight Number of HTTP requests being served.")
+	fmt.Fprintln(cw, "# TYPE calendar_http_requests_in_flight gauge")
+	fmt.Fprintf(cw, "calendar_http_requests_in_flight %d\n", m.inFlight.Load())
+
+	fmt.Fprintln(cw, "# HELP calendar_http_requests_total Total number of HTTP requests.")
+	fmt.Fprintln(cw, "# TYPE calendar_http_requests_total counter")
+	for _, l := range labels {
+		fmt.Fprintf(cw, "calendar_http_requests_total{%s} %d\n", l, snapshot[l].count)
+	}
+
+	fmt.Fprintln(cw, "# HELP calendar_http_request_duration_seconds HTTP request latency.")
+	fmt.Fprintln(cw, "# TYPE calendar_http_request_duration_seconds histogram")
+	for _, l := range labels {
+		s := snapshot[l]
+		for i, upper := range m.buckets {
+			fmt.Fprintf(cw, "calendar_http_request_duration_seconds_bucket{%s,le=%q} %d\n",
+				l, strconv.FormatFloat(upper, 'g', -1, 64), s.buckets[i])
+		}
+		fmt.Fprintf(cw, "calendar_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, s.count)
+		fmt.Fprintf(cw, "calendar_http_request_duration_seconds_sum{%s} %s\n", l, strconv.FormatFloat(s.sum, 'g', -1, 64))
+		fmt.Fprintf(cw, "calendar_http_request_duration_seconds_count{%s} %d\n", l, s.count)
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// String - метки ряда в формате Prometheus
+func (l label) String() string {
+	return fmt.Sprintf(`method="%s",route="%s",status="%d"`, escape(l.method), escape(l.route), l.status)
+}
+
+// escape - экранирование значения метки
+var escape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+
+// countingWriter - считает записанные байты и запоминает первую ошибку
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
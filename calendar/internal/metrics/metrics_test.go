@@ -0,0 +1,37 @@
+package metrics_test
+
+import (
+	"Calendar/internal/metrics"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPWriteTo(t *testing.T) {
+	m := metrics.NewHTTP([]float64{0.1, 1})
+
+	m.Start()
+	m.Start()
+	m.Done("GET", "/events_for_day", 200, 50*time.Millisecond)
+	m.Start()
+	m.Done("GET", "/events_for_day", 200, 500*time.Millisecond)
+	m.Start()
+	m.Done("POST", `/create_"event"`, 400, 2*time.Second)
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	require.NoError(t, err)
+	require.EqualValues(t, buf.Len(), n)
+
+	out := buf.String()
+	require.Contains(t, out, "# TYPE calendar_http_requests_in_flight gauge\ncalendar_http_requests_in_flight 1\n")
+	require.Contains(t, out, `calendar_http_requests_total{method="GET",route="/events_for_day",status="200"} 2`)
+	require.Contains(t, out, `calendar_http_request_duration_seconds_bucket{method="GET",route="/events_for_day",status="200",le="0.1"} 1`)
+	require.Contains(t, out, `calendar_http_request_duration_seconds_bucket{method="GET",route="/events_for_day",status="200",le="1"} 2`)
+	require.Contains(t, out, `calendar_http_request_duration_seconds_bucket{method="GET",route="/events_for_day",status="200",le="+Inf"} 2`)
+	require.Contains(t, out, `calendar_http_request_duration_seconds_sum{method="GET",route="/events_for_day",status="200"} 0.55`)
+	// значения меток экранируются
+	require.Contains(t, out, `calendar_http_request_duration_seconds_bucket{method="POST",route="/create_\"event\"",status="400",le="1"} 0`)
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/pkg/logger"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Authenticator - проверка токена запроса
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (int64, error)
+}
+
+// Auth - пропускает только запросы с действительным токеном
+// (Authorization: Bearer <JWT или API ключ> либо X-API-Key: <API ключ>)
+// и кладет идентификатор пользователя в контекст (и в поля логов запроса)
+func Auth(authn Authenticator, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := authn.Authenticate(r.Context(), token(r))
+			if err != nil {
+				if !errors.Is(err, domain.ErrUnauthorized) {
+					log.ErrorContext(r.Context(), "failed to authenticate request",
+						slog.String("request_id", middleware.GetReqID(r.Context())),
+						slog.String("err", err.Error()),
+					)
+					writeError(w, http.StatusInternalServerError, "internal error")
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="calendar"`)
+				writeError(w, http.StatusUnauthorized, domain.ErrUnauthorized.Error())
+				return
+			}
+
+			logger.SetUserID(r.Context(), userID)
+			next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+		})
+	}
+}
+
+// token - токен из заголовков запроса
+func token(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// writeError - JSON ответ с ошибкой в формате обработчиков
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"error":"` + msg + `"}` + "\n"))
+}
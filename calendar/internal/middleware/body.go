@@ -0,0 +1,18 @@
+package middleware
+
+import "net/http"
+
+// MaxBodySize - ограничивает размер тела запроса. Запрос с заявленной длиной больше limit
+// сразу получает 413, а чтение сверх limit тела без длины возвращает *http.MaxBytesError
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxIdempotencyKey - максимальная длина заголовка Idempotency-Key
+const maxIdempotencyKey = 255
+
+// Idempotency - повтор POST запроса с тем же заголовком Idempotency-Key отдает сохраненный ответ
+// первого запроса (с заголовком Idempotent-Replayed: true), не выполняя его заново.
+// Ключи у каждого клиента свои (см. RateLimit), поэтому middleware ставится после Auth.
+// Тот же ключ с другим запросом - 422, пока первый запрос выполняется - 409.
+// Ответы 5xx не сохраняются: такой запрос можно повторить с тем же ключом.
+func Idempotency(store *IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				writeError(w, http.StatusBadRequest, "idempotency key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+					return
+				}
+				writeError(w, http.StatusBadRequest, "read body failed")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := clientKey(r) + " " + key
+			saved, err := store.begin(scope, fingerprint(r, body), time.Now())
+			switch {
+			case errors.Is(err, errKeyReused):
+				writeError(w, http.StatusUnprocessableEntity, "idempotency key is reused with a different request")
+				return
+			case errors.Is(err, errKeyInProgress):
+				writeError(w, http.StatusConflict, "request with this idempotency key is in progress")
+				return
+			case saved != nil:
+				saved.replay(w)
+				return
+			}
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			// при панике ключ освобождается, чтобы запрос можно было повторить
+			done := false
+			defer func() {
+				if !done {
+					store.abort(scope)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				store.abort(scope)
+			} else {
+				store.finish(scope, &savedResponse{status: status, header: w.Header().Clone(), body: buf.Bytes()}, time.Now())
+			}
+			done = true
+		})
+	}
+}
+
+// fingerprint - отпечаток запроса: метод, путь с параметрами и тело
+func fingerprint(r *http.Request, body []byte) [sha256.Size]byte {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+var (
+	errKeyReused     = errors.New("idempotency key reused")
+	errKeyInProgress = errors.New("idempotency key in progress")
+)
+
+// IdempotencyStore - ответы на запросы с ключом идемпотентности; хранятся в памяти ttl
+type IdempotencyStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	// nil - запрос еще выполняется
+	response *savedResponse
+	expires  time.Time
+}
+
+// savedResponse - сохраненный ответ на запрос
+type savedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewIdempotencyStore - конструктор; ttl - сколько помнить ответ
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{ttl: ttl, entries: make(map[string]*idempotencyEntry)}
+}
+
+// begin - сохраненный ответ на запрос с ключом; если его нет - ключ занимается до finish или abort
+func (s *IdempotencyStore) begin(scope string, fp [sha256.Size]byte, now time.Time) (*savedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// просроченные ответы удаляются, чтобы карта не росла бесконечно
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	e, ok := s.entries[scope]
+	if !ok || now.After(e.expires) {
+		s.entries[scope] = &idempotencyEntry{fingerprint: fp, expires: now.Add(s.ttl)}
+		return nil, nil
+	}
+	if e.fingerprint != fp {
+		return nil, errKeyReused
+	}
+	if e.response == nil {
+		return nil, errKeyInProgress
+	}
+	return e.response, nil
+}
+
+// finish - сохраняет ответ на запрос с ключом
+func (s *IdempotencyStore) finish(scope string, resp *savedResponse, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[scope]; ok {
+		e.response = resp
+		e.expires = now.Add(s.ttl)
+	}
+}
+
+// abort - освобождает ключ запроса, ответ на который не сохраняется
+func (s *IdempotencyStore) abort(scope string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[scope]; ok && e.response == nil {
+		delete(s.entries, scope)
+	}
+}
+
+// replay - отдает сохраненный ответ повторно
+func (resp *savedResponse) replay(w http.ResponseWriter) {
+	maps.Copy(w.Header(), resp.header)
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.status)
+	_, _ = w.Write(resp.body)
+}
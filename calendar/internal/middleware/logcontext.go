@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"Calendar/pkg/logger"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// LogContext - заводит поля запроса для логов: request ID (ставится после middleware.RequestID)
+// и trace ID из заголовка traceparent (W3C Trace Context). Пользователя добавляет Auth.
+func LogContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logger.NewContext(r.Context())
+		logger.SetRequestID(ctx, middleware.GetReqID(ctx))
+		if traceID, ok := traceID(r.Header.Get("traceparent")); ok {
+			logger.SetTraceID(ctx, traceID)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// traceID - trace-id из заголовка вида 00-<32 hex>-<16 hex>-<2 hex>
+func traceID(traceparent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 {
+		return "", false
+	}
+	id := strings.ToLower(parts[1])
+	if strings.Trim(id, "0123456789abcdef") != "" || strings.Trim(id, "0") == "" {
+		return "", false
+	}
+	return id, true
+}
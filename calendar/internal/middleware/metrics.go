@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute - маршрут запросов, не попавших ни в один шаблон (404, 405)
+const unmatchedRoute = "unmatched"
+
+// Recorder - приемник метрик запросов
+type Recorder interface {
+	// Start - запрос начал обрабатываться
+	Start()
+	// Done - запрос обработан
+	Done(method, route string, status int, d time.Duration)
+}
+
+// Metrics - учитывает каждый запрос в rec по шаблону маршрута chi и статусу ответа
+func Metrics(rec Recorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			rec.Start()
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				// шаблон известен только после маршрутизации
+				route := unmatchedRoute
+				if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+					route = rctx.RoutePattern()
+				}
+
+				rec.Done(r.Method, route, status, time.Since(start))
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package middleware_test
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/internal/middleware"
+	"Calendar/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerAndRecoverer(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	h := chimw.RequestID(middleware.Logger(log)(middleware.Recoverer(log)(mux)))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "request", entry["msg"])
+	require.Equal(t, "GET", entry["method"])
+	require.Equal(t, "/ok", entry["path"])
+	require.EqualValues(t, http.StatusCreated, entry["status"])
+	require.EqualValues(t, 5, entry["bytes"])
+	require.NotEmpty(t, entry["request_id"])
+	require.NotEmpty(t, entry["remote_addr"])
+
+	buf.Reset()
+	rec = httptest.NewRecorder()
+	require.NotPanics(t, func() {
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Contains(t, buf.String(), "panic recovered")
+	require.Contains(t, buf.String(), "stack")
+	require.Contains(t, buf.String(), `"status":500`)
+}
+
+type authFunc func(ctx context.Context, token string) (int64, error)
+
+func (f authFunc) Authenticate(ctx context.Context, token string) (int64, error) {
+	return f(ctx, token)
+}
+
+func TestAuth(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	authn := authFunc(func(ctx context.Context, token string) (int64, error) {
+		switch token {
+		case "good":
+			return 7, nil
+		case "broken":
+			return 0, errors.New("storage is down")
+		}
+		return 0, domain.ErrUnauthorized
+	})
+
+	h := middleware.Auth(authn, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := auth.UserID(r.Context())
+		require.True(t, ok)
+		require.Equal(t, int64(7), userID)
+	}))
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{name: "bearer", header: http.Header{"Authorization": {"Bearer good"}}, status: http.StatusOK},
+		{name: "api key header", header: http.Header{"X-Api-Key": {"good"}}, status: http.StatusOK},
+		{name: "no token", header: http.Header{}, status: http.StatusUnauthorized},
+		{name: "basic scheme", header: http.Header{"Authorization": {"Basic good"}}, status: http.StatusUnauthorized},
+		{name: "invalid token", header: http.Header{"Authorization": {"Bearer bad"}}, status: http.StatusUnauthorized},
+		{name: "authenticator error", header: http.Header{"Authorization": {"Bearer broken"}}, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusUnauthorized {
+				require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+				require.JSONEq(t, `{"error":"unauthorized"}`, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := middleware.RateLimit(1, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(remoteAddr string, userID int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != 0 {
+			req = req.WithContext(auth.WithUserID(req.Context(), userID))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// корзина на два запроса подряд
+	require.Equal(t, http.StatusOK, do("10.0.0.1:1000", 0).Code)
+	require.Equal(t, http.StatusOK, do("10.0.0.1:2000", 0).Code)
+	rec := do("10.0.0.1:3000", 0)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"error":"rate limit exceeded"}`, rec.Body.String())
+
+	// у другого IP и у аутентифицированного пользователя свои корзины
+	require.Equal(t, http.StatusOK, do("10.0.0.2:1000", 0).Code)
+	require.Equal(t, http.StatusOK, do("10.0.0.1:4000", 7).Code)
+	require.Equal(t, http.StatusOK, do("10.0.0.3:1000", 7).Code)
+	require.Equal(t, http.StatusTooManyRequests, do("10.0.0.4:1000", 7).Code)
+}
+
+func TestRateLimitIP(t *testing.T) {
+	h := middleware.RateLimitIP(1, 1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(userID int64) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1000"
+		req = req.WithContext(auth.WithUserID(req.Context(), userID))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// пользователь не учитывается: корзина одна на IP
+	require.Equal(t, http.StatusOK, do(1))
+	require.Equal(t, http.StatusTooManyRequests, do(2))
+}
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	started, release := make(chan struct{}), make(chan struct{})
+	h := middleware.Idempotency(middleware.NewIdempotencyStore(time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		switch string(body) {
+		case "fail":
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		case "slow":
+			close(started)
+			<-release
+		}
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+
+	do := func(key, body string, userID int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		if userID != 0 {
+			req = req.WithContext(auth.WithUserID(req.Context(), userID))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := do("k1", "standup", 1)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	// повтор отдает сохраненный ответ, не вызывая обработчик
+	again := do("k1", "standup", 1)
+	require.Equal(t, http.StatusCreated, again.Code)
+	require.Equal(t, "standup", again.Body.String())
+	require.Equal(t, `"1"`, again.Header().Get("ETag"))
+	require.Equal(t, "true", again.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, 1, calls)
+
+	// тот же ключ с другим телом - ошибка; у другого пользователя ключи свои
+	rec := do("k1", "retro", 1)
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	require.Equal(t, http.StatusCreated, do("k1", "retro", 2).Code)
+	require.Equal(t, 2, calls)
+
+	// ответ с ошибкой сервера не сохраняется
+	require.Equal(t, http.StatusGatewayTimeout, do("k2", "fail", 1).Code)
+	require.Equal(t, http.StatusGatewayTimeout, do("k2", "fail", 1).Code)
+	require.Equal(t, 4, calls)
+
+	// пока первый запрос выполняется, повтор отклоняется
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- do("k3", "slow", 1) }()
+	<-started
+	require.Equal(t, http.StatusConflict, do("k3", "slow", 1).Code)
+	close(release)
+	require.Equal(t, http.StatusCreated, (<-done).Code)
+
+	// запрос без ключа выполняется как обычно, слишком длинный ключ отклоняется
+	req := httptest.NewRequest(http.MethodPost, "/create_event", strings.NewReader("standup"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.Equal(t, 6, calls)
+	require.Equal(t, http.StatusBadRequest, do(strings.Repeat("k", 256), "standup", 1).Code)
+}
+
+func TestMaxBodySize(t *testing.T) {
+	h := middleware.MaxBodySize(4)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("1234")))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// заявленная длина проверяется до обработчика
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345")))
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.Contains(t, rec.Body.String(), "request body too large")
+
+	// тело без длины обрывается при чтении
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345"))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	require.Empty(t, rec.Body.String())
+}
+
+func TestLogContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(logger.NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+	authn := authFunc(func(ctx context.Context, token string) (int64, error) {
+		return 42, nil
+	})
+
+	h := chimw.RequestID(middleware.LogContext(middleware.Logger(log)(
+		middleware.Auth(authn, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.InfoContext(r.Context(), "handled")
+		})),
+	)))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		require.NotEmpty(t, entry["request_id"])
+		// пользователь известен после аутентификации, но попадает и в лог запроса снаружи
+		require.EqualValues(t, 42, entry["user_id"])
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+	}
+	require.Equal(t, 1, strings.Count(lines[1], `"request_id"`))
+
+	// некорректный traceparent пропускается
+	buf.Reset()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotContains(t, buf.String(), "trace_id")
+}
+
+func TestRecovererLogContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(logger.NewContextHandler(slog.NewJSONHandler(&buf, nil)))
+	authn := authFunc(func(ctx context.Context, token string) (int64, error) {
+		return 42, nil
+	})
+
+	h := chimw.RequestID(middleware.LogContext(middleware.Recoverer(log)(
+		middleware.Auth(authn, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})),
+	)))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "panic recovered", entry["msg"])
+	require.EqualValues(t, 42, entry["user_id"])
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"Calendar/internal/auth"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL - через сколько простоя корзина клиента забывается
+const limiterIdleTTL = 10 * time.Minute
+
+// RateLimit - ограничивает частоту запросов алгоритмом token bucket: у каждого клиента
+// своя корзина на burst запросов, пополняемая со скоростью rps в секунду.
+// Клиент - аутентифицированный пользователь, а без аутентификации - IP адрес,
+// поэтому middleware ставится после Auth. При превышении - 429 с заголовком Retry-After.
+func RateLimit(rps float64, burst int) func(http.Handler) http.Handler {
+	return rateLimit(rps, burst, clientKey)
+}
+
+// RateLimitIP - то же ограничение, но всегда по IP адресу. Ставится до Auth,
+// чтобы ограничивать и запросы с неверными учетными данными, и публичные маршруты.
+func RateLimitIP(rps float64, burst int) func(http.Handler) http.Handler {
+	return rateLimit(rps, burst, ipKey)
+}
+
+func rateLimit(rps float64, burst int, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	l := &limiters{rps: rate.Limit(rps), burst: burst, clients: make(map[string]*client)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait, ok := l.allow(key(r), time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limiters - корзины клиентов
+type limiters struct {
+	rps   rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	clients   map[string]*client
+	lastSweep time.Time
+}
+
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// allow - можно ли выполнить запрос сейчас; если нельзя - через сколько появится токен
+func (l *limiters) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// корзины простаивающих клиентов удаляются, чтобы карта не росла бесконечно
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > limiterIdleTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.clients[key] = c
+	}
+	c.lastSeen = now
+
+	res := c.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		// burst = 0 - запросы запрещены совсем
+		return limiterIdleTTL, false
+	}
+	if wait := res.DelayFrom(now); wait > 0 {
+		// токен не тратим: запрос все равно отклоняется
+		res.CancelAt(now)
+		return wait, false
+	}
+	return 0, true
+}
+
+// clientKey - аутентифицированный пользователь или IP адрес клиента
+func clientKey(r *http.Request) string {
+	if id, ok := auth.UserID(r.Context()); ok {
+		return "user:" + strconv.FormatInt(id, 10)
+	}
+	return ipKey(r)
+}
+
+// ipKey - IP адрес клиента
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
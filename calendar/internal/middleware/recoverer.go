@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer - перехватывает панику в обработчике, логирует стек и отвечает 500
+func Recoverer(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				// ErrAbortHandler - штатный способ прервать ответ, net/http обработает его сам
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				log.ErrorContext(r.Context(), "panic recovered",
+					slog.Any("panic", rec),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if r.Header.Get("Connection") != "Upgrade" {
+					writeError(w, http.StatusInternalServerError, "internal error")
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
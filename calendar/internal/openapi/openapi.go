@@ -0,0 +1,12 @@
+package openapi
+
+import _ "embed"
+
+//go:embed openapi.json
+var document []byte
+
+// Document - описание API событий в формате OpenAPI 3.1 (JSON).
+// По схемам из него же проверяются тела запросов, см. Validate.
+func Document() []byte {
+	return document
+}
@@ -0,0 +1,65 @@
+package openapi_test
+
+import (
+	"Calendar/internal/openapi"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument(t *testing.T) {
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(openapi.Document(), &doc))
+	require.Equal(t, "3.1.0", doc["openapi"])
+
+	paths := doc["paths"].(map[string]any)
+	for _, path := range []string{"/create_event", "/update_event", "/delete_event", "/events_for_day", "/events_for_week", "/events_for_month"} {
+		require.Contains(t, paths, path)
+	}
+
+	// все ссылки указывают на существующие схемы
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, ref := range regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllStringSubmatch(string(openapi.Document()), -1) {
+		require.Contains(t, schemas, ref[1])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	body := func(s string) any {
+		var v any
+		dec := json.NewDecoder(strings.NewReader(s))
+		dec.UseNumber()
+		require.NoError(t, dec.Decode(&v))
+		return v
+	}
+
+	require.NoError(t, openapi.Validate("CreateEventRequest", body(`{"user_id": 1, "title": "standup", "date": "2025-01-15T10:00"}`)))
+	require.NoError(t, openapi.Validate("CreateEventRequest", map[string]any{"user_id": "1", "title": "standup", "date": "2025-01-15"}))
+
+	err := openapi.Validate("CreateEventRequest", body(`{"user_id": 0, "title": "", "remind_before": "soon", "color": "red"}`))
+	var verr *openapi.ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []openapi.FieldError{
+		{Field: "date", Message: "is required"},
+		{Field: "color", Message: "unknown field"},
+		{Field: "remind_before", Message: "has invalid format"},
+		{Field: "title", Message: "must not be empty"},
+		{Field: "user_id", Message: "must be at least 1"},
+	}, verr.Fields)
+
+	err = openapi.Validate("UpdateEventRequest", body(`{"event_id": 1.5, "title": 7, "date": "2025-01-15"}`))
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []openapi.FieldError{
+		{Field: "event_id", Message: "must be integer or string"},
+		{Field: "title", Message: "must be string"},
+	}, verr.Fields)
+
+	err = openapi.Validate("DeleteEventRequest", body(`[1]`))
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, []openapi.FieldError{{Field: "body", Message: "must be object"}}, verr.Fields)
+
+	require.Error(t, openapi.Validate("Unknown", nil))
+}
@@ -0,0 +1,277 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError - ошибка в одном поле тела запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError - тело запроса не соответствует схеме
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// schema - подмножество JSON Schema, которое используется в документе
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 schemaType         `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Pattern              string             `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaType - "type" бывает строкой или списком строк
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaType{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+var (
+	schemasOnce sync.Once
+	schemas     map[string]*schema
+	schemasErr  error
+)
+
+// loadSchemas - разбирает components.schemas документа один раз
+func loadSchemas() (map[string]*schema, error) {
+	schemasOnce.Do(func() {
+		var doc struct {
+			Components struct {
+				Schemas map[string]*schema `json:"schemas"`
+			} `json:"components"`
+		}
+		if err := json.Unmarshal(document, &doc); err != nil {
+			schemasErr = fmt.Errorf("parse openapi document: %w", err)
+			return
+		}
+		for name, s := range doc.Components.Schemas {
+			if err := compile(s); err != nil {
+				schemasErr = fmt.Errorf("schema %s: %w", name, err)
+				return
+			}
+		}
+		schemas = doc.Components.Schemas
+	})
+	return schemas, schemasErr
+}
+
+// compile - компилирует регулярные выражения схемы и вложенных схем
+func compile(s *schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := compile(p); err != nil {
+			return err
+		}
+	}
+	return compile(s.Items)
+}
+
+// Validate - проверяет значение, разобранное из JSON (числа - json.Number),
+// по схеме components.schemas[name]. Несоответствие - *ValidationError.
+func Validate(name string, v any) error {
+	all, err := loadSchemas()
+	if err != nil {
+		return err
+	}
+	s, ok := all[name]
+	if !ok {
+		return fmt.Errorf("unknown schema %q", name)
+	}
+
+	var errs []FieldError
+	validate(all, s, v, "", &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+func validate(all map[string]*schema, s *schema, v any, path string, errs *[]FieldError) {
+	if s.Ref != "" {
+		ref, ok := all[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if !ok {
+			*errs = append(*errs, FieldError{Field: field(path), Message: "unknown schema " + s.Ref})
+			return
+		}
+		s = ref
+	}
+
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, FieldError{Field: field(path), Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		fail("must be %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		fail("must be one of %s", enumString(s.Enum))
+		return
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters", *s.MinLength)
+			}
+			return
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+			return
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("has invalid format")
+		}
+	case json.Number:
+		if f, err := v.Float64(); err == nil && s.Minimum != nil && f < *s.Minimum {
+			fail("must be at least %s", strconv.FormatFloat(*s.Minimum, 'g', -1, 64))
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, FieldError{Field: join(path, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, FieldError{Field: join(path, name), Message: "unknown field"})
+				}
+				continue
+			}
+			validate(all, prop, v[name], join(path, name), errs)
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				validate(all, s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+// matches - подходит ли значение под один из типов
+func (t schemaType) matches(v any) bool {
+	for _, typ := range t {
+		switch v := v.(type) {
+		case nil:
+			if typ == "null" {
+				return true
+			}
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case json.Number:
+			if typ == "number" {
+				return true
+			}
+			if _, err := v.Int64(); err == nil && typ == "integer" {
+				return true
+			}
+		case map[string]any:
+			if typ == "object" {
+				return true
+			}
+		case []any:
+			if typ == "array" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, e := range enum {
+		values = append(values, fmt.Sprint(e))
+	}
+	return strings.Join(values, ", ")
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// field - имя поля в ошибке; ошибка всего тела - "body"
+func field(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
@@ -0,0 +1,71 @@
+package recurrence
+
+import (
+	"Calendar/internal/domain"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Occurrences - вхождения серии, начинающиеся в полуинтервале [from, to)
+func Occurrences(series domain.Event, from, to time.Time) ([]domain.Event, error) {
+	rule, err := Parse(series.RRule)
+	if err != nil {
+		return nil, fmt.Errorf("parse rule of event %d: %w", series.ID, err)
+	}
+
+	// разворачиваем в зоне события, чтобы повторения сохраняли местное время при смене смещения;
+	// события на весь день - в UTC, их даты от зоны не зависят
+	loc := series.Location()
+	if series.AllDay {
+		loc = time.UTC
+	}
+	duration := series.End.Sub(series.Start)
+	starts := rule.Between(series.Start.In(loc), from, to, series.ExDates)
+
+	events := make([]domain.Event, 0, len(starts))
+	for _, start := range starts {
+		start = start.UTC()
+		occ := series
+		occ.Start = start
+		occ.End = start.Add(duration)
+		occ.RecurrenceID = start
+		occ.ExDates = nil
+		events = append(events, occ)
+	}
+
+	return events, nil
+}
+
+// Reminders - события с напоминаниями, приходящимися на полуинтервал [from, to),
+// по возрастанию момента напоминания. Серии заменяются такими вхождениями.
+func Reminders(events []domain.Event, from, to time.Time) ([]domain.Event, error) {
+	out := make([]domain.Event, 0)
+	for _, e := range events {
+		if e.RemindBefore <= 0 {
+			continue
+		}
+		if !e.Recurring() {
+			if at, _ := e.RemindAt(); !at.Before(from) && at.Before(to) {
+				out = append(out, e)
+			}
+			continue
+		}
+
+		occ, err := Occurrences(e, from.Add(e.RemindBefore), to.Add(e.RemindBefore))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, occ...)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, _ := out[i].RemindAt()
+		b, _ := out[j].RemindAt()
+		if a.Equal(b) {
+			return out[i].ID < out[j].ID
+		}
+		return a.Before(b)
+	})
+	return out, nil
+}
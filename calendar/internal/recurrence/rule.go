@@ -0,0 +1,517 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRule - некорректное или неподдерживаемое правило повторения
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Freq - частота повторения
+type Freq string
+
+const (
+	Daily   Freq = "DAILY"
+	Weekly  Freq = "WEEKLY"
+	Monthly Freq = "MONTHLY"
+	Yearly  Freq = "YEARLY"
+)
+
+// maxIterations - ограничение на перебор, чтобы некорректное правило не зациклило сервис
+const maxIterations = 100000
+
+// Rule - правило повторения (подмножество RRULE из RFC 5545): FREQ, INTERVAL,
+// BYDAY (порядковые номера - только для MONTHLY и YEARLY), BYMONTHDAY, BYMONTH, WKST, COUNT, UNTIL
+type Rule struct {
+	Freq       Freq
+	Interval   int
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+	// первый день недели для WEEKLY с INTERVAL больше 1
+	WeekStart time.Weekday
+	Count     int
+	// UNTIL в UTC; для даты и плавающего времени (без Z) - часы по UTC,
+	// которые при переборе переносятся в зону серии
+	Until     time.Time
+	untilKind untilKind
+}
+
+// untilKind - форма записи UNTIL
+type untilKind int
+
+const (
+	untilUTC untilKind = iota
+	// дата-время без зоны - в зоне серии
+	untilFloating
+	// дата - вхождения этого дня включительно в зоне серии
+	untilDate
+)
+
+// Weekday - значение BYDAY: день недели и его порядковый номер в месяце или году
+// (2TU - второй вторник, -1FR - последняя пятница); 0 - каждый такой день
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+// String - значение в формате BYDAY
+func (w Weekday) String() string {
+	day := strings.ToUpper(w.Day.String()[:2])
+	if w.N == 0 {
+		return day
+	}
+	return strconv.Itoa(w.N) + day
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// untilLayouts - форматы UNTIL по untilKind: дата-время в UTC, плавающее дата-время и дата
+var untilLayouts = []string{
+	untilUTC:      "20060102T150405Z",
+	untilFloating: "20060102T150405",
+	untilDate:     "20060102",
+}
+
+// Parse - разбирает строку вида "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10"
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1, WeekStart: time.Monday}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Freq(strings.ToUpper(value))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && rule.Interval < 1 {
+				err = errors.New("INTERVAL must be positive")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("COUNT must be positive")
+			}
+		case "UNTIL":
+			rule.Until, rule.untilKind, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "BYMONTH":
+			rule.ByMonth, err = parseByMonth(value)
+		case "WKST":
+			wd, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", value)
+			}
+			rule.WeekStart = wd
+		default:
+			err = fmt.Errorf("unsupported part %q", key)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	}
+	if rule.Freq == Daily || rule.Freq == Weekly {
+		for _, d := range rule.ByDay {
+			if d.N != 0 {
+				return Rule{}, fmt.Errorf("%w: BYDAY with ordinal is supported only for MONTHLY and YEARLY", ErrInvalidRule)
+			}
+		}
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return Rule{}, fmt.Errorf("%w: BYMONTHDAY is not allowed for WEEKLY", ErrInvalidRule)
+	}
+
+	return rule, nil
+}
+
+func parseUntil(s string) (time.Time, untilKind, error) {
+	for kind, layout := range untilLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, untilKind(kind), nil
+		}
+	}
+	return time.Time{}, 0, fmt.Errorf("invalid UNTIL %q", s)
+}
+
+func parseByDay(s string) ([]Weekday, error) {
+	var days []Weekday
+	seen := make(map[Weekday]bool)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.ToUpper(strings.TrimSpace(v))
+		if len(v) < 2 {
+			return nil, fmt.Errorf("unsupported BYDAY value %q", v)
+		}
+		wd, ok := weekdays[v[len(v)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported BYDAY value %q", v)
+		}
+		d := Weekday{Day: wd}
+		if prefix := v[:len(v)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("unsupported BYDAY value %q", v)
+			}
+			d.N = n
+		}
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+
+	// порядок внутри недели, начиная с понедельника
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].Day != days[j].Day {
+			return mondayOffset(days[i].Day) < mondayOffset(days[j].Day)
+		}
+		return days[i].N < days[j].N
+	})
+	return days, nil
+}
+
+func parseByMonthDay(s string) ([]int, error) {
+	var days []int
+	for _, v := range strings.Split(s, ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || d == 0 || d < -31 || d > 31 {
+			return nil, fmt.Errorf("invalid BYMONTHDAY value %q", v)
+		}
+		if !slices.Contains(days, d) {
+			days = append(days, d)
+		}
+	}
+	slices.Sort(days)
+	return days, nil
+}
+
+func parseByMonth(s string) ([]time.Month, error) {
+	var months []time.Month
+	for _, v := range strings.Split(s, ",") {
+		m, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || m < 1 || m > 12 {
+			return nil, fmt.Errorf("invalid BYMONTH value %q", v)
+		}
+		if !slices.Contains(months, time.Month(m)) {
+			months = append(months, time.Month(m))
+		}
+	}
+	slices.Sort(months)
+	return months, nil
+}
+
+// String - строковое представление в формате RRULE
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, 0, len(r.ByMonth))
+		for _, m := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(m)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, d.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+Weekday{Day: r.WeekStart}.String())
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayouts[r.untilKind]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between - начала вхождений серии с первым вхождением start, попадающие в полуинтервал [from, to).
+// Вхождения из exdates исключаются (COUNT при этом учитывает и их, как в RFC 5545).
+func (r Rule) Between(start, from, to time.Time, exdates []time.Time) []time.Time {
+	var out []time.Time
+	r.each(start, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) && !slices.ContainsFunc(exdates, t.Equal) {
+			out = append(out, t)
+		}
+		return true
+	})
+	return out
+}
+
+// Includes - является ли t началом одного из вхождений серии
+func (r Rule) Includes(start, t time.Time) bool {
+	found := false
+	r.each(start, func(occ time.Time) bool {
+		if occ.Equal(t) {
+			found = true
+		}
+		return occ.Before(t)
+	})
+	return found
+}
+
+// each - перебирает вхождения по возрастанию, пока yield возвращает true
+func (r Rule) each(start time.Time, yield func(time.Time) bool) {
+	interval := max(r.Interval, 1)
+	count := 0
+	until := r.until(start.Location())
+
+	emit := func(t time.Time) bool {
+		if !until.IsZero() && t.After(until) {
+			return false
+		}
+		count++
+		if !yield(t) {
+			return false
+		}
+		return r.Count == 0 || count < r.Count
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		var period []time.Time
+		switch r.Freq {
+		case Daily:
+			t := start.AddDate(0, 0, i*interval)
+			if !r.matchDay(t) {
+				continue
+			}
+			period = []time.Time{t}
+		case Weekly:
+			period = r.weekDays(start, i*interval)
+		case Monthly:
+			first := time.Date(start.Year(), start.Month()+time.Month(i*interval), 1, 0, 0, 0, 0, start.Location())
+			if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, first.Month()) {
+				continue
+			}
+			period = r.monthDays(start, first.Year(), first.Month())
+		case Yearly:
+			period = r.yearDays(start, start.Year()+i*interval)
+		default:
+			return
+		}
+
+		for _, t := range period {
+			if t.Before(start) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// until - последний момент, в который может начаться вхождение, для серии в зоне loc
+func (r Rule) until(loc *time.Location) time.Time {
+	u := r.Until
+	switch {
+	case u.IsZero():
+		return u
+	case r.untilKind == untilFloating:
+		return time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc)
+	case r.untilKind == untilDate:
+		return time.Date(u.Year(), u.Month(), u.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	default:
+		return u
+	}
+}
+
+// matchDay - подходит ли день под BYDAY, BYMONTHDAY и BYMONTH (для DAILY они только ограничивают)
+func (r Rule) matchDay(t time.Time) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, t.Month()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 {
+		n := daysIn(t.Year(), t.Month())
+		if !slices.ContainsFunc(r.ByMonthDay, func(d int) bool { return d == t.Day() || n+d+1 == t.Day() }) {
+			return false
+		}
+	}
+	if len(r.ByDay) > 0 && !slices.ContainsFunc(r.ByDay, func(d Weekday) bool { return d.Day == t.Weekday() }) {
+		return false
+	}
+	return true
+}
+
+// weekDays - вхождения недели, отстоящей от первой на weeks недель; неделя начинается с WKST
+func (r Rule) weekDays(start time.Time, weeks int) []time.Time {
+	offset := func(d time.Weekday) int {
+		return (int(d) - int(r.WeekStart) + 7) % 7
+	}
+	weekStart := start.AddDate(0, 0, -offset(start.Weekday())+weeks*7)
+
+	var days []time.Time
+	if len(r.ByDay) == 0 {
+		days = []time.Time{weekStart.AddDate(0, 0, offset(start.Weekday()))}
+	}
+	for _, d := range r.ByDay {
+		days = append(days, weekStart.AddDate(0, 0, offset(d.Day)))
+	}
+	slices.SortFunc(days, time.Time.Compare)
+
+	if len(r.ByMonth) == 0 {
+		return days
+	}
+	return slices.DeleteFunc(days, func(t time.Time) bool { return !slices.Contains(r.ByMonth, t.Month()) })
+}
+
+// monthDays - вхождения в месяце по возрастанию. Без BYMONTHDAY и BYDAY - число начала серии;
+// если такого числа в месяце нет (например, 31-го), вхождение пропускается
+func (r Rule) monthDays(start time.Time, year int, month time.Month) []time.Time {
+	n := daysIn(year, month)
+
+	var days []int
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d += n + 1
+			}
+			if d >= 1 && d <= n {
+				days = append(days, d)
+			}
+		}
+		// вместе с BYMONTHDAY день недели только ограничивает
+		if len(r.ByDay) > 0 {
+			byDay := r.spanDays(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), n)
+			days = slices.DeleteFunc(days, func(d int) bool { return !slices.Contains(byDay, d) })
+		}
+	case len(r.ByDay) > 0:
+		days = r.spanDays(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), n)
+	case start.Day() <= n:
+		days = []int{start.Day()}
+	}
+
+	slices.Sort(days)
+	days = slices.Compact(days)
+
+	out := make([]time.Time, 0, len(days))
+	for _, d := range days {
+		out = append(out, at(start, year, month, d))
+	}
+	return out
+}
+
+// yearDays - вхождения в году по возрастанию. BYDAY без BYMONTH и BYMONTHDAY
+// нумеруется в пределах года, иначе - в пределах каждого месяца
+func (r Rule) yearDays(start time.Time, year int) []time.Time {
+	if len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 {
+		n := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+		days := r.spanDays(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), n)
+		slices.Sort(days)
+
+		out := make([]time.Time, 0, len(days))
+		for _, d := range slices.Compact(days) {
+			// 32 января нормализуется в 1 февраля и т.д.
+			out = append(out, at(start, year, time.January, d))
+		}
+		return out
+	}
+
+	months := r.ByMonth
+	if len(months) == 0 {
+		if len(r.ByMonthDay) > 0 {
+			months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		} else {
+			months = []time.Month{start.Month()}
+		}
+	}
+
+	var out []time.Time
+	for _, m := range months {
+		out = append(out, r.monthDays(start, year, m)...)
+	}
+	return out
+}
+
+// spanDays - номера дней (с 1) из n дней начиная с first, подходящих под BYDAY;
+// порядковый номер считается от начала или, если он отрицательный, от конца промежутка
+func (r Rule) spanDays(first time.Time, n int) []int {
+	var days []int
+	for _, wd := range r.ByDay {
+		// первый такой день недели в промежутке
+		d := 1 + (int(wd.Day)-int(first.Weekday())+7)%7
+		var matches []int
+		for ; d <= n; d += 7 {
+			matches = append(matches, d)
+		}
+
+		switch {
+		case wd.N == 0:
+			days = append(days, matches...)
+		case wd.N > 0 && wd.N <= len(matches):
+			days = append(days, matches[wd.N-1])
+		case wd.N < 0 && -wd.N <= len(matches):
+			days = append(days, matches[len(matches)+wd.N])
+		}
+	}
+	return days
+}
+
+// at - день в году и месяце со временем начала серии в ее зоне
+func at(start time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+}
+
+// daysIn - число дней в месяце
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func mondayOffset(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
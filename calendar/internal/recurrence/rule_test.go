@@ -0,0 +1,260 @@
+package recurrence_test
+
+import (
+	"Calendar/internal/recurrence"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dt(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func dates(times []time.Time) []string {
+	out := make([]string, 0, len(times))
+	for _, t := range times {
+		out = append(out, t.Format("2006-01-02 15:04"))
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	rule, err := recurrence.Parse("RRULE:FREQ=weekly;INTERVAL=2;BYDAY=WE,MO;COUNT=4")
+	require.NoError(t, err)
+	require.Equal(t, recurrence.Weekly, rule.Freq)
+	require.Equal(t, 2, rule.Interval)
+	require.Equal(t, []recurrence.Weekday{{Day: time.Monday}, {Day: time.Wednesday}}, rule.ByDay)
+	require.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=4", rule.String())
+
+	rule, err = recurrence.Parse("FREQ=MONTHLY;BYDAY=-1FR,2TU;BYMONTHDAY=-1,13;BYMONTH=11,2;WKST=SU")
+	require.NoError(t, err)
+	require.Equal(t, []recurrence.Weekday{{Day: time.Tuesday, N: 2}, {Day: time.Friday, N: -1}}, rule.ByDay)
+	require.Equal(t, []int{-1, 13}, rule.ByMonthDay)
+	require.Equal(t, []time.Month{time.February, time.November}, rule.ByMonth)
+	require.Equal(t, time.Sunday, rule.WeekStart)
+	require.Equal(t, "FREQ=MONTHLY;BYMONTH=2,11;BYMONTHDAY=-1,13;BYDAY=2TU,-1FR;WKST=SU", rule.String())
+
+	for _, bad := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYDAY=-1FR",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=54MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=WEEKLY;WKST=XX",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		_, err := recurrence.Parse(bad)
+		require.ErrorIs(t, err, recurrence.ErrInvalidRule, bad)
+	}
+}
+
+func TestBetween(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    string
+		start   string
+		from    string
+		to      string
+		exdates []time.Time
+		want    []string
+	}{
+		{
+			name:  "daily with interval",
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: "2025-01-01 09:00",
+			from:  "2025-01-02 00:00",
+			to:    "2025-01-08 00:00",
+			want:  []string{"2025-01-03 09:00", "2025-01-05 09:00", "2025-01-07 09:00"},
+		},
+		{
+			name:  "weekdays only",
+			rule:  "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			start: "2025-01-03 10:00",
+			from:  "2025-01-01 00:00",
+			to:    "2025-01-08 00:00",
+			want:  []string{"2025-01-03 10:00", "2025-01-06 10:00", "2025-01-07 10:00"},
+		},
+		{
+			name:  "weekly byday with count",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=3",
+			start: "2025-01-02 10:00",
+			from:  "2025-01-01 00:00",
+			to:    "2025-02-01 00:00",
+			want:  []string{"2025-01-02 10:00", "2025-01-06 10:00", "2025-01-09 10:00"},
+		},
+		{
+			name:    "biweekly with exdate and until",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;UNTIL=20250215T000000Z",
+			start:   "2025-01-06 10:00",
+			from:    "2025-01-01 00:00",
+			to:      "2025-12-31 00:00",
+			exdates: []time.Time{dt("2025-01-20 10:00")},
+			want:    []string{"2025-01-06 10:00", "2025-02-03 10:00"},
+		},
+		{
+			name:  "monthly skips short months",
+			rule:  "FREQ=MONTHLY;COUNT=3",
+			start: "2025-01-31 12:00",
+			from:  "2025-01-01 00:00",
+			to:    "2026-01-01 00:00",
+			want:  []string{"2025-01-31 12:00", "2025-03-31 12:00", "2025-05-31 12:00"},
+		},
+		{
+			name:  "monthly second tuesday",
+			rule:  "FREQ=MONTHLY;BYDAY=2TU;COUNT=3",
+			start: "2025-01-14 09:00",
+			from:  "2025-01-01 00:00",
+			to:    "2026-01-01 00:00",
+			want:  []string{"2025-01-14 09:00", "2025-02-11 09:00", "2025-03-11 09:00"},
+		},
+		{
+			name:  "monthly last friday",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: "2025-01-31 17:00",
+			from:  "2025-01-01 00:00",
+			to:    "2025-04-01 00:00",
+			want:  []string{"2025-01-31 17:00", "2025-02-28 17:00", "2025-03-28 17:00"},
+		},
+		{
+			name:  "monthly first and last day",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=1,-1",
+			start: "2025-01-01 08:00",
+			from:  "2025-01-01 00:00",
+			to:    "2025-03-01 00:00",
+			want:  []string{"2025-01-01 08:00", "2025-01-31 08:00", "2025-02-01 08:00", "2025-02-28 08:00"},
+		},
+		{
+			name:  "friday the thirteenth",
+			rule:  "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			start: "2025-06-13 12:00",
+			from:  "2025-01-01 00:00",
+			to:    "2026-03-01 00:00",
+			want:  []string{"2025-06-13 12:00", "2026-02-13 12:00"},
+		},
+		{
+			name:  "yearly fourth thursday of november",
+			rule:  "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+			start: "2024-11-28 15:00",
+			from:  "2024-01-01 00:00",
+			to:    "2027-01-01 00:00",
+			want:  []string{"2024-11-28 15:00", "2025-11-27 15:00", "2026-11-26 15:00"},
+		},
+		{
+			name:  "yearly first monday of the year",
+			rule:  "FREQ=YEARLY;BYDAY=1MO",
+			start: "2025-01-06 10:00",
+			from:  "2025-01-01 00:00",
+			to:    "2027-01-01 00:00",
+			want:  []string{"2025-01-06 10:00", "2026-01-05 10:00"},
+		},
+		{
+			name:  "biweekly with week start monday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			start: "1997-08-05 09:00",
+			from:  "1997-01-01 00:00",
+			to:    "1998-01-01 00:00",
+			want:  []string{"1997-08-05 09:00", "1997-08-10 09:00", "1997-08-19 09:00", "1997-08-24 09:00"},
+		},
+		{
+			name:  "biweekly with week start sunday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			start: "1997-08-05 09:00",
+			from:  "1997-01-01 00:00",
+			to:    "1998-01-01 00:00",
+			want:  []string{"1997-08-05 09:00", "1997-08-17 09:00", "1997-08-19 09:00", "1997-08-31 09:00"},
+		},
+		{
+			name:  "yearly leap day",
+			rule:  "FREQ=YEARLY",
+			start: "2024-02-29 08:00",
+			from:  "2024-01-01 00:00",
+			to:    "2029-01-01 00:00",
+			want:  []string{"2024-02-29 08:00", "2028-02-29 08:00"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := recurrence.Parse(tt.rule)
+			require.NoError(t, err)
+
+			got := rule.Between(dt(tt.start), dt(tt.from), dt(tt.to), tt.exdates)
+			require.Equal(t, tt.want, dates(got))
+		})
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	rule, err := recurrence.Parse("FREQ=WEEKLY;BYDAY=MO,WE")
+	require.NoError(t, err)
+
+	start := dt("2025-01-06 10:00")
+	require.True(t, rule.Includes(start, dt("2025-01-15 10:00")))
+	require.False(t, rule.Includes(start, dt("2025-01-15 11:00")))
+	require.False(t, rule.Includes(start, dt("2025-01-14 10:00")))
+}
+
+func TestUntil(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	local := func(s string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", s, ny)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	testCases := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []string
+	}{
+		{
+			// дата включается целиком, даже если в UTC вхождение уже на следующий день
+			name:  "date is inclusive",
+			rule:  "FREQ=DAILY;UNTIL=20250103",
+			start: local("2025-01-01 20:00"),
+			want:  []string{"2025-01-01 20:00", "2025-01-02 20:00", "2025-01-03 20:00"},
+		},
+		{
+			name:  "floating time in series zone",
+			rule:  "FREQ=DAILY;UNTIL=20250103T100000",
+			start: local("2025-01-01 10:00"),
+			want:  []string{"2025-01-01 10:00", "2025-01-02 10:00", "2025-01-03 10:00"},
+		},
+		{
+			name:  "utc time",
+			rule:  "FREQ=DAILY;UNTIL=20250103T100000Z",
+			start: local("2025-01-01 10:00"),
+			want:  []string{"2025-01-01 10:00", "2025-01-02 10:00"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := recurrence.Parse(tt.rule)
+			require.NoError(t, err)
+			// форма UNTIL сохраняется
+			require.Equal(t, tt.rule, rule.String())
+
+			got := rule.Between(tt.start, tt.start, tt.start.AddDate(0, 1, 0), nil)
+			require.Equal(t, tt.want, dates(got))
+		})
+	}
+}
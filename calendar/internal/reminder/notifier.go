@@ -0,0 +1,110 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Reminder - напоминание о событии
+type Reminder struct {
+	EventID int64     `json:"event_id"`
+	UserID  int64     `json:"user_id"`
+	Title   string    `json:"title"`
+	Start   time.Time `json:"start"`
+	At      time.Time `json:"at"`
+}
+
+// Notifier - способ доставки напоминаний
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// LogNotifier - пишет напоминания в лог
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+// NewLogNotifier - конструктор
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+// Notify - логирует напоминание
+func (n *LogNotifier) Notify(ctx context.Context, r Reminder) error {
+	n.log.InfoContext(ctx, "reminder",
+		slog.Int64("event_id", r.EventID),
+		slog.Int64("user_id", r.UserID),
+		slog.String("title", r.Title),
+		slog.Time("start", r.Start),
+	)
+	return nil
+}
+
+// WriterNotifier - пишет напоминания строками в io.Writer (например, os.Stdout)
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterNotifier - конструктор
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+// Notify - выводит напоминание
+func (n *WriterNotifier) Notify(ctx context.Context, r Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.w, "reminder: user %d, event %d %q starts at %s\n",
+		r.UserID, r.EventID, r.Title, r.Start.Format(time.RFC3339))
+	return err
+}
+
+// WebhookNotifier - отправляет напоминания POST запросом с JSON телом
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier - конструктор с таймаутом запроса
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Notify - отправляет напоминание на webhook
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("marshal reminder: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post %s: %w", n.url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("bad status %d from %s", resp.StatusCode, n.url)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package reminder
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Source - источник событий с напоминаниями
+type Source interface {
+	// Reminders - события, напоминания о которых приходятся на полуинтервал [from, to)
+	Reminders(ctx context.Context, from, to time.Time) ([]domain.Event, error)
+}
+
+// Scheduler - фоновый планировщик напоминаний.
+// Раз в interval выбирает наступившие напоминания и доставляет их через Notifier.
+type Scheduler struct {
+	src      Source
+	sent     SentStore
+	notifier Notifier
+	log      *slog.Logger
+
+	interval time.Duration
+	// насколько назад смотреть при старте и повторах, чтобы не терять напоминания
+	lookback time.Duration
+
+	since time.Time
+	// когда последний раз забывались отправленные напоминания за пределами окна
+	pruned time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler - конструктор
+func NewScheduler(src Source, sent SentStore, notifier Notifier, log *slog.Logger, interval, lookback time.Duration) *Scheduler {
+	return &Scheduler{
+		src:      src,
+		sent:     sent,
+		notifier: notifier,
+		log:      log,
+		interval: interval,
+		lookback: lookback,
+	}
+}
+
+// Start - запускает планировщик в фоне
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.since = time.Now().Add(-s.lookback)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.Tick(ctx, time.Now())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close - останавливает планировщик и дожидается завершения текущей итерации
+func (s *Scheduler) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// Tick - доставляет напоминания, наступившие к моменту now с прошлой итерации.
+// Если какое-то напоминание не удалось доставить, окно не сдвигается и оно будет повторено.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	oldest := now.Add(-s.lookback)
+	if s.since.Before(oldest) {
+		s.since = oldest
+	}
+	s.prune(ctx, now, oldest)
+
+	events, err := s.src.Reminders(ctx, s.since, now)
+	if err != nil {
+		s.log.Error("failed to load reminders", slog.String("err", err.Error()))
+		return
+	}
+
+	ok := true
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.fire(ctx, e); err != nil {
+			s.log.Error("failed to send reminder",
+				slog.Int64("event_id", e.ID),
+				slog.String("err", err.Error()),
+			)
+			ok = false
+		}
+	}
+
+	if ok {
+		s.since = now
+	}
+}
+
+// pruneInterval - как часто забывать отправленные напоминания, которые уже не попадут в окно
+const pruneInterval = 10 * time.Minute
+
+// prune - забывает отметки напоминаний раньше oldest, чтобы учет не рос без ограничений
+func (s *Scheduler) prune(ctx context.Context, now, oldest time.Time) {
+	if !s.pruned.IsZero() && now.Sub(s.pruned) < pruneInterval {
+		return
+	}
+	if err := s.sent.Prune(ctx, oldest); err != nil {
+		s.log.Error("failed to prune sent reminders", slog.String("err", err.Error()))
+		return
+	}
+	s.pruned = now
+}
+
+// fire - отправляет напоминание, если оно еще не было отправлено
+func (s *Scheduler) fire(ctx context.Context, e domain.Event) error {
+	at, _ := e.RemindAt()
+	r := Reminder{
+		EventID: e.ID,
+		UserID:  e.UserID,
+		Title:   e.Title,
+		Start:   e.Start,
+		At:      at,
+	}
+
+	sent, err := s.sent.IsSent(ctx, r)
+	if err != nil || sent {
+		return err
+	}
+
+	if err := s.notifier.Notify(ctx, r); err != nil {
+		return err
+	}
+	return s.sent.MarkSent(ctx, r)
+}
@@ -0,0 +1,189 @@
+package reminder_test
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/reminder"
+	"Calendar/internal/storage/memory"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu        sync.Mutex
+	reminders []reminder.Reminder
+}
+
+func (r *recorder) handler(w http.ResponseWriter, req *http.Request) {
+	var rem reminder.Reminder
+	if err := json.NewDecoder(req.Body).Decode(&rem); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reminders = append(r.reminders, rem)
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reminders)
+}
+
+func TestSchedulerWebhook(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2025, 1, 15, 9, 50, 0, 0, time.UTC)
+
+	repo := memory.New()
+	start := now.Add(10 * time.Minute)
+	_, err := repo.Create(ctx, domain.Event{UserID: 1, Title: "standup", Start: start, End: start, RemindBefore: 15 * time.Minute}, domain.AuditEntry{Action: domain.AuditCreated})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, domain.Event{UserID: 1, Title: "later", Start: start, End: start, RemindBefore: 5 * time.Minute}, domain.AuditEntry{Action: domain.AuditCreated})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, domain.Event{UserID: 1, Title: "no reminder", Start: start, End: start}, domain.AuditEntry{Action: domain.AuditCreated})
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	stub := httptest.NewServer(http.HandlerFunc(rec.handler))
+	defer stub.Close()
+
+	path := filepath.Join(t.TempDir(), "sent.jsonl")
+	sent, err := reminder.OpenFileSent(path)
+	require.NoError(t, err)
+
+	notifier := reminder.NewWebhookNotifier(stub.URL, time.Second)
+	s := reminder.NewScheduler(repo, sent, notifier, log, time.Minute, time.Hour)
+
+	s.Tick(ctx, now)
+	require.Equal(t, 1, rec.count())
+	require.Equal(t, "standup", rec.reminders[0].Title)
+
+	// повторный запуск в том же окне не дублирует напоминание
+	s.Tick(ctx, now)
+	require.Equal(t, 1, rec.count())
+
+	s.Tick(ctx, now.Add(6*time.Minute))
+	require.Equal(t, 2, rec.count())
+	require.NoError(t, sent.Close())
+
+	// после рестарта отправленные напоминания не срабатывают снова
+	sent, err = reminder.OpenFileSent(path)
+	require.NoError(t, err)
+	defer sent.Close()
+
+	s = reminder.NewScheduler(repo, sent, notifier, log, time.Minute, time.Hour)
+	s.Tick(ctx, now.Add(7*time.Minute))
+	require.Equal(t, 2, rec.count())
+}
+
+type failingNotifier struct {
+	fail  bool
+	calls int
+}
+
+func (n *failingNotifier) Notify(ctx context.Context, r reminder.Reminder) error {
+	n.calls++
+	if n.fail {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func TestSchedulerRetriesFailed(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	repo := memory.New()
+	_, err := repo.Create(ctx, domain.Event{UserID: 1, Title: "standup", Start: now, End: now, RemindBefore: time.Minute}, domain.AuditEntry{Action: domain.AuditCreated})
+	require.NoError(t, err)
+
+	notifier := &failingNotifier{fail: true}
+	s := reminder.NewScheduler(repo, reminder.NewMemorySent(), notifier, log, time.Minute, time.Hour)
+
+	s.Tick(ctx, now)
+	require.Equal(t, 1, notifier.calls)
+
+	notifier.fail = false
+	s.Tick(ctx, now.Add(time.Minute))
+	require.Equal(t, 2, notifier.calls)
+
+	s.Tick(ctx, now.Add(2*time.Minute))
+	require.Equal(t, 2, notifier.calls)
+}
+
+func TestSchedulerSeriesOccurrences(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Date(2025, 1, 13, 10, 0, 0, 0, time.UTC)
+
+	repo := memory.New()
+	_, err := repo.Create(ctx, domain.Event{
+		UserID: 1, Title: "standup", Start: start, End: start.Add(15 * time.Minute),
+		RemindBefore: 10 * time.Minute, RRule: "FREQ=DAILY",
+		ExDates: []time.Time{start.AddDate(0, 0, 1)},
+	}, domain.AuditEntry{Action: domain.AuditCreated})
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	stub := httptest.NewServer(http.HandlerFunc(rec.handler))
+	defer stub.Close()
+
+	notifier := reminder.NewWebhookNotifier(stub.URL, time.Second)
+	s := reminder.NewScheduler(repo, reminder.NewMemorySent(), notifier, log, time.Minute, time.Hour)
+
+	// напоминание о вхождении через два дня после начала серии
+	s.Tick(ctx, start.AddDate(0, 0, 2).Add(-5*time.Minute))
+	require.Equal(t, 1, rec.count())
+	require.True(t, start.AddDate(0, 0, 2).Equal(rec.reminders[0].Start))
+
+	// исключенное вхождение не напоминает
+	s = reminder.NewScheduler(repo, reminder.NewMemorySent(), notifier, log, time.Minute, time.Hour)
+	s.Tick(ctx, start.AddDate(0, 0, 1).Add(-5*time.Minute))
+	require.Equal(t, 1, rec.count())
+}
+
+func TestFileSentPrune(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	old := reminder.Reminder{EventID: 1, At: now.Add(-2 * time.Hour)}
+	fresh := reminder.Reminder{EventID: 2, At: now.Add(-time.Minute)}
+	later := reminder.Reminder{EventID: 3, At: now}
+
+	path := filepath.Join(t.TempDir(), "sent.jsonl")
+	sent, err := reminder.OpenFileSent(path)
+	require.NoError(t, err)
+	require.NoError(t, sent.MarkSent(ctx, old))
+	require.NoError(t, sent.MarkSent(ctx, fresh))
+
+	require.NoError(t, sent.Prune(ctx, now.Add(-time.Hour)))
+	ok, err := sent.IsSent(ctx, old)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// после сжатия файла запись продолжается в него же
+	require.NoError(t, sent.MarkSent(ctx, later))
+	require.NoError(t, sent.Close())
+
+	sent, err = reminder.OpenFileSent(path)
+	require.NoError(t, err)
+	defer sent.Close()
+
+	for r, want := range map[reminder.Reminder]bool{old: false, fresh: true, later: true} {
+		ok, err := sent.IsSent(ctx, r)
+		require.NoError(t, err)
+		require.Equal(t, want, ok, "event %d", r.EventID)
+	}
+}
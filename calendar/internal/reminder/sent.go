@@ -0,0 +1,259 @@
+package reminder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SentStore - учет уже отправленных напоминаний
+type SentStore interface {
+	IsSent(ctx context.Context, r Reminder) (bool, error)
+	MarkSent(ctx context.Context, r Reminder) error
+	// Prune - забывает напоминания, сработавшие раньше before: планировщик их уже не выбирает
+	Prune(ctx context.Context, before time.Time) error
+}
+
+// sentKey - ключ напоминания: при переносе события напоминание срабатывает заново
+type sentKey struct {
+	EventID int64     `json:"event_id"`
+	At      time.Time `json:"at"`
+}
+
+func keyOf(r Reminder) sentKey {
+	return sentKey{EventID: r.EventID, At: r.At.UTC()}
+}
+
+// MemorySent - учет отправленных напоминаний в памяти
+type MemorySent struct {
+	mu   sync.Mutex
+	sent map[sentKey]struct{}
+}
+
+// NewMemorySent - конструктор
+func NewMemorySent() *MemorySent {
+	return &MemorySent{sent: make(map[sentKey]struct{})}
+}
+
+// IsSent - было ли напоминание отправлено
+func (s *MemorySent) IsSent(_ context.Context, r Reminder) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.sent[keyOf(r)]
+	return ok, nil
+}
+
+// MarkSent - отмечает напоминание отправленным
+func (s *MemorySent) MarkSent(_ context.Context, r Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent[keyOf(r)] = struct{}{}
+	return nil
+}
+
+// Prune - забывает напоминания, сработавшие раньше before
+func (s *MemorySent) Prune(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(before)
+	return nil
+}
+
+// prune - удаляет старые ключи и сообщает, было ли что удалять; вызывается под mu
+func (s *MemorySent) prune(before time.Time) bool {
+	pruned := false
+	for key := range s.sent {
+		if key.At.Before(before) {
+			delete(s.sent, key)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// FileSent - учет отправленных напоминаний с сохранением в файл JSON-строк
+type FileSent struct {
+	mem  *MemorySent
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// OpenFileSent - открывает файл и загружает из него отправленные напоминания
+func OpenFileSent(path string) (*FileSent, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	s := &FileSent{mem: NewMemorySent(), path: path, f: f}
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// недописанную строку отрезаем
+			if len(data) > 0 {
+				err = f.Truncate(offset)
+			} else {
+				err = nil
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("truncate %s: %w", path, err)
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		offset += int64(len(data))
+
+		var key sentKey
+		if err := json.Unmarshal(data, &key); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("decode %s: %w", path, err)
+		}
+		s.mem.sent[key] = struct{}{}
+	}
+
+	return s, nil
+}
+
+// IsSent - было ли напоминание отправлено
+func (s *FileSent) IsSent(ctx context.Context, r Reminder) (bool, error) {
+	return s.mem.IsSent(ctx, r)
+}
+
+// MarkSent - отмечает напоминание отправленным и дописывает его в файл
+func (s *FileSent) MarkSent(ctx context.Context, r Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(keyOf(r))
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	return s.mem.MarkSent(ctx, r)
+}
+
+// Prune - забывает напоминания, сработавшие раньше before, и переписывает файл без них.
+// Файл заменяется атомарно: при сбое остается либо старая, либо новая версия.
+func (s *FileSent) Prune(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	if !s.mem.prune(before) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+
+	if err := writeKeys(tmp, s.mem.sent); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("rename: %w", err)
+	}
+
+	// дальше дописываем в новый файл: позиция уже в его конце
+	s.f.Close()
+	s.f = tmp
+
+	return nil
+}
+
+// writeKeys - записывает ключи JSON-строками и сбрасывает файл на диск
+func writeKeys(f *os.File, keys map[sentKey]struct{}) error {
+	// временный файл создается с правами 0600, а журнал - с 0644
+	if err := f.Chmod(0644); err != nil {
+		return fmt.Errorf("chmod: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	for key := range keys {
+		data, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	return nil
+}
+
+// Close - закрывает файл
+func (s *FileSent) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.f.Close()
+}
+
+// SentRepository - хранилище событий, которое само учитывает отправленные напоминания
+type SentRepository interface {
+	IsReminderSent(ctx context.Context, eventID int64, at time.Time) (bool, error)
+	MarkReminderSent(ctx context.Context, eventID int64, at time.Time) error
+	PruneRemindersSent(ctx context.Context, before time.Time) error
+}
+
+// RepoSent - учет отправленных напоминаний в хранилище событий
+type RepoSent struct {
+	repo SentRepository
+}
+
+// NewRepoSent - конструктор
+func NewRepoSent(repo SentRepository) *RepoSent {
+	return &RepoSent{repo: repo}
+}
+
+// IsSent - было ли напоминание отправлено
+func (s *RepoSent) IsSent(ctx context.Context, r Reminder) (bool, error) {
+	key := keyOf(r)
+	return s.repo.IsReminderSent(ctx, key.EventID, key.At)
+}
+
+// MarkSent - отмечает напоминание отправленным
+func (s *RepoSent) MarkSent(ctx context.Context, r Reminder) error {
+	key := keyOf(r)
+	return s.repo.MarkReminderSent(ctx, key.EventID, key.At)
+}
+
+// Prune - забывает напоминания, сработавшие раньше before
+func (s *RepoSent) Prune(ctx context.Context, before time.Time) error {
+	return s.repo.PruneRemindersSent(ctx, before)
+}
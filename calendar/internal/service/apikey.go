@@ -0,0 +1,49 @@
+package service
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CreateAPIKey - выпускает API ключ пользователя. Ключ возвращается только здесь,
+// в хранилище остается его хэш.
+func (c *Calendar) CreateAPIKey(ctx context.Context, userID int64, name string) (domain.APIKey, string, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.APIKey{}, "", err
+	}
+
+	key, secret := auth.NewAPIKey(userID, strings.TrimSpace(name))
+	if err := c.repo.SaveAPIKey(ctx, key); err != nil {
+		return domain.APIKey{}, "", fmt.Errorf("save api key: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// RevokeAPIKey - удаляет API ключ пользователя
+func (c *Calendar) RevokeAPIKey(ctx context.Context, userID int64, keyID string) error {
+	if err := checkUser(ctx, userID); err != nil {
+		return err
+	}
+
+	key, err := c.repo.GetAPIKey(ctx, keyID)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("get api key: %w", err)
+	}
+	// чужой ключ неотличим от несуществующего
+	if key.UserID != userID {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	if err := c.repo.DeleteAPIKey(ctx, keyID); err != nil && !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RestoreEvent - восстанавливает удаленное событие, если срок хранения не истек
+func (c *Calendar) RestoreEvent(ctx context.Context, userID, eventID int64) (domain.Event, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.Event{}, err
+	}
+
+	deleted, at, err := c.repo.Deleted(ctx, eventID)
+	if errors.Is(err, domain.ErrEventNotFound) {
+		return domain.Event{}, c.notRestorable(ctx, userID, eventID)
+	}
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("get deleted event: %w", err)
+	}
+	if err := c.checkDeletedAccess(ctx, userID, deleted, domain.PermissionWrite); err != nil {
+		return domain.Event{}, err
+	}
+	if at.Before(time.Now().Add(-c.retention)) {
+		return domain.Event{}, domain.ErrRestoreExpired
+	}
+
+	e, err := c.repo.Restore(ctx, eventID, auditEntry(userID, domain.AuditRestored, nil))
+	if errors.Is(err, domain.ErrEventNotFound) {
+		// уже удалено окончательно
+		return domain.Event{}, domain.ErrRestoreExpired
+	}
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("restore event: %w", err)
+	}
+
+	c.notify(ctx, domain.ChangeCreated, e)
+	return e, nil
+}
+
+// notRestorable - почему события нет в корзине: оно не удалялось (domain.ErrNotDeleted),
+// удалено окончательно (domain.ErrRestoreExpired) или недоступно пользователю
+func (c *Calendar) notRestorable(ctx context.Context, userID, eventID int64) error {
+	if _, err := c.userEvent(ctx, userID, eventID); !errors.Is(err, domain.ErrEventNotFound) {
+		if err != nil {
+			return err
+		}
+		return domain.ErrNotDeleted
+	}
+
+	// окончательно удаленное событие остается в журнале изменений
+	entries, err := c.repo.ListAudit(ctx, eventID)
+	if err != nil {
+		return fmt.Errorf("list audit: %w", err)
+	}
+	if len(entries) == 0 || entries[len(entries)-1].Action != domain.AuditDeleted {
+		return domain.ErrEventNotFound
+	}
+	if err := c.checkDeletedAccess(ctx, userID, entries[len(entries)-1].Snapshot(), domain.PermissionWrite); err != nil {
+		return err
+	}
+	return domain.ErrRestoreExpired
+}
+
+// EventHistory - журнал изменений события по времени; доступен и после удаления события
+func (c *Calendar) EventHistory(ctx context.Context, userID, eventID int64) ([]domain.AuditEntry, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	entries, err := c.repo.ListAudit(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("list audit: %w", err)
+	}
+
+	_, err = c.accessibleEvent(ctx, userID, eventID, domain.PermissionRead)
+	if errors.Is(err, domain.ErrEventNotFound) && len(entries) > 0 {
+		// удаленное событие: права - по последнему состоянию из журнала
+		err = c.checkDeletedAccess(ctx, userID, entries[len(entries)-1].Snapshot(), domain.PermissionRead)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// PurgeDeleted - окончательно удаляет события, срок восстановления которых истек
+func (c *Calendar) PurgeDeleted(ctx context.Context) (int, error) {
+	n, err := c.repo.PurgeDeleted(ctx, time.Now().UTC().Add(-c.retention))
+	if err != nil {
+		return 0, fmt.Errorf("purge deleted events: %w", err)
+	}
+	return n, nil
+}
+
+// checkDeletedAccess - есть ли у пользователя право need на удаленное событие
+func (c *Calendar) checkDeletedAccess(ctx context.Context, userID int64, e domain.Event, need domain.Permission) error {
+	perm, err := c.eventPermission(ctx, userID, e)
+	if err != nil {
+		return err
+	}
+	if perm == "" {
+		return domain.ErrEventNotFound
+	}
+	if !perm.Allows(need) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// auditEntry - запись журнала об изменении события, сделанном пользователем actorID;
+// before - состояние до изменения, остальное заполняет хранилище
+func auditEntry(actorID int64, action domain.AuditAction, before *domain.Event) domain.AuditEntry {
+	return domain.AuditEntry{ActorID: actorID, Action: action, At: time.Now().UTC(), Before: before}
+}
@@ -1 +1,439 @@
 package service
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Repository - хранилище календаря
+type Repository interface {
+	EventRepository
+	UserRepository
+	APIKeyRepository
+	SharingRepository
+	AuditRepository
+}
+
+// AuditRepository - журнал изменений событий
+type AuditRepository interface {
+	// ListAudit - записи журнала события по возрастанию идентификатора
+	ListAudit(ctx context.Context, eventID int64) ([]domain.AuditEntry, error)
+}
+
+// APIKeyRepository - хранилище API ключей
+type APIKeyRepository interface {
+	// SaveAPIKey - сохраняет новый ключ
+	SaveAPIKey(ctx context.Context, k domain.APIKey) error
+	// GetAPIKey - возвращает ключ по открытой части или domain.ErrAPIKeyNotFound
+	GetAPIKey(ctx context.Context, id string) (domain.APIKey, error)
+	// DeleteAPIKey - удаляет ключ или возвращает domain.ErrAPIKeyNotFound
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+// UserRepository - хранилище настроек пользователей
+type UserRepository interface {
+	// GetUser - возвращает настройки пользователя или domain.ErrUserNotFound
+	GetUser(ctx context.Context, id int64) (domain.User, error)
+	// SaveUser - создает или заменяет настройки пользователя
+	SaveUser(ctx context.Context, u domain.User) error
+}
+
+// EventRepository - хранилище событий. Изменение события сохраняется атомарно вместе
+// с записью журнала a: хранилище заполняет в ней EventID и записанное состояние события
+// (After, для Delete - Before), остальное задает вызывающий.
+type EventRepository interface {
+	// Create - сохраняет новое событие, присваивая ему идентификатор
+	Create(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.Event, error)
+	// Get - возвращает событие по идентификатору или domain.ErrEventNotFound
+	Get(ctx context.Context, id int64) (domain.Event, error)
+	// Update - заменяет существующее событие, если его версия равна e.Version, и увеличивает версию;
+	// иначе - domain.ErrVersionMismatch, а для отсутствующего события - domain.ErrEventNotFound
+	Update(ctx context.Context, e domain.Event, a domain.AuditEntry) error
+	// Delete - переносит событие в корзину, если его версия равна version (0 - любая);
+	// иначе - domain.ErrVersionMismatch, а для отсутствующего события - domain.ErrEventNotFound.
+	// Удаленное событие не возвращается остальными методами, кроме Deleted.
+	// Выделенные вхождения серии удаляются вместе с ней, а Restore серии возвращает их.
+	Delete(ctx context.Context, id, version int64, a domain.AuditEntry) error
+	// Deleted - событие из корзины и время его удаления или domain.ErrEventNotFound
+	Deleted(ctx context.Context, id int64) (domain.Event, time.Time, error)
+	// Restore - возвращает событие из корзины или возвращает domain.ErrEventNotFound
+	Restore(ctx context.Context, id int64, a domain.AuditEntry) (domain.Event, error)
+	// Discard - удаляет событие безвозвратно, минуя корзину, вместе с его журналом изменений;
+	// откатывает создание события, когда операция не завершилась
+	Discard(ctx context.Context, id int64) error
+	// PurgeDeleted - окончательно удаляет события, удаленные раньше before; возвращает их число
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	// ListByUser - события пользователя, начинающиеся в полуинтервале [from, to), по возрастанию начала
+	ListByUser(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error)
+	// ListByCalendar - события календаря, начинающиеся в полуинтервале [from, to), по возрастанию начала
+	ListByCalendar(ctx context.Context, calendarID int64, from, to time.Time) ([]domain.Event, error)
+	// ListByUID - события пользователя с заданным UID (серия и ее выделенные вхождения)
+	ListByUID(ctx context.Context, userID int64, uid string) ([]domain.Event, error)
+	// ListRecurring - повторяющиеся серии пользователя, начинающиеся раньше to
+	ListRecurring(ctx context.Context, userID int64, to time.Time) ([]domain.Event, error)
+	// ListRecurringByCalendar - повторяющиеся серии календаря, начинающиеся раньше to
+	ListRecurringByCalendar(ctx context.Context, calendarID int64, to time.Time) ([]domain.Event, error)
+	// ListBySeries - выделенные вхождения серии
+	ListBySeries(ctx context.Context, seriesID int64) ([]domain.Event, error)
+	// ListOverlapping - разовые события и выделенные вхождения пользователя,
+	// пересекающиеся с интервалом (from, to): Start < to и End > from; по возрастанию начала
+	ListOverlapping(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error)
+	// Reminders - события, напоминания о которых приходятся на полуинтервал [from, to)
+	Reminders(ctx context.Context, from, to time.Time) ([]domain.Event, error)
+	// Search - события, подходящие под фильтр, по возрастанию (Start, ID), не больше f.Limit
+	Search(ctx context.Context, f domain.EventFilter) ([]domain.Event, error)
+}
+
+// defaultRetention - сколько удаленное событие можно восстановить
+const defaultRetention = 30 * 24 * time.Hour
+
+// Calendar - сервис событий календаря
+type Calendar struct {
+	repo Repository
+	hub  *hub
+	// проверка пересечений и запись события владельца
+	owners *ownerLocks
+	// срок восстановления удаленных событий
+	retention time.Duration
+}
+
+// New - конструктор
+func New(repo Repository) *Calendar {
+	return &Calendar{repo: repo, hub: newHub(), owners: newOwnerLocks(), retention: defaultRetention}
+}
+
+// SetRetention - задает срок, в течение которого удаленное событие можно восстановить
+func (c *Calendar) SetRetention(d time.Duration) {
+	c.retention = d
+}
+
+// CreateEvent - создает событие. Если политика календаря отклоняет пересечения,
+// событие, пересекающееся с другими, не сохраняется: ошибка - *domain.ConflictError.
+func (c *Calendar) CreateEvent(ctx context.Context, e domain.Event) (domain.Event, error) {
+	return c.createEvent(ctx, e, true)
+}
+
+// createEvent - создает событие; checkConflicts - применять ли политику пересечений
+func (c *Calendar) createEvent(ctx context.Context, e domain.Event, checkConflicts bool) (domain.Event, error) {
+	if err := validate(&e); err != nil {
+		return domain.Event{}, err
+	}
+	if err := checkUser(ctx, e.UserID); err != nil {
+		return domain.Event{}, err
+	}
+	actorID := e.UserID
+
+	// событие без зоны получает зону пользователя
+	if e.TimeZone == "" {
+		user, err := c.User(ctx, e.UserID)
+		if err != nil {
+			return domain.Event{}, err
+		}
+		e.TimeZone = user.TimeZone
+	}
+
+	// событие общего календаря принадлежит владельцу календаря
+	if e.CalendarID != 0 {
+		cal, err := c.writableCalendar(ctx, e.UserID, e.CalendarID)
+		if err != nil {
+			return domain.Event{}, err
+		}
+		e.UserID = cal.OwnerID
+	}
+
+	unlock := c.owners.lock(e.UserID)
+	defer unlock()
+	if checkConflicts {
+		if err := c.checkConflicts(ctx, e); err != nil {
+			return domain.Event{}, err
+		}
+	}
+
+	e, err := c.repo.Create(ctx, e, auditEntry(actorID, domain.AuditCreated, nil))
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("create event: %w", err)
+	}
+
+	c.notify(ctx, domain.ChangeCreated, e)
+	return e, nil
+}
+
+// UpdateEvent - обновляет событие пользователя. Для серии с заданным e.RecurrenceID
+// изменяется только это вхождение, иначе - событие (вся серия) целиком.
+// Пересечения проверяются так же, как при создании. Ненулевая e.Version - версия,
+// которую ожидает клиент: если событие (для вхождения - серию) уже изменили, ошибка - domain.ErrVersionMismatch.
+func (c *Calendar) UpdateEvent(ctx context.Context, e domain.Event) (domain.Event, error) {
+	return c.updateEvent(ctx, e, true)
+}
+
+// updateEvent - обновляет событие; checkConflicts - применять ли политику пересечений
+func (c *Calendar) updateEvent(ctx context.Context, e domain.Event, checkConflicts bool) (domain.Event, error) {
+	if !e.RecurrenceID.IsZero() {
+		return c.updateOccurrence(ctx, e, checkConflicts)
+	}
+
+	if err := validate(&e); err != nil {
+		return domain.Event{}, err
+	}
+
+	actorID := e.UserID
+	old, err := c.userEvent(ctx, e.UserID, e.ID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if err := checkVersion(old, e.Version); err != nil {
+		return domain.Event{}, err
+	}
+	e.Version = old.Version
+	// владельца, календарь, связь выделенного вхождения с серией и исключения серии клиент не меняет
+	e.UserID, e.CalendarID = old.UserID, old.CalendarID
+	e.SeriesID, e.RecurrenceID = old.SeriesID, old.RecurrenceID
+	if e.TimeZone == "" {
+		e.TimeZone = old.TimeZone
+	}
+	if e.Recurring() && (e.ExDates == nil || slices.EqualFunc(e.ExDates, old.ExDates, time.Time.Equal)) {
+		// исключения переносятся вместе с началом серии, иначе они указывают на прежние вхождения
+		e.ExDates = shiftOccurrences(old.ExDates, old.Start, e.Start, e.Location())
+	}
+
+	unlock := c.owners.lock(e.UserID)
+	defer unlock()
+	if checkConflicts {
+		if err := c.checkConflicts(ctx, e); err != nil {
+			return domain.Event{}, err
+		}
+	}
+
+	if err := c.repo.Update(ctx, e, auditEntry(actorID, domain.AuditUpdated, &old)); err != nil {
+		return domain.Event{}, fmt.Errorf("update event: %w", err)
+	}
+	e.Version++
+	if old.Recurring() && !old.Start.Equal(e.Start) {
+		if err := c.moveDetached(ctx, actorID, old, e); err != nil {
+			return domain.Event{}, err
+		}
+	}
+
+	c.notify(ctx, domain.ChangeUpdated, e)
+	return e, nil
+}
+
+// DeleteEvent - удаляет событие пользователя; в течение срока хранения его можно восстановить.
+// Ненулевая version - версия, которую ожидает клиент (иначе domain.ErrVersionMismatch).
+func (c *Calendar) DeleteEvent(ctx context.Context, userID, eventID, version int64) error {
+	if err := checkUser(ctx, userID); err != nil {
+		return err
+	}
+
+	e, err := c.userEvent(ctx, userID, eventID)
+	if err != nil {
+		return err
+	}
+	// после удаления приглашений уже нет - получателей определяем заранее
+	audience := c.audience(ctx, e)
+	// версия проверяется при удалении: изменение, сделанное после чтения, не теряется
+	if err := c.repo.Delete(ctx, eventID, version, auditEntry(userID, domain.AuditDeleted, nil)); err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+
+	c.notifyUsers(audience, domain.ChangeDeleted, e)
+	return nil
+}
+
+// EventsForDay - события пользователя за день. Границы дня считаются в зоне пользователя.
+func (c *Calendar) EventsForDay(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
+	from, err := c.userDay(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	return c.eventsBetween(ctx, userID, from, from.AddDate(0, 0, 1))
+}
+
+// EventsForWeek - события пользователя за неделю (с понедельника), в которую входит дата
+func (c *Calendar) EventsForWeek(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
+	from, err := c.userDay(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	// неделя начинается с понедельника
+	offset := (int(from.Weekday()) + 6) % 7
+	from = from.AddDate(0, 0, -offset)
+	return c.eventsBetween(ctx, userID, from, from.AddDate(0, 0, 7))
+}
+
+// EventsForMonth - события пользователя за месяц, в который входит дата
+func (c *Calendar) EventsForMonth(ctx context.Context, userID int64, date time.Time) ([]domain.Event, error) {
+	from, err := c.userDay(ctx, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	from = from.AddDate(0, 0, 1-from.Day())
+	return c.eventsBetween(ctx, userID, from, from.AddDate(0, 1, 0))
+}
+
+// userDay - начало календарного дня date в зоне пользователя.
+// AddDate от него дает границы с учетом перехода на летнее время (день бывает 23 или 25 часов).
+func (c *Calendar) userDay(ctx context.Context, userID int64, date time.Time) (time.Time, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := c.UserLocation(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// checkUser - проверяет идентификатор пользователя. Если запрос аутентифицирован,
+// пользователь может работать только со своими данными.
+func checkUser(ctx context.Context, userID int64) error {
+	if userID <= 0 {
+		return domain.ErrInvalidUserID
+	}
+	if authID, ok := auth.UserID(ctx); ok && authID != userID {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// checkVersion - domain.ErrVersionMismatch, если клиент ожидает другую версию события (0 - любую)
+func checkVersion(e domain.Event, version int64) error {
+	if version != 0 && version != e.Version {
+		return domain.ErrVersionMismatch
+	}
+	return nil
+}
+
+// userEvent - возвращает событие, если пользователь может его изменять
+func (c *Calendar) userEvent(ctx context.Context, userID, eventID int64) (domain.Event, error) {
+	return c.accessibleEvent(ctx, userID, eventID, domain.PermissionWrite)
+}
+
+// accessibleEvent - возвращает событие, если у пользователя есть право need.
+// Недоступное событие неотличимо от несуществующего, доступное только для чтения - ErrForbidden при записи.
+func (c *Calendar) accessibleEvent(ctx context.Context, userID, eventID int64, need domain.Permission) (domain.Event, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.Event{}, err
+	}
+
+	e, err := c.repo.Get(ctx, eventID)
+	if errors.Is(err, domain.ErrEventNotFound) {
+		return domain.Event{}, err
+	}
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("get event: %w", err)
+	}
+
+	perm, err := c.eventPermission(ctx, userID, e)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if perm == "" {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	if !perm.Allows(need) {
+		return domain.Event{}, domain.ErrForbidden
+	}
+	return e, nil
+}
+
+// eventsBetween - события, видимые пользователю и начинающиеся в полуинтервале [from, to):
+// собственные, из открытых ему календарей и те, на которые он приглашен и не отказался
+func (c *Calendar) eventsBetween(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return c.visibleEvents(ctx, userID, from, to)
+}
+
+// visibleEvents - то же, что eventsBetween, но без проверки, что запрос сделал сам пользователь
+func (c *Calendar) visibleEvents(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	m := newMerger(from, to)
+
+	listed, err := c.repo.ListByUser(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	series, err := c.repo.ListRecurring(ctx, userID, to)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring events: %w", err)
+	}
+	if err := m.add(listed, series); err != nil {
+		return nil, err
+	}
+
+	if err := c.sharedEvents(ctx, m, userID, from, to); err != nil {
+		return nil, err
+	}
+	if err := c.invitedEvents(ctx, m, userID); err != nil {
+		return nil, err
+	}
+
+	return m.events(), nil
+}
+
+// validate - проверяет и нормализует событие
+func validate(e *domain.Event) error {
+	if e.UserID <= 0 {
+		return domain.ErrInvalidUserID
+	}
+
+	e.Title = strings.TrimSpace(e.Title)
+	if e.Title == "" {
+		return domain.ErrEmptyTitle
+	}
+
+	if e.Start.IsZero() {
+		return domain.ErrInvalidDate
+	}
+	// событие без окончания считаем моментальным
+	if e.End.IsZero() {
+		e.End = e.Start
+	}
+	if e.End.Before(e.Start) {
+		return domain.ErrInvalidDate
+	}
+
+	if e.RemindBefore < 0 {
+		return domain.ErrInvalidReminder
+	}
+
+	if _, err := domain.LoadLocation(e.TimeZone); err != nil {
+		return err
+	}
+	tags, err := normalizeTags(e.Tags)
+	if err != nil {
+		return err
+	}
+	e.Tags = tags
+	// время храним в UTC, зона остается в TimeZone
+	e.Start, e.End = e.Start.UTC(), e.End.UTC()
+	if !e.RecurrenceID.IsZero() {
+		e.RecurrenceID = e.RecurrenceID.UTC()
+	}
+	e.ExDates = slices.Clone(e.ExDates)
+	for i := range e.ExDates {
+		e.ExDates[i] = e.ExDates[i].UTC()
+	}
+
+	if e.Recurring() {
+		rule, err := recurrence.Parse(e.RRule)
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidRecurrence, err)
+		}
+		if e.SeriesID != 0 {
+			return fmt.Errorf("%w: occurrence cannot be a series", domain.ErrInvalidRecurrence)
+		}
+		e.RRule = rule.String()
+	}
+
+	return nil
+}
@@ -0,0 +1,1115 @@
+package service_test
+
+import (
+	"Calendar/internal/auth"
+	"Calendar/internal/domain"
+	"Calendar/internal/service"
+	"Calendar/internal/storage/memory"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCreateEventValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		event   domain.Event
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			event: domain.Event{UserID: 1, Title: "standup", Start: date("2025-01-10")},
+		},
+		{
+			name:    "empty title",
+			event:   domain.Event{UserID: 1, Title: "  ", Start: date("2025-01-10")},
+			wantErr: domain.ErrEmptyTitle,
+		},
+		{
+			name:    "zero date",
+			event:   domain.Event{UserID: 1, Title: "standup"},
+			wantErr: domain.ErrInvalidDate,
+		},
+		{
+			name: "end before start",
+			event: domain.Event{
+				UserID: 1,
+				Title:  "standup",
+				Start:  date("2025-01-10"),
+				End:    date("2025-01-09"),
+			},
+			wantErr: domain.ErrInvalidDate,
+		},
+		{
+			name:    "invalid user",
+			event:   domain.Event{Title: "standup", Start: date("2025-01-10")},
+			wantErr: domain.ErrInvalidUserID,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := service.New(memory.New())
+			e, err := svc.CreateEvent(context.Background(), tt.event)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotZero(t, e.ID)
+			require.Equal(t, e.Start, e.End)
+		})
+	}
+}
+
+func TestUpdateDeleteEvent(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(memory.New())
+
+	e, err := svc.CreateEvent(ctx, domain.Event{UserID: 1, Title: "retro", Start: date("2025-01-10")})
+	require.NoError(t, err)
+
+	e.Title = "retro v2"
+	updated, err := svc.UpdateEvent(ctx, e)
+	require.NoError(t, err)
+	require.Equal(t, "retro v2", updated.Title)
+
+	// чужое событие недоступно
+	e.UserID = 2
+	_, err = svc.UpdateEvent(ctx, e)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	require.ErrorIs(t, svc.DeleteEvent(ctx, 2, e.ID, 0), domain.ErrEventNotFound)
+
+	require.NoError(t, svc.DeleteEvent(ctx, 1, e.ID, 0))
+	require.ErrorIs(t, svc.DeleteEvent(ctx, 1, e.ID, 0), domain.ErrEventNotFound)
+}
+
+func TestEventsForPeriod(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(memory.New())
+
+	// 2025-01-15 - среда
+	for _, d := range []string{"2025-01-13", "2025-01-15", "2025-01-19", "2025-01-20", "2025-01-31", "2025-02-01"} {
+		_, err := svc.CreateEvent(ctx, domain.Event{UserID: 1, Title: d, Start: date(d)})
+		require.NoError(t, err)
+	}
+	_, err := svc.CreateEvent(ctx, domain.Event{UserID: 2, Title: "other", Start: date("2025-01-15")})
+	require.NoError(t, err)
+
+	titles := func(events []domain.Event) []string {
+		out := make([]string, 0, len(events))
+		for _, e := range events {
+			out = append(out, e.Title)
+		}
+		return out
+	}
+
+	day, err := svc.EventsForDay(ctx, 1, date("2025-01-15"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"2025-01-15"}, titles(day))
+
+	week, err := svc.EventsForWeek(ctx, 1, date("2025-01-15"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"2025-01-13", "2025-01-15", "2025-01-19"}, titles(week))
+
+	month, err := svc.EventsForMonth(ctx, 1, date("2025-01-15"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"2025-01-13", "2025-01-15", "2025-01-19", "2025-01-20", "2025-01-31"}, titles(month))
+
+	_, err = svc.EventsForDay(ctx, 0, date("2025-01-15"))
+	require.ErrorIs(t, err, domain.ErrInvalidUserID)
+}
+
+func TestRecurringEvents(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(memory.New())
+
+	start := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC) // понедельник
+	series, err := svc.CreateEvent(ctx, domain.Event{
+		UserID: 1,
+		Title:  "standup",
+		Start:  start,
+		End:    start.Add(15 * time.Minute),
+		RRule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+	})
+	require.NoError(t, err)
+
+	week, err := svc.EventsForWeek(ctx, 1, start)
+	require.NoError(t, err)
+	require.Len(t, week, 3)
+	require.Equal(t, start.AddDate(0, 0, 2), week[1].Start)
+	require.Equal(t, 15*time.Minute, week[1].End.Sub(week[1].Start))
+	require.Equal(t, week[1].Start, week[1].RecurrenceID)
+
+	// изменение одного вхождения
+	wednesday := start.AddDate(0, 0, 2)
+	moved, err := svc.UpdateEvent(ctx, domain.Event{
+		ID:           series.ID,
+		UserID:       1,
+		Title:        "standup (moved)",
+		Start:        wednesday.Add(2 * time.Hour),
+		RecurrenceID: wednesday,
+	})
+	require.NoError(t, err)
+	require.Equal(t, series.ID, moved.SeriesID)
+
+	// удаление одного вхождения
+	require.NoError(t, svc.DeleteOccurrence(ctx, 1, series.ID, start.AddDate(0, 0, 4), 0))
+	require.ErrorIs(t, svc.DeleteOccurrence(ctx, 1, series.ID, start.Add(time.Hour), 0), domain.ErrInvalidRecurrence)
+
+	week, err = svc.EventsForWeek(ctx, 1, start)
+	require.NoError(t, err)
+	require.Len(t, week, 2)
+	require.Equal(t, "standup", week[0].Title)
+	require.Equal(t, "standup (moved)", week[1].Title)
+
+	// изменение всей серии сохраняет исключения; серия уже менялась, и старая версия отклоняется
+	series.Title = "daily sync"
+	_, err = svc.UpdateEvent(ctx, series)
+	require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	series.Version = 3
+	series, err = svc.UpdateEvent(ctx, series)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), series.Version)
+
+	month, err := svc.EventsForMonth(ctx, 1, start)
+	require.NoError(t, err)
+	// январь 2025: с 6-го 12 вхождений пн/ср/пт, два из них исключены, одно выделено
+	require.Len(t, month, 11)
+	require.Equal(t, "daily sync", month[0].Title)
+
+	_, err = svc.CreateEvent(ctx, domain.Event{UserID: 1, Title: "bad", Start: start, RRule: "FREQ=SECONDLY"})
+	require.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+}
+
+func TestSeriesWithOccurrences(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	svc := service.New(repo)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// 09:00 по Берлину каждый день; 30 марта 2025 переход на летнее время
+	start := time.Date(2025, 3, 28, 9, 0, 0, 0, berlin)
+	series, err := svc.CreateEvent(ctx, domain.Event{
+		UserID:   1,
+		Title:    "standup",
+		Start:    start,
+		End:      start.Add(15 * time.Minute),
+		RRule:    "FREQ=DAILY",
+		TimeZone: "Europe/Berlin",
+	})
+	require.NoError(t, err)
+
+	// вхождение без окончания длится столько же, сколько вхождения серии
+	saturday := start.AddDate(0, 0, 1)
+	moved, err := svc.UpdateEvent(ctx, domain.Event{ID: series.ID, UserID: 1, Title: "standup (moved)", Start: saturday.Add(time.Hour), RecurrenceID: saturday})
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Minute, moved.End.Sub(moved.Start))
+	require.NoError(t, svc.DeleteOccurrence(ctx, 1, series.ID, start.AddDate(0, 0, 3), 0))
+
+	// серия переносится на 10:00: исключения и выделенные вхождения переносятся с ней по местному времени
+	series, err = repo.Get(ctx, series.ID)
+	require.NoError(t, err)
+	series.Start, series.End = start.Add(time.Hour), start.Add(time.Hour+15*time.Minute)
+	series, err = svc.UpdateEvent(ctx, series)
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{time.Date(2025, 3, 29, 10, 0, 0, 0, berlin).UTC(), time.Date(2025, 3, 31, 10, 0, 0, 0, berlin).UTC()}, series.ExDates)
+
+	events, err := svc.EventsForWeek(ctx, 1, start)
+	require.NoError(t, err)
+	// пт, сб (выделенное), вс; понедельник исключен
+	require.Len(t, events, 3)
+	require.Equal(t, "standup (moved)", events[1].Title)
+	require.Equal(t, time.Date(2025, 3, 29, 10, 0, 0, 0, berlin).UTC(), events[1].RecurrenceID)
+	require.Equal(t, 10, events[2].Start.In(berlin).Hour())
+
+	// выделенное вхождение удаляется и восстанавливается вместе с серией
+	require.NoError(t, svc.DeleteEvent(ctx, 1, series.ID, 0))
+	_, err = repo.Get(ctx, moved.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	_, err = svc.RestoreEvent(ctx, 1, series.ID)
+	require.NoError(t, err)
+	moved, err = repo.Get(ctx, moved.ID)
+	require.NoError(t, err)
+	require.Equal(t, series.ID, moved.SeriesID)
+}
+
+func TestTimeZones(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(memory.New())
+
+	u, err := svc.User(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "UTC", u.TimeZone)
+
+	_, err = svc.SetUserTimeZone(ctx, 1, "Mars/Olympus")
+	require.ErrorIs(t, err, domain.ErrInvalidTimeZone)
+	_, err = svc.SetUserTimeZone(ctx, 1, "Asia/Novosibirsk")
+	require.NoError(t, err)
+
+	novosibirsk, err := time.LoadLocation("Asia/Novosibirsk")
+	require.NoError(t, err)
+
+	// 01:00 по Новосибирску - еще 14 января по UTC
+	e, err := svc.CreateEvent(ctx, domain.Event{
+		UserID: 1,
+		Title:  "early call",
+		Start:  time.Date(2025, 1, 15, 1, 0, 0, 0, novosibirsk),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Novosibirsk", e.TimeZone)
+	require.Equal(t, time.UTC, e.Start.Location())
+	require.Equal(t, time.Date(2025, 1, 14, 18, 0, 0, 0, time.UTC), e.Start)
+
+	// границы дня считаются в зоне пользователя
+	day, err := svc.EventsForDay(ctx, 1, date("2025-01-15"))
+	require.NoError(t, err)
+	require.Len(t, day, 1)
+	day, err = svc.EventsForDay(ctx, 1, date("2025-01-14"))
+	require.NoError(t, err)
+	require.Empty(t, day)
+
+	_, err = svc.CreateEvent(ctx, domain.Event{UserID: 1, Title: "x", Start: e.Start, TimeZone: "Local"})
+	require.ErrorIs(t, err, domain.ErrInvalidTimeZone)
+}
+
+func TestRecurringEventsAcrossDST(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(memory.New())
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	_, err = svc.SetUserTimeZone(ctx, 1, "Europe/Berlin")
+	require.NoError(t, err)
+
+	// 09:00 по Берлину каждый день; 30 марта 2025 переход на летнее время
+	_, err = svc.CreateEvent(ctx, domain.Event{
+		UserID:   1,
+		Title:    "standup",
+		Start:    time.Date(2025, 3, 28, 9, 0, 0, 0, berlin),
+		RRule:    "FREQ=DAILY",
+		TimeZone: "Europe/Berlin",
+	})
+	require.NoError(t, err)
+
+	week, err := svc.EventsForWeek(ctx, 1, date("2025-03-27"))
+	require.NoError(t, err)
+	require.Len(t, week, 3)
+	for _, occ := range week {
+		require.Equal(t, 9, occ.Start.In(berlin).Hour())
+	}
+	require.Equal(t, 8, week[0].Start.Hour())
+	require.Equal(t, 7, week[2].Start.Hour())
+
+	// день перехода короче, но границы считаются по местному времени
+	day, err := svc.EventsForDay(ctx, 1, date("2025-03-31"))
+	require.NoError(t, err)
+	require.Len(t, day, 1)
+	require.Equal(t, time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC), day[0].Start)
+}
+
+func TestUserIsolation(t *testing.T) {
+	svc := service.New(memory.New())
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+
+	e, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "private", Start: date("2025-01-15")})
+	require.NoError(t, err)
+
+	_, err = svc.CreateEvent(bob, domain.Event{UserID: 1, Title: "spoofed", Start: date("2025-01-15")})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.EventsForDay(bob, 1, date("2025-01-15"))
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	require.ErrorIs(t, svc.DeleteEvent(bob, 1, e.ID, 0), domain.ErrForbidden)
+
+	// чужое событие под своим идентификатором не видно
+	e.UserID = 2
+	_, err = svc.UpdateEvent(bob, e)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	require.ErrorIs(t, svc.DeleteEvent(bob, 2, e.ID, 0), domain.ErrEventNotFound)
+
+	key, token, err := svc.CreateAPIKey(alice, 1, "cli")
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.ErrorIs(t, svc.RevokeAPIKey(bob, 2, key.ID), domain.ErrAPIKeyNotFound)
+	require.NoError(t, svc.RevokeAPIKey(alice, 1, key.ID))
+}
+
+func TestSharedCalendarsAndInvitations(t *testing.T) {
+	svc := service.New(memory.New())
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+	carol := auth.WithUserID(context.Background(), 3)
+	day := date("2025-01-15")
+
+	team, err := svc.CreateCalendar(alice, 1, "team")
+	require.NoError(t, err)
+	_, err = svc.CreateCalendar(alice, 1, " ")
+	require.ErrorIs(t, err, domain.ErrEmptyName)
+
+	// календарь еще не открыт
+	_, err = svc.CreateEvent(bob, domain.Event{UserID: 2, CalendarID: team.ID, Title: "x", Start: day})
+	require.ErrorIs(t, err, domain.ErrCalendarNotFound)
+
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 2, domain.PermissionRead)
+	require.NoError(t, err)
+	_, err = svc.ShareCalendar(bob, 2, team.ID, 3, domain.PermissionWrite)
+	require.ErrorIs(t, err, domain.ErrCalendarNotFound)
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 3, "admin")
+	require.ErrorIs(t, err, domain.ErrInvalidPermission)
+
+	planning, err := svc.CreateEvent(alice, domain.Event{UserID: 1, CalendarID: team.ID, Title: "planning", Start: day.Add(10 * time.Hour)})
+	require.NoError(t, err)
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "dentist", Start: day.Add(15 * time.Hour)})
+	require.NoError(t, err)
+
+	// чтение: видно событие календаря, но не личное событие владельца
+	events, err := svc.EventsForDay(bob, 2, day)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "planning", events[0].Title)
+
+	planning.Title = "renamed"
+	planning.UserID = 2
+	_, err = svc.UpdateEvent(bob, planning)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	// запись: событие создается от имени владельца календаря
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 2, domain.PermissionWrite)
+	require.NoError(t, err)
+	updated, err := svc.UpdateEvent(bob, planning)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), updated.UserID)
+	require.Equal(t, team.ID, updated.CalendarID)
+	retro, err := svc.CreateEvent(bob, domain.Event{UserID: 2, CalendarID: team.ID, Title: "retro", Start: day.Add(16 * time.Hour)})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), retro.UserID)
+
+	calendars, err := svc.Calendars(bob, 2)
+	require.NoError(t, err)
+	require.Len(t, calendars, 1)
+	require.Equal(t, domain.PermissionWrite, calendars[0].Permission)
+
+	// приглашение на серию
+	standup, err := svc.CreateEvent(bob, domain.Event{UserID: 2, Title: "standup", Start: day.Add(9 * time.Hour), RRule: "FREQ=DAILY;COUNT=3"})
+	require.NoError(t, err)
+	_, err = svc.InviteToEvent(carol, 3, standup.ID, 1)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	_, err = svc.InviteToEvent(bob, 2, standup.ID, 2)
+	require.ErrorIs(t, err, domain.ErrInvalidUserID)
+	inv, err := svc.InviteToEvent(bob, 2, standup.ID, 3)
+	require.NoError(t, err)
+	require.Equal(t, domain.RSVPNeedsAction, inv.Status)
+
+	week, err := svc.EventsForWeek(carol, 3, day)
+	require.NoError(t, err)
+	require.Len(t, week, 3)
+
+	_, err = svc.RespondToInvitation(carol, 3, standup.ID, domain.RSVPNeedsAction)
+	require.ErrorIs(t, err, domain.ErrInvalidRSVP)
+	_, err = svc.RespondToInvitation(alice, 1, standup.ID, domain.RSVPAccepted)
+	require.ErrorIs(t, err, domain.ErrInvitationNotFound)
+	inv, err = svc.RespondToInvitation(carol, 3, standup.ID, domain.RSVPTentative)
+	require.NoError(t, err)
+	require.Equal(t, domain.RSVPTentative, inv.Status)
+
+	attendees, err := svc.Attendees(carol, 3, standup.ID)
+	require.NoError(t, err)
+	require.Len(t, attendees, 1)
+
+	// приглашенный может только читать
+	require.ErrorIs(t, svc.DeleteEvent(carol, 3, standup.ID, 0), domain.ErrForbidden)
+
+	_, err = svc.RespondToInvitation(carol, 3, standup.ID, domain.RSVPDeclined)
+	require.NoError(t, err)
+	week, err = svc.EventsForWeek(carol, 3, day)
+	require.NoError(t, err)
+	require.Empty(t, week)
+
+	// приглашенный, которому открыт и календарь, видит событие один раз
+	_, err = svc.InviteToEvent(alice, 1, retro.ID, 2)
+	require.NoError(t, err)
+	events, err = svc.EventsForDay(bob, 2, day)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	require.NoError(t, svc.UnshareCalendar(alice, 1, team.ID, 2))
+	events, err = svc.EventsForDay(bob, 2, day)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
+
+func TestFindSlots(t *testing.T) {
+	svc := service.New(memory.New())
+	ctx := context.Background()
+	alice := auth.WithUserID(ctx, 1)
+	bob := auth.WithUserID(ctx, 2)
+
+	// рабочий день Алисы по Москве - с 6:00 до 15:00 UTC, Боба - с 9:00 до 18:00 UTC
+	_, err := svc.SetUserTimeZone(alice, 1, "Europe/Moscow")
+	require.NoError(t, err)
+
+	monday := date("2025-01-13")
+	_, err = svc.CreateEvent(alice, domain.Event{
+		UserID: 1, Title: "private sync",
+		Start: monday.Add(10 * time.Hour), End: monday.Add(11 * time.Hour),
+	})
+	require.NoError(t, err)
+	// событие началось накануне и заходит в период поиска
+	_, err = svc.CreateEvent(bob, domain.Event{
+		UserID: 2, Title: "night shift",
+		Start: monday.Add(-4 * time.Hour), End: monday.Add(9*time.Hour + 30*time.Minute),
+	})
+	require.NoError(t, err)
+
+	// занятость коллеги видна без деталей событий
+	busy, err := svc.FreeBusy(alice, 1, []int64{2, 1, 2}, monday, monday.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []domain.BusyTimes{
+		{UserID: 1, Busy: []domain.Interval{{Start: monday.Add(10 * time.Hour), End: monday.Add(11 * time.Hour)}}},
+		{UserID: 2, Busy: []domain.Interval{{Start: monday, End: monday.Add(9*time.Hour + 30*time.Minute)}}},
+	}, busy)
+
+	q := domain.SlotQuery{
+		UserIDs:      []int64{1, 2},
+		From:         monday,
+		To:           monday.AddDate(0, 0, 1),
+		Duration:     time.Hour,
+		WorkingHours: domain.DefaultWorkingHours,
+	}
+	slots, err := svc.FindSlots(alice, 1, q)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Interval{{Start: monday.Add(11 * time.Hour), End: monday.Add(15 * time.Hour)}}, slots)
+
+	q.Duration = 30 * time.Minute
+	slots, err = svc.FindSlots(alice, 1, q)
+	require.NoError(t, err)
+	require.Len(t, slots, 2)
+	require.Equal(t, monday.Add(9*time.Hour+30*time.Minute), slots[0].Start)
+
+	// выходные по умолчанию не рабочие
+	q.From, q.To = date("2025-01-18"), date("2025-01-20")
+	slots, err = svc.FindSlots(alice, 1, q)
+	require.NoError(t, err)
+	require.Empty(t, slots)
+
+	q.Duration = 0
+	_, err = svc.FindSlots(alice, 1, q)
+	require.ErrorIs(t, err, domain.ErrInvalidSlotQuery)
+	_, err = svc.FreeBusy(alice, 1, []int64{2}, monday, monday)
+	require.ErrorIs(t, err, domain.ErrInvalidDate)
+	_, err = svc.FreeBusy(bob, 1, []int64{2}, monday, monday.AddDate(0, 0, 1))
+	require.ErrorIs(t, err, domain.ErrForbidden)
+}
+
+func TestFreeBusySources(t *testing.T) {
+	svc := service.New(memory.New())
+	ctx := context.Background()
+	alice := auth.WithUserID(ctx, 1)
+	bob := auth.WithUserID(ctx, 2)
+	monday := date("2025-01-13")
+	at := func(h int) time.Time { return monday.Add(time.Duration(h) * time.Hour) }
+
+	// общий календарь Боба виден Алисе, но ее время не занимает
+	cal, err := svc.CreateCalendar(bob, 2, "team")
+	require.NoError(t, err)
+	_, err = svc.ShareCalendar(bob, 2, cal.ID, 1, domain.PermissionRead)
+	require.NoError(t, err)
+	_, err = svc.CreateEvent(bob, domain.Event{UserID: 2, CalendarID: cal.ID, Title: "team sync", Start: at(9), End: at(10)})
+	require.NoError(t, err)
+
+	// время занимают только принятые приглашения
+	accepted, err := svc.CreateEvent(bob, domain.Event{UserID: 2, Title: "review", Start: at(11), End: at(12)})
+	require.NoError(t, err)
+	tentative, err := svc.CreateEvent(bob, domain.Event{UserID: 2, Title: "demo", Start: at(13), End: at(14)})
+	require.NoError(t, err)
+	unanswered, err := svc.CreateEvent(bob, domain.Event{UserID: 2, Title: "retro", Start: at(15), End: at(16)})
+	require.NoError(t, err)
+	for _, e := range []domain.Event{accepted, tentative, unanswered} {
+		_, err = svc.InviteToEvent(bob, 2, e.ID, 1)
+		require.NoError(t, err)
+	}
+	_, err = svc.RespondToInvitation(alice, 1, accepted.ID, domain.RSVPAccepted)
+	require.NoError(t, err)
+	_, err = svc.RespondToInvitation(alice, 1, tentative.ID, domain.RSVPTentative)
+	require.NoError(t, err)
+
+	// многодневное событие, начавшееся задолго до периода, в него заходит
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "vacation", Start: monday.AddDate(0, 0, -10), End: at(8)})
+	require.NoError(t, err)
+
+	busy, err := svc.FreeBusy(alice, 1, []int64{1}, monday, monday.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []domain.Interval{{Start: monday, End: at(8)}, {Start: at(11), End: at(12)}}, busy[0].Busy)
+}
+
+func TestSubscribe(t *testing.T) {
+	svc := service.New(memory.New())
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+	day := date("2025-01-15")
+
+	_, _, err := svc.Subscribe(bob, 1)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	aliceCh, cancelAlice, err := svc.Subscribe(alice, 1)
+	require.NoError(t, err)
+	defer cancelAlice()
+	bobCh, cancelBob, err := svc.Subscribe(bob, 2)
+	require.NoError(t, err)
+
+	// личное событие видит только владелец
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "dentist", Start: day})
+	require.NoError(t, err)
+	change := <-aliceCh
+	require.Equal(t, domain.ChangeCreated, change.Type)
+	require.Equal(t, "dentist", change.Event.Title)
+	require.Empty(t, bobCh)
+
+	// событие открытого календаря - и участник
+	team, err := svc.CreateCalendar(alice, 1, "team")
+	require.NoError(t, err)
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 2, domain.PermissionRead)
+	require.NoError(t, err)
+	planning, err := svc.CreateEvent(alice, domain.Event{UserID: 1, CalendarID: team.ID, Title: "planning", Start: day})
+	require.NoError(t, err)
+	require.Equal(t, domain.ChangeCreated, (<-aliceCh).Type)
+	require.Equal(t, planning.ID, (<-bobCh).Event.ID)
+
+	require.NoError(t, svc.DeleteEvent(alice, 1, planning.ID, 0))
+	require.Equal(t, domain.ChangeDeleted, (<-aliceCh).Type)
+	change = <-bobCh
+	require.Equal(t, domain.ChangeDeleted, change.Type)
+	require.Equal(t, "planning", change.Event.Title)
+
+	// приглашенный видит изменения события
+	lunch, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "lunch", Start: day})
+	require.NoError(t, err)
+	require.Equal(t, domain.ChangeCreated, (<-aliceCh).Type)
+	_, err = svc.InviteToEvent(alice, 1, lunch.ID, 2)
+	require.NoError(t, err)
+	lunch.Title = "team lunch"
+	_, err = svc.UpdateEvent(alice, lunch)
+	require.NoError(t, err)
+	require.Equal(t, domain.ChangeUpdated, (<-aliceCh).Type)
+	require.Equal(t, "team lunch", (<-bobCh).Event.Title)
+
+	// после отписки канал закрыт
+	cancelBob()
+	_, ok := <-bobCh
+	require.False(t, ok)
+
+	svc.CloseSubscriptions()
+	_, ok = <-aliceCh
+	require.False(t, ok)
+	_, _, err = svc.Subscribe(alice, 1)
+	require.ErrorIs(t, err, domain.ErrStreamClosed)
+}
+
+// countingRepo - хранилище, считающее запросы прав доступа
+type countingRepo struct {
+	*memory.Storage
+	lookups atomic.Int64
+}
+
+func (r *countingRepo) GetShare(ctx context.Context, calendarID, userID int64) (domain.Share, error) {
+	r.lookups.Add(1)
+	return r.Storage.GetShare(ctx, calendarID, userID)
+}
+
+func (r *countingRepo) ListCalendarShares(ctx context.Context, calendarID int64) ([]domain.Share, error) {
+	r.lookups.Add(1)
+	return r.Storage.ListCalendarShares(ctx, calendarID)
+}
+
+func (r *countingRepo) GetInvitation(ctx context.Context, eventID, userID int64) (domain.Invitation, error) {
+	r.lookups.Add(1)
+	return r.Storage.GetInvitation(ctx, eventID, userID)
+}
+
+func (r *countingRepo) ListInvitations(ctx context.Context, eventID int64) ([]domain.Invitation, error) {
+	r.lookups.Add(1)
+	return r.Storage.ListInvitations(ctx, eventID)
+}
+
+func TestSubscribersAudience(t *testing.T) {
+	repo := &countingRepo{Storage: memory.New()}
+	svc := service.New(repo)
+	alice := auth.WithUserID(context.Background(), 1)
+	day := date("2025-01-15")
+
+	team, err := svc.CreateCalendar(alice, 1, "team")
+	require.NoError(t, err)
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 2, domain.PermissionRead)
+	require.NoError(t, err)
+
+	// число запросов к хранилищу при рассылке не зависит от числа подписчиков
+	lookups := func() int64 {
+		before := repo.lookups.Load()
+		_, err := svc.CreateEvent(alice, domain.Event{UserID: 1, CalendarID: team.ID, Title: "planning", Start: day})
+		require.NoError(t, err)
+		return repo.lookups.Load() - before
+	}
+
+	channels := make(map[int64]<-chan domain.Change)
+	for id := int64(1); id <= 3; id++ {
+		ch, cancel, err := svc.Subscribe(auth.WithUserID(context.Background(), id), id)
+		require.NoError(t, err)
+		defer cancel()
+		channels[id] = ch
+	}
+	few := lookups()
+	for id := int64(4); id <= 50; id++ {
+		_, cancel, err := svc.Subscribe(auth.WithUserID(context.Background(), id), id)
+		require.NoError(t, err)
+		defer cancel()
+	}
+	require.Equal(t, few, lookups())
+
+	// уведомления получают только владелец и участник календаря
+	require.Len(t, channels[1], 2)
+	require.Len(t, channels[2], 2)
+	require.Empty(t, channels[3])
+}
+
+func TestSearchEvents(t *testing.T) {
+	svc := service.New(memory.New())
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+	day := date("2025-01-15")
+
+	create := func(ctx context.Context, e domain.Event) domain.Event {
+		t.Helper()
+		created, err := svc.CreateEvent(ctx, e)
+		require.NoError(t, err)
+		return created
+	}
+
+	standup := create(alice, domain.Event{UserID: 1, Title: "Standup", Start: day.Add(9 * time.Hour), Tags: []string{" Work ", "daily", "work"}})
+	// теги нормализуются
+	require.Equal(t, []string{"daily", "work"}, standup.Tags)
+	create(alice, domain.Event{UserID: 1, Title: "Review", Description: "quarterly STANDUP notes", Start: day.Add(14 * time.Hour), Tags: []string{"work"}})
+	create(alice, domain.Event{UserID: 1, Title: "Dentist", Start: day.AddDate(0, 0, 1).Add(9 * time.Hour), Tags: []string{"personal"}})
+	create(bob, domain.Event{UserID: 2, Title: "Bob standup", Start: day.Add(9 * time.Hour), Tags: []string{"work"}})
+
+	titles := func(page domain.SearchPage) []string {
+		var titles []string
+		for _, e := range page.Events {
+			titles = append(titles, e.Title)
+		}
+		return titles
+	}
+
+	// текст ищется в названии и описании без учета регистра, только среди видимых событий
+	page, err := svc.SearchEvents(alice, domain.SearchQuery{UserID: 1, Text: "standup"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Standup", "Review"}, titles(page))
+	require.Empty(t, page.NextCursor)
+
+	page, err = svc.SearchEvents(alice, domain.SearchQuery{UserID: 1, Tags: []string{"WORK", "daily"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Standup"}, titles(page))
+
+	page, err = svc.SearchEvents(alice, domain.SearchQuery{UserID: 1, From: day.Add(12 * time.Hour), To: day.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Review", "Dentist"}, titles(page))
+
+	// постраничная выдача по курсору
+	var all []string
+	query := domain.SearchQuery{UserID: 1, Limit: 2}
+	for {
+		page, err := svc.SearchEvents(alice, query)
+		require.NoError(t, err)
+		all = append(all, titles(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"Standup", "Review", "Dentist"}, all)
+
+	// серия находится по вхождениям в периоде поиска, исключенные вхождения пропускаются
+	sync := day.Add(10 * time.Hour)
+	create(alice, domain.Event{UserID: 1, Title: "Weekly sync", Start: sync.AddDate(0, 0, -14), End: sync.AddDate(0, 0, -14).Add(time.Hour),
+		RRule: "FREQ=WEEKLY", ExDates: []time.Time{sync.AddDate(0, 0, 7)}})
+	var starts []time.Time
+	query = domain.SearchQuery{UserID: 1, Text: "sync", From: day, To: day.AddDate(0, 0, 21), Limit: 1}
+	for {
+		page, err := svc.SearchEvents(alice, query)
+		require.NoError(t, err)
+		for _, e := range page.Events {
+			starts = append(starts, e.Start)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	require.Equal(t, []time.Time{sync, sync.AddDate(0, 0, 14)}, starts)
+
+	// события общего календаря видны тому, кому он открыт
+	team, err := svc.CreateCalendar(alice, 1, "team")
+	require.NoError(t, err)
+	create(alice, domain.Event{UserID: 1, CalendarID: team.ID, Title: "Planning", Start: day.Add(11 * time.Hour), Tags: []string{"work"}})
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 2, domain.PermissionRead)
+	require.NoError(t, err)
+	page, err = svc.SearchEvents(bob, domain.SearchQuery{UserID: 2, Tags: []string{"work"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Bob standup", "Planning"}, titles(page))
+
+	_, err = svc.SearchEvents(bob, domain.SearchQuery{UserID: 1})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.SearchEvents(alice, domain.SearchQuery{UserID: 1, Cursor: "garbage"})
+	require.ErrorIs(t, err, domain.ErrInvalidSearch)
+	_, err = svc.SearchEvents(alice, domain.SearchQuery{UserID: 1, Limit: 1000})
+	require.ErrorIs(t, err, domain.ErrInvalidSearch)
+	_, err = svc.SearchEvents(alice, domain.SearchQuery{UserID: 1, From: day, To: day})
+	require.ErrorIs(t, err, domain.ErrInvalidSearch)
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "x", Start: day, Tags: []string{"a,b"}})
+	require.ErrorIs(t, err, domain.ErrInvalidTags)
+}
+
+func TestEventConflicts(t *testing.T) {
+	svc := service.New(memory.New())
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+	// вхождения серий проверяются только в будущем
+	day := date("2030-01-07")
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+
+	ids := func(events []domain.Event) []int64 {
+		var ids []int64
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	review, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "review", Start: at(10, 0), End: at(11, 0)})
+	require.NoError(t, err)
+	// по умолчанию пересечение только предупреждение
+	sync, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "sync", Start: at(10, 30), End: at(11, 30)})
+	require.NoError(t, err)
+	conflicts, err := svc.EventConflicts(alice, 1, sync.ID)
+	require.NoError(t, err)
+	require.Equal(t, []int64{review.ID}, ids(conflicts))
+
+	// моментальные и смежные события не пересекаются
+	deadline, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "deadline", Start: at(10, 15)})
+	require.NoError(t, err)
+	conflicts, err = svc.EventConflicts(alice, 1, deadline.ID)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+	lunch, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "lunch", Start: at(11, 30), End: at(12, 30)})
+	require.NoError(t, err)
+	conflicts, err = svc.EventConflicts(alice, 1, lunch.ID)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+
+	// события других календарей не мешают
+	team, err := svc.CreateCalendar(alice, 1, "team")
+	require.NoError(t, err)
+	planning, err := svc.CreateEvent(alice, domain.Event{UserID: 1, CalendarID: team.ID, Title: "planning", Start: at(10, 0), End: at(11, 0)})
+	require.NoError(t, err)
+	conflicts, err = svc.EventConflicts(alice, 1, planning.ID)
+	require.NoError(t, err)
+	require.Empty(t, conflicts)
+	_, err = svc.EventConflicts(bob, 2, planning.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	_, err = svc.SetUserConflictPolicy(alice, 1, "ignore")
+	require.ErrorIs(t, err, domain.ErrInvalidConflictPolicy)
+	u, err := svc.SetUserConflictPolicy(alice, 1, domain.ConflictReject)
+	require.NoError(t, err)
+	require.Equal(t, domain.ConflictReject, u.ConflictPolicy)
+
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "call", Start: at(10, 45), End: at(11, 15)})
+	require.ErrorIs(t, err, domain.ErrConflict)
+	var conflictErr *domain.ConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, []int64{review.ID, sync.ID}, ids(conflictErr.Events))
+
+	// событие не конфликтует само с собой
+	sync.Title = "sync 2"
+	_, err = svc.UpdateEvent(alice, sync)
+	require.ErrorIs(t, err, domain.ErrConflict)
+	sync.Start, sync.End = at(13, 0), at(14, 0)
+	_, err = svc.UpdateEvent(alice, sync)
+	require.NoError(t, err)
+	review.End = at(11, 30)
+	_, err = svc.UpdateEvent(alice, review)
+	require.NoError(t, err)
+
+	// новая серия проверяется по вхождениям, существующая - разворачивается
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "1:1", Start: at(9, 0).AddDate(0, 0, -7), End: at(10, 30).AddDate(0, 0, -7), RRule: "FREQ=WEEKLY"})
+	require.ErrorIs(t, err, domain.ErrConflict)
+	weekly, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "1:1", Start: at(9, 0).AddDate(0, 0, -7), End: at(10, 0).AddDate(0, 0, -7), RRule: "FREQ=WEEKLY"})
+	require.NoError(t, err)
+	_, err = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "call", Start: at(9, 30).AddDate(0, 0, 7), End: at(9, 45).AddDate(0, 0, 7)})
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, []int64{weekly.ID}, ids(conflictErr.Events))
+	require.Equal(t, at(9, 0).AddDate(0, 0, 7), conflictErr.Events[0].RecurrenceID)
+
+	// перенос вхождения не конфликтует с самой серией
+	_, err = svc.UpdateEvent(alice, domain.Event{ID: weekly.ID, UserID: 1, Title: "1:1", RecurrenceID: at(9, 0).AddDate(0, 0, 7), Start: at(8, 30).AddDate(0, 0, 7), End: at(9, 15).AddDate(0, 0, 7)})
+	require.NoError(t, err)
+
+	// политику календаря меняет только владелец
+	_, err = svc.ShareCalendar(alice, 1, team.ID, 2, domain.PermissionWrite)
+	require.NoError(t, err)
+	_, err = svc.SetCalendarConflictPolicy(bob, 2, team.ID, domain.ConflictReject)
+	require.ErrorIs(t, err, domain.ErrCalendarNotFound)
+	cal, err := svc.SetCalendarConflictPolicy(alice, 1, team.ID, domain.ConflictReject)
+	require.NoError(t, err)
+	require.Equal(t, domain.ConflictReject, cal.ConflictPolicy)
+	_, err = svc.CreateEvent(bob, domain.Event{UserID: 2, CalendarID: team.ID, Title: "retro", Start: at(10, 30), End: at(11, 30)})
+	require.ErrorAs(t, err, &conflictErr)
+	require.Equal(t, []int64{planning.ID}, ids(conflictErr.Events))
+}
+
+// slowOverlapRepo - хранилище, медленно отдающее пересечения: параллельные проверки успевают перекрыться
+type slowOverlapRepo struct {
+	*memory.Storage
+}
+
+func (r slowOverlapRepo) ListOverlapping(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	events, err := r.Storage.ListOverlapping(ctx, userID, from, to)
+	time.Sleep(10 * time.Millisecond)
+	return events, err
+}
+
+func TestConcurrentConflicts(t *testing.T) {
+	svc := service.New(slowOverlapRepo{memory.New()})
+	alice := auth.WithUserID(context.Background(), 1)
+	start := date("2030-01-07").Add(10 * time.Hour)
+
+	_, err := svc.SetUserConflictPolicy(alice, 1, domain.ConflictReject)
+	require.NoError(t, err)
+
+	// из пересекающихся событий, создаваемых одновременно, сохраняется только одно
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Go(func() {
+			_, errs[i] = svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "review", Start: start, End: start.Add(time.Hour)})
+		})
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		require.ErrorIs(t, err, domain.ErrConflict)
+	}
+	require.Equal(t, 1, created)
+}
+
+func TestAuditAndRestore(t *testing.T) {
+	svc := service.New(memory.New())
+	alice := auth.WithUserID(context.Background(), 1)
+	bob := auth.WithUserID(context.Background(), 2)
+	start := date("2030-01-07").Add(10 * time.Hour)
+
+	actions := func(entries []domain.AuditEntry) []domain.AuditAction {
+		var actions []domain.AuditAction
+		for _, a := range entries {
+			actions = append(actions, a.Action)
+		}
+		return actions
+	}
+
+	e, err := svc.CreateEvent(alice, domain.Event{UserID: 1, Title: "review", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = svc.RestoreEvent(alice, 1, e.ID)
+	require.ErrorIs(t, err, domain.ErrNotDeleted)
+
+	e.Title = "code review"
+	_, err = svc.UpdateEvent(alice, e)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteEvent(alice, 1, e.ID, 0))
+
+	// удаленное событие не видно, но его история доступна
+	events, err := svc.EventsForDay(alice, 1, start)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	history, err := svc.EventHistory(alice, 1, e.ID)
+	require.NoError(t, err)
+	require.Equal(t, []domain.AuditAction{domain.AuditCreated, domain.AuditUpdated, domain.AuditDeleted}, actions(history))
+	require.Nil(t, history[0].Before)
+	require.Equal(t, "review", history[1].Before.Title)
+	require.Equal(t, "code review", history[1].After.Title)
+	require.Equal(t, int64(1), history[2].ActorID)
+	require.Nil(t, history[2].After)
+
+	// чужое событие недоступно и после удаления
+	_, err = svc.EventHistory(bob, 2, e.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	_, err = svc.RestoreEvent(bob, 2, e.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	restored, err := svc.RestoreEvent(alice, 1, e.ID)
+	require.NoError(t, err)
+	require.Equal(t, "code review", restored.Title)
+	events, err = svc.EventsForDay(alice, 1, start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	history, err = svc.EventHistory(alice, 1, e.ID)
+	require.NoError(t, err)
+	require.Equal(t, domain.AuditRestored, history[len(history)-1].Action)
+
+	// по истечении срока событие не восстановить, а очистка удаляет его окончательно
+	require.NoError(t, svc.DeleteEvent(alice, 1, e.ID, 0))
+	svc.SetRetention(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	_, err = svc.RestoreEvent(alice, 1, e.ID)
+	require.ErrorIs(t, err, domain.ErrRestoreExpired)
+	n, err := svc.PurgeDeleted(alice)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	svc.SetRetention(time.Hour)
+	_, err = svc.RestoreEvent(alice, 1, e.ID)
+	require.ErrorIs(t, err, domain.ErrRestoreExpired)
+}
+
+// failingSeries - хранилище, в котором не сохраняются изменения повторяющихся серий
+type failingSeries struct {
+	*memory.Storage
+}
+
+func (s failingSeries) Update(ctx context.Context, e domain.Event, a domain.AuditEntry) error {
+	if e.Recurring() {
+		return errors.New("storage is unavailable")
+	}
+	return s.Storage.Update(ctx, e, a)
+}
+
+func TestOccurrenceRollback(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(failingSeries{memory.New()})
+	start := date("2030-01-07").Add(10 * time.Hour)
+
+	series, err := svc.CreateEvent(ctx, domain.Event{UserID: 1, Title: "standup", Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY"})
+	require.NoError(t, err)
+
+	// серия не сохранилась - выделенное вхождение удаляется без следа
+	_, err = svc.UpdateEvent(ctx, domain.Event{ID: series.ID, UserID: 1, Title: "moved", Start: start.Add(time.Hour), RecurrenceID: start})
+	require.Error(t, err)
+
+	occurrenceID := series.ID + 1
+	_, err = svc.RestoreEvent(ctx, 1, occurrenceID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	_, err = svc.EventHistory(ctx, 1, occurrenceID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	events, err := svc.EventsForDay(ctx, 1, start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "standup", events[0].Title)
+}
+
+// failingCreateRepo - хранилище, которое не может сохранить событие с названием title
+type failingCreateRepo struct {
+	*memory.Storage
+	title string
+}
+
+func (r failingCreateRepo) Create(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.Event, error) {
+	if e.Title == r.title {
+		return domain.Event{}, errors.New("disk is full")
+	}
+	return r.Storage.Create(ctx, e, a)
+}
+
+func TestImportRollback(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(failingCreateRepo{Storage: memory.New(), title: "broken"})
+	start := date("2030-01-07").Add(10 * time.Hour)
+
+	series := domain.Event{UID: "standup@example.com", Title: "standup", Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY"}
+	moved := domain.Event{UID: "standup@example.com", Title: "standup (late)", RecurrenceID: start.AddDate(0, 0, 1),
+		Start: start.AddDate(0, 0, 1).Add(2 * time.Hour), End: start.AddDate(0, 0, 1).Add(3 * time.Hour)}
+	// вхождение неизвестной серии сохраняется последним, и хранилище его не принимает
+	broken := domain.Event{UID: "review@example.com", Title: "broken", RecurrenceID: start.Add(30 * time.Minute),
+		Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)}
+
+	_, err := svc.ImportEvents(ctx, 1, []domain.Event{series, moved, broken})
+	require.Error(t, err)
+	events, err := svc.EventsForWeek(ctx, 1, start)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	res, err := svc.ImportEvents(ctx, 1, []domain.Event{series, moved})
+	require.NoError(t, err)
+	require.Equal(t, domain.ImportResult{Created: 2}, res)
+
+	// неудачный повторный импорт возвращает серию и вхождение к прежнему состоянию
+	series.Title, series.Start, series.End = "daily standup", start.Add(time.Hour), start.Add(2*time.Hour)
+	moved.Title = "standup (very late)"
+	_, err = svc.ImportEvents(ctx, 1, []domain.Event{series, moved, broken})
+	require.Error(t, err)
+
+	events, err = svc.EventsForDay(ctx, 1, start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "standup", events[0].Title)
+	require.Equal(t, start, events[0].Start)
+	events, err = svc.EventsForDay(ctx, 1, start.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "standup (late)", events[0].Title)
+	require.Equal(t, start.AddDate(0, 0, 1), events[0].RecurrenceID)
+}
+
+func TestImportSkipsInvalid(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(memory.New())
+	start := date("2030-01-07").Add(10 * time.Hour)
+
+	res, err := svc.ImportEvents(ctx, 1, []domain.Event{
+		{UID: "standup@example.com", Title: "standup", Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY"},
+		// вхождения с таким началом в серии нет
+		{UID: "standup@example.com", Title: "standup (late)", RecurrenceID: start.Add(30 * time.Minute),
+			Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+		{UID: "review@example.com", Title: "review", Start: start, End: start.Add(time.Hour), RRule: "FREQ=MONTHLY;BYSETPOS=1"},
+		// вхождение пропущенной серии тоже пропускается, а не сохраняется разовым событием
+		{UID: "review@example.com", Title: "review (moved)", RecurrenceID: start,
+			Start: start.Add(time.Hour), End: start.Add(2 * time.Hour)},
+		{UID: "untitled@example.com", Start: start, End: start.Add(time.Hour)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Created)
+	require.Zero(t, res.Updated)
+
+	reasons := make(map[string][]string)
+	for _, sk := range res.Skipped {
+		reasons[sk.UID] = append(reasons[sk.UID], sk.Reason)
+	}
+	require.Len(t, reasons["standup@example.com"], 1)
+	require.Contains(t, reasons["standup@example.com"][0], "no such occurrence")
+	require.Len(t, reasons["review@example.com"], 2)
+	require.Contains(t, reasons["review@example.com"][0], "BYSETPOS")
+	require.Equal(t, "series is skipped", reasons["review@example.com"][1])
+	require.Equal(t, []string{domain.ErrEmptyTitle.Error()}, reasons["untitled@example.com"])
+
+	events, err := svc.EventsForDay(ctx, 1, start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "standup", events[0].Title)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// conflictHorizon - на сколько вперед проверяются пересечения вхождений серии
+const conflictHorizon = 90 * 24 * time.Hour
+
+// EventConflicts - события того же календаря, пересекающиеся с событием,
+// а для серии - с ее вхождениями в ближайшие conflictHorizon
+func (c *Calendar) EventConflicts(ctx context.Context, userID, eventID int64) ([]domain.Event, error) {
+	e, err := c.accessibleEvent(ctx, userID, eventID, domain.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	return c.conflicts(ctx, e)
+}
+
+// SetUserConflictPolicy - сохраняет политику пересечений личных событий пользователя
+func (c *Calendar) SetUserConflictPolicy(ctx context.Context, userID int64, policy domain.ConflictPolicy) (domain.User, error) {
+	if !policy.Valid() {
+		return domain.User{}, domain.ErrInvalidConflictPolicy
+	}
+
+	u, err := c.User(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	u.ConflictPolicy = policy
+	if err := c.repo.SaveUser(ctx, u); err != nil {
+		return domain.User{}, fmt.Errorf("save user: %w", err)
+	}
+
+	return u, nil
+}
+
+// SetCalendarConflictPolicy - сохраняет политику пересечений календаря; менять ее может только владелец
+func (c *Calendar) SetCalendarConflictPolicy(ctx context.Context, userID, calendarID int64, policy domain.ConflictPolicy) (domain.Calendar, error) {
+	if !policy.Valid() {
+		return domain.Calendar{}, domain.ErrInvalidConflictPolicy
+	}
+
+	cal, err := c.ownCalendar(ctx, userID, calendarID)
+	if err != nil {
+		return domain.Calendar{}, err
+	}
+
+	cal.ConflictPolicy = policy
+	if err := c.repo.UpdateCalendar(ctx, cal); err != nil {
+		return domain.Calendar{}, fmt.Errorf("update calendar: %w", err)
+	}
+	cal.Permission = domain.PermissionWrite
+
+	return cal, nil
+}
+
+// checkConflicts - *domain.ConflictError, если политика календаря события
+// отклоняет пересечения и они есть
+func (c *Calendar) checkConflicts(ctx context.Context, e domain.Event) error {
+	policy, err := c.conflictPolicy(ctx, e)
+	if err != nil {
+		return err
+	}
+	if policy != domain.ConflictReject {
+		return nil
+	}
+
+	conflicts, err := c.conflicts(ctx, e)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &domain.ConflictError{Events: conflicts}
+	}
+	return nil
+}
+
+// conflictPolicy - политика календаря события, для личного события - политика владельца
+func (c *Calendar) conflictPolicy(ctx context.Context, e domain.Event) (domain.ConflictPolicy, error) {
+	if e.CalendarID != 0 {
+		cal, err := c.repo.GetCalendar(ctx, e.CalendarID)
+		if err != nil {
+			return "", fmt.Errorf("get calendar: %w", err)
+		}
+		return cal.ConflictPolicy, nil
+	}
+
+	u, err := c.user(ctx, e.UserID)
+	if err != nil {
+		return "", err
+	}
+	return u.ConflictPolicy, nil
+}
+
+// conflicts - события владельца из того же календаря (для личного события - личные),
+// пересекающиеся с событием или вхождениями серии, по возрастанию начала.
+// Само событие, его серия и ее вхождения конфликтами не считаются.
+func (c *Calendar) conflicts(ctx context.Context, e domain.Event) ([]domain.Event, error) {
+	spans := []domain.Event{e}
+	if e.Recurring() {
+		// прошедшие вхождения уже не важны
+		from := e.Start
+		if now := time.Now().UTC(); now.After(from) {
+			from = now
+		}
+		var err error
+		if spans, err = recurrence.Occurrences(e, from, from.Add(conflictHorizon)); err != nil {
+			return nil, err
+		}
+	}
+	// моментальные события время не занимают
+	spans = slices.DeleteFunc(spans, func(s domain.Event) bool { return !s.Start.Before(s.End) })
+	if len(spans) == 0 {
+		return nil, nil
+	}
+	from, to := spans[0].Start, spans[len(spans)-1].End
+
+	candidates, err := c.repo.ListOverlapping(ctx, e.UserID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list overlapping events: %w", err)
+	}
+	series, err := c.repo.ListRecurring(ctx, e.UserID, to)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring events: %w", err)
+	}
+	for _, s := range series {
+		occ, err := overlapping(s, from, to)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, occ...)
+	}
+
+	conflicts := make([]domain.Event, 0)
+	for _, x := range candidates {
+		if x.CalendarID != e.CalendarID || sameSeries(e, x) {
+			continue
+		}
+		// вхождения серии одной длительности: концы упорядочены так же, как начала
+		i := sort.Search(len(spans), func(i int) bool { return spans[i].End.After(x.Start) })
+		if i < len(spans) && spans[i].Overlaps(x) {
+			conflicts = append(conflicts, x)
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Start.Equal(conflicts[j].Start) {
+			return conflicts[i].ID < conflicts[j].ID
+		}
+		return conflicts[i].Start.Before(conflicts[j].Start)
+	})
+	return conflicts, nil
+}
+
+// sameSeries - x - само событие e, его серия или ее вхождение
+func sameSeries(e, x domain.Event) bool {
+	return x.ID == e.ID ||
+		(e.ID != 0 && x.SeriesID == e.ID) ||
+		(e.SeriesID != 0 && (x.ID == e.SeriesID || x.SeriesID == e.SeriesID))
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/freebusy"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	// maxSlotRange - наибольший период поиска свободного времени
+	maxSlotRange = 62 * 24 * time.Hour
+	// maxSlotUsers - наибольшее число участников в одном запросе
+	maxSlotUsers = 50
+)
+
+// FreeBusy - занятость пользователей в полуинтервале [from, to).
+// Отдаются только интервалы, без названий и описаний событий, поэтому
+// запрашивающий может видеть занятость коллег, не имея доступа к их календарям.
+func (c *Calendar) FreeBusy(ctx context.Context, requesterID int64, userIDs []int64, from, to time.Time) ([]domain.BusyTimes, error) {
+	if err := checkUser(ctx, requesterID); err != nil {
+		return nil, err
+	}
+	userIDs, err := checkRange(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.BusyTimes, 0, len(userIDs))
+	for _, id := range userIDs {
+		busy, err := c.busy(ctx, id, from, to)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, domain.BusyTimes{UserID: id, Busy: busy})
+	}
+
+	return result, nil
+}
+
+// FindSlots - общие свободные интервалы участников не короче q.Duration.
+// Рабочее время каждого участника считается в его собственной зоне.
+func (c *Calendar) FindSlots(ctx context.Context, requesterID int64, q domain.SlotQuery) ([]domain.Interval, error) {
+	if err := checkUser(ctx, requesterID); err != nil {
+		return nil, err
+	}
+	userIDs, err := checkRange(q.UserIDs, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	hours := q.WorkingHours
+	if q.Duration <= 0 || hours.Start < 0 || hours.End > 24*time.Hour || hours.End <= hours.Start {
+		return nil, domain.ErrInvalidSlotQuery
+	}
+
+	from, to := q.From.UTC(), q.To.UTC()
+	common := []domain.Interval{{Start: from, End: to}}
+	for _, id := range userIDs {
+		loc, err := c.userLocation(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		busy, err := c.busy(ctx, id, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		free := freebusy.Subtract(freebusy.WorkingWindows(from, to, loc, hours), busy)
+		common = freebusy.Intersect(common, free)
+		if len(common) == 0 {
+			break
+		}
+	}
+
+	return freebusy.Slots(common, q.Duration), nil
+}
+
+// busy - занятые интервалы пользователя, обрезанные по [from, to): его собственные события
+// и события, приглашение на которые он принял. События общих календарей других пользователей
+// и приглашения без ответа или под вопросом время не занимают.
+func (c *Calendar) busy(ctx context.Context, userID int64, from, to time.Time) ([]domain.Interval, error) {
+	from, to = from.UTC(), to.UTC()
+	events, err := c.repo.ListOverlapping(ctx, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list overlapping events: %w", err)
+	}
+	series, err := c.repo.ListRecurring(ctx, userID, to)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring events: %w", err)
+	}
+	for _, s := range series {
+		occ, err := overlapping(s, from, to)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, occ...)
+	}
+	accepted, err := c.acceptedEvents(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, accepted...)
+
+	busy := make([]domain.Interval, 0, len(events))
+	for _, e := range events {
+		busy = append(busy, domain.Interval{Start: e.Start, End: e.End})
+	}
+	return freebusy.Intersect(busy, []domain.Interval{{Start: from, End: to}}), nil
+}
+
+// userLocation - зона пользователя без проверки, что запрос сделал сам пользователь
+func (c *Calendar) userLocation(ctx context.Context, userID int64) (*time.Location, error) {
+	u, err := c.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.LoadLocation(u.TimeZone)
+}
+
+// checkRange - проверяет период и список участников, убирая повторы
+func checkRange(userIDs []int64, from, to time.Time) ([]int64, error) {
+	if from.IsZero() || !to.After(from) || to.Sub(from) > maxSlotRange {
+		return nil, domain.ErrInvalidDate
+	}
+
+	ids := slices.Clone(userIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 || len(ids) > maxSlotUsers {
+		return nil, domain.ErrInvalidSlotQuery
+	}
+	if ids[0] <= 0 {
+		return nil, domain.ErrInvalidUserID
+	}
+	return ids, nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer - сколько уведомлений может ждать отправки одному подписчику
+const subscriptionBuffer = 64
+
+// hub - рассылка уведомлений об изменениях подписчикам в памяти процесса
+type hub struct {
+	mu     sync.Mutex
+	subs   map[int64]map[*subscription]struct{}
+	closed bool
+}
+
+// subscription - подписка пользователя; канал закрывается при отписке, остановке
+// или переполнении буфера (клиент не успевает читать и должен переподключиться)
+type subscription struct {
+	userID int64
+	ch     chan domain.Change
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[int64]map[*subscription]struct{})}
+}
+
+// subscribe - новая подписка пользователя; false, если хаб уже закрыт
+func (h *hub) subscribe(userID int64) (*subscription, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, false
+	}
+
+	s := &subscription{userID: userID, ch: make(chan domain.Change, subscriptionBuffer)}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*subscription]struct{})
+	}
+	h.subs[userID][s] = struct{}{}
+	return s, true
+}
+
+// unsubscribe - отменяет подписку; повторный вызов ничего не делает
+func (h *hub) unsubscribe(s *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(s)
+}
+
+// remove - удаляет подписку и закрывает ее канал; вызывается под h.mu
+func (h *hub) remove(s *subscription) {
+	subs, ok := h.subs[s.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[s]; !ok {
+		return
+	}
+
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.subs, s.userID)
+	}
+	close(s.ch)
+}
+
+// users - пользователи, у которых есть подписки
+func (h *hub) users() []int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ids := make([]int64, 0, len(h.subs))
+	for id := range h.subs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// publish - отправляет уведомление подпискам пользователей, не блокируясь
+func (h *hub) publish(userIDs []int64, c domain.Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, id := range userIDs {
+		for s := range h.subs[id] {
+			select {
+			case s.ch <- c:
+			default:
+				h.remove(s)
+			}
+		}
+	}
+}
+
+// close - закрывает все подписки и запрещает новые
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for s := range subs {
+			h.remove(s)
+		}
+	}
+}
+
+// Subscribe - подписывает пользователя на изменения видимых ему событий.
+// Канал закрывается при отмене (cancel), остановке сервиса или если клиент не успевает читать.
+func (c *Calendar) Subscribe(ctx context.Context, userID int64) (<-chan domain.Change, func(), error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return nil, nil, err
+	}
+
+	s, ok := c.hub.subscribe(userID)
+	if !ok {
+		return nil, nil, domain.ErrStreamClosed
+	}
+	return s.ch, func() { c.hub.unsubscribe(s) }, nil
+}
+
+// CloseSubscriptions - закрывает все подписки; вызывается при остановке сервера,
+// чтобы долгие запросы потока завершились и не задерживали ее
+func (c *Calendar) CloseSubscriptions() {
+	c.hub.close()
+}
+
+// audience - подписчики, которым видно событие: владелец, участники открытого календаря
+// и приглашенные (на серию - и на ее вхождения). Число запросов к хранилищу не зависит
+// от числа подписчиков, а без подписчиков хранилище не запрашивается вовсе.
+func (c *Calendar) audience(ctx context.Context, e domain.Event) []int64 {
+	subscribed := make(map[int64]bool)
+	for _, id := range c.hub.users() {
+		subscribed[id] = true
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	var ids []int64
+	add := func(id int64) {
+		if subscribed[id] {
+			ids = append(ids, id)
+			// каждый пользователь получает уведомление один раз
+			subscribed[id] = false
+		}
+	}
+
+	add(e.UserID)
+	// ошибка хранилища не должна ломать само изменение - такие получатели пропускаются
+	if e.CalendarID != 0 {
+		shares, _ := c.repo.ListCalendarShares(ctx, e.CalendarID)
+		for _, sh := range shares {
+			add(sh.UserID)
+		}
+	}
+	for _, id := range []int64{e.ID, e.SeriesID} {
+		if id == 0 {
+			continue
+		}
+		invitations, _ := c.repo.ListInvitations(ctx, id)
+		for _, inv := range invitations {
+			add(inv.UserID)
+		}
+	}
+
+	return ids
+}
+
+// notify - рассылает уведомление тем, кому видно событие
+func (c *Calendar) notify(ctx context.Context, typ domain.ChangeType, e domain.Event) {
+	c.notifyUsers(c.audience(ctx, e), typ, e)
+}
+
+// notifyUsers - рассылает уведомление заранее определенным пользователям
+func (c *Calendar) notifyUsers(userIDs []int64, typ domain.ChangeType, e domain.Event) {
+	if len(userIDs) == 0 {
+		return
+	}
+	c.hub.publish(userIDs, domain.Change{Type: typ, Event: e, At: time.Now().UTC()})
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// границы выборки всех событий пользователя
+var (
+	allFrom = time.Time{}
+	allTo   = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// ExportEvents - все события пользователя без разворачивания серий
+func (c *Calendar) ExportEvents(ctx context.Context, userID int64) ([]domain.Event, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	events, err := c.repo.ListByUser(ctx, userID, allFrom, allTo)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	return events, nil
+}
+
+// ImportEvents - сохраняет события пользователя из внешнего календаря.
+// События с уже известным UID обновляются, выделенные вхождения (RECURRENCE-ID)
+// привязываются к серии с тем же UID. Некорректные и неподдерживаемые события (и вхождения
+// пропущенной серии) пропускаются с причиной в ImportResult.Skipped. Пересечения не проверяются,
+// а если сохранить событие не удалось из-за хранилища, уже сделанные изменения откатываются.
+func (c *Calendar) ImportEvents(ctx context.Context, userID int64, events []domain.Event) (domain.ImportResult, error) {
+	var res domain.ImportResult
+	if err := checkUser(ctx, userID); err != nil {
+		return res, err
+	}
+
+	// серии должны быть сохранены раньше своих вхождений
+	events = slices.Clone(events)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].RecurrenceID.IsZero() && !events[j].RecurrenceID.IsZero()
+	})
+
+	skippedSeries := make(map[string]bool)
+	skip := func(e domain.Event, err error) {
+		res.Skipped = append(res.Skipped, domain.ImportSkip{UID: e.UID, Reason: err.Error()})
+		if e.RecurrenceID.IsZero() && e.UID != "" {
+			skippedSeries[e.UID] = true
+		}
+	}
+
+	undo := &importUndo{before: make(map[int64]domain.Event)}
+	for _, e := range events {
+		e.ID, e.SeriesID, e.UserID = 0, 0, userID
+
+		if !e.RecurrenceID.IsZero() && skippedSeries[e.UID] {
+			skip(e, errors.New("series is skipped"))
+			continue
+		}
+		// событие проверяется до изменений, чтобы не сохранять его частично
+		checked := e
+		if !checked.RecurrenceID.IsZero() {
+			checked.RRule = ""
+		}
+		if err := validate(&checked); err != nil {
+			skip(e, err)
+			continue
+		}
+
+		updated, err := c.importEvent(ctx, e, undo)
+		if invalidEvent(err) {
+			// ошибки проверки возникают до записи - пропускается только это событие
+			skip(e, err)
+			continue
+		}
+		if err != nil {
+			c.rollbackImport(ctx, userID, undo)
+			return domain.ImportResult{}, fmt.Errorf("event %q: %w", e.UID, err)
+		}
+		if updated {
+			res.Updated++
+		} else {
+			res.Created++
+		}
+	}
+
+	return res, nil
+}
+
+// invalidEvent - ошибка в данных самого события, а не хранилища
+func invalidEvent(err error) bool {
+	for _, target := range []error{
+		domain.ErrInvalidDate,
+		domain.ErrEmptyTitle,
+		domain.ErrInvalidReminder,
+		domain.ErrInvalidRecurrence,
+		domain.ErrInvalidTimeZone,
+		domain.ErrInvalidTags,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// importUndo - изменения импорта для отката: созданные события и прежние состояния измененных
+type importUndo struct {
+	created []int64
+	before  map[int64]domain.Event
+	// порядок, в котором запомнены прежние состояния
+	updated []int64
+}
+
+// remember - запоминает состояние событий до их первого изменения импортом
+func (u *importUndo) remember(events ...domain.Event) {
+	for _, e := range events {
+		if _, ok := u.before[e.ID]; !ok {
+			u.before[e.ID] = e
+			u.updated = append(u.updated, e.ID)
+		}
+	}
+}
+
+// rollbackImport - удаляет созданные импортом события и возвращает измененным прежнее состояние.
+// Откат делается по возможности: ошибка здесь не должна скрыть ошибку импорта.
+func (c *Calendar) rollbackImport(ctx context.Context, userID int64, undo *importUndo) {
+	for _, id := range slices.Backward(undo.created) {
+		e, err := c.repo.Get(ctx, id)
+		if err != nil {
+			continue
+		}
+		if err := c.repo.Discard(ctx, id); err == nil {
+			c.notify(ctx, domain.ChangeDeleted, e)
+		}
+	}
+
+	for _, id := range slices.Backward(undo.updated) {
+		before := undo.before[id]
+		current, err := c.repo.Get(ctx, id)
+		if err != nil || current.Version == before.Version {
+			continue
+		}
+		before.Version = current.Version
+		if err := c.repo.Update(ctx, before, auditEntry(userID, domain.AuditUpdated, &current)); err == nil {
+			before.Version++
+			c.notify(ctx, domain.ChangeUpdated, before)
+		}
+	}
+}
+
+// importEvent - создает или обновляет событие, возвращает true при обновлении;
+// изменения записываются в undo
+func (c *Calendar) importEvent(ctx context.Context, e domain.Event, undo *importUndo) (bool, error) {
+	var existing []domain.Event
+	if e.UID != "" {
+		var err error
+		if existing, err = c.repo.ListByUID(ctx, e.UserID, e.UID); err != nil {
+			return false, fmt.Errorf("find by uid: %w", err)
+		}
+	}
+	undo.remember(existing...)
+
+	var master *domain.Event
+	for i := range existing {
+		if existing[i].SeriesID == 0 && existing[i].RecurrenceID.IsZero() {
+			master = &existing[i]
+			break
+		}
+	}
+
+	if e.RecurrenceID.IsZero() {
+		if master == nil {
+			return false, c.importCreate(ctx, e, undo)
+		}
+		// перенос начала серии переносит и ее выделенные вхождения, в том числе с другим UID
+		detached, err := c.repo.ListBySeries(ctx, master.ID)
+		if err != nil {
+			return false, fmt.Errorf("list occurrences: %w", err)
+		}
+		undo.remember(detached...)
+		e.ID = master.ID
+		_, err = c.updateEvent(ctx, e, false)
+		return true, err
+	}
+
+	// серии нет - сохраняем вхождение как разовое событие
+	if master == nil || !master.Recurring() {
+		e.RecurrenceID = time.Time{}
+		return false, c.importCreate(ctx, e, undo)
+	}
+
+	for _, ex := range existing {
+		if ex.SeriesID == master.ID && ex.RecurrenceID.Equal(e.RecurrenceID) {
+			// updateEvent сохранит привязку к серии
+			e.ID, e.RecurrenceID = ex.ID, time.Time{}
+			_, err := c.updateEvent(ctx, e, false)
+			return true, err
+		}
+	}
+
+	// вхождение уже исключено из серии (EXDATE в файле) - просто привязываем его
+	if slices.ContainsFunc(master.ExDates, e.RecurrenceID.Equal) {
+		e.RRule, e.SeriesID = "", master.ID
+		if err := validate(&e); err != nil {
+			return false, err
+		}
+		e, err := c.repo.Create(ctx, e, auditEntry(e.UserID, domain.AuditCreated, nil))
+		if err != nil {
+			return false, fmt.Errorf("create occurrence: %w", err)
+		}
+		undo.created = append(undo.created, e.ID)
+		c.notify(ctx, domain.ChangeCreated, e)
+		return false, nil
+	}
+
+	// вхождение выделяется из серии: создается событие, серия получает исключение
+	e.ID = master.ID
+	occ, err := c.updateEvent(ctx, e, false)
+	if err != nil {
+		return false, err
+	}
+	undo.created = append(undo.created, occ.ID)
+	return false, nil
+}
+
+// importCreate - создает событие импорта и запоминает его для отката
+func (c *Calendar) importCreate(ctx context.Context, e domain.Event, undo *importUndo) error {
+	created, err := c.createEvent(ctx, e, false)
+	if err != nil {
+		return err
+	}
+	undo.created = append(undo.created, created.ID)
+	return nil
+}
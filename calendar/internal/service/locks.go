@@ -0,0 +1,45 @@
+package service
+
+import "sync"
+
+// ownerLocks - блокировки по владельцу событий: проверка пересечений и запись события
+// выполняются под одной блокировкой, иначе два параллельных запроса проходят проверку
+// друг до друга и оба сохраняются. Блокировки действуют в пределах процесса.
+type ownerLocks struct {
+	mu    sync.Mutex
+	locks map[int64]*ownerLock
+}
+
+// ownerLock - блокировка владельца и число ее ожидающих и держащих;
+// блокировка без них удаляется, чтобы карта не росла
+type ownerLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newOwnerLocks() *ownerLocks {
+	return &ownerLocks{locks: make(map[int64]*ownerLock)}
+}
+
+// lock - захватывает блокировку владельца; возвращает функцию ее освобождения
+func (l *ownerLocks) lock(ownerID int64) func() {
+	l.mu.Lock()
+	ol, ok := l.locks[ownerID]
+	if !ok {
+		ol = &ownerLock{}
+		l.locks[ownerID] = ol
+	}
+	ol.refs++
+	l.mu.Unlock()
+
+	ol.mu.Lock()
+	return func() {
+		ol.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if ol.refs--; ol.refs == 0 {
+			delete(l.locks, ownerID)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DeleteOccurrence - удаляет одно вхождение серии, добавляя его в исключения.
+// Ненулевая version - версия серии, которую ожидает клиент.
+func (c *Calendar) DeleteOccurrence(ctx context.Context, userID, eventID int64, recurrenceID time.Time, version int64) error {
+	if err := checkUser(ctx, userID); err != nil {
+		return err
+	}
+
+	series, err := c.seriesWithOccurrence(ctx, userID, eventID, recurrenceID)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(series, version); err != nil {
+		return err
+	}
+
+	// Update сохраняет серию, только если ее версия та же, что при чтении,
+	// поэтому изменение серии между проверкой и записью тоже дает ErrVersionMismatch
+	before := series
+	series.ExDates = append(slices.Clip(series.ExDates), recurrenceID)
+	if err := c.repo.Update(ctx, series, auditEntry(userID, domain.AuditUpdated, &before)); err != nil {
+		return fmt.Errorf("update series: %w", err)
+	}
+	series.Version++
+
+	c.notify(ctx, domain.ChangeUpdated, series)
+	return nil
+}
+
+// updateOccurrence - выделяет вхождение серии в отдельное событие с новыми данными.
+// Без окончания вхождение длится столько же, сколько вхождения серии.
+func (c *Calendar) updateOccurrence(ctx context.Context, e domain.Event, checkConflicts bool) (domain.Event, error) {
+	recurrenceID, actorID, version := e.RecurrenceID, e.UserID, e.Version
+	withoutEnd := e.End.IsZero()
+	e.RRule, e.ExDates = "", nil
+	e.SeriesID = 0
+	if err := validate(&e); err != nil {
+		return domain.Event{}, err
+	}
+
+	series, err := c.seriesWithOccurrence(ctx, e.UserID, e.ID, recurrenceID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if err := checkVersion(series, version); err != nil {
+		return domain.Event{}, err
+	}
+
+	if e.TimeZone == "" {
+		e.TimeZone = series.TimeZone
+	}
+	if withoutEnd {
+		e.End = e.Start.Add(series.End.Sub(series.Start))
+	}
+	e.UserID, e.CalendarID = series.UserID, series.CalendarID
+	e.ID = 0
+	e.SeriesID = series.ID
+	e.RecurrenceID = recurrenceID
+	unlock := c.owners.lock(e.UserID)
+	defer unlock()
+	if checkConflicts {
+		if err := c.checkConflicts(ctx, e); err != nil {
+			return domain.Event{}, err
+		}
+	}
+	e, err = c.repo.Create(ctx, e, auditEntry(actorID, domain.AuditCreated, nil))
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("create occurrence: %w", err)
+	}
+
+	before := series
+	series.ExDates = append(slices.Clip(series.ExDates), recurrenceID)
+	if err := c.repo.Update(ctx, series, auditEntry(actorID, domain.AuditUpdated, &before)); err != nil {
+		// вхождение не должно остаться ни в корзине, ни в журнале изменений
+		_ = c.repo.Discard(ctx, e.ID)
+		return domain.Event{}, fmt.Errorf("update series: %w", err)
+	}
+	series.Version++
+
+	c.notify(ctx, domain.ChangeUpdated, series)
+	c.notify(ctx, domain.ChangeCreated, e)
+	return e, nil
+}
+
+// overlapping - вхождения серии, пересекающиеся с интервалом (from, to)
+func overlapping(series domain.Event, from, to time.Time) ([]domain.Event, error) {
+	// вхождение, начавшееся раньше from, заходит в интервал, если длится дольше, чем до from
+	occ, err := recurrence.Occurrences(series, from.Add(-series.End.Sub(series.Start)), to)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(occ, func(o domain.Event) bool { return !o.End.After(from) }), nil
+}
+
+// moveDetached - переносит выделенные вхождения серии вслед за ее началом:
+// их RecurrenceID должны совпадать с перенесенными исключениями серии
+func (c *Calendar) moveDetached(ctx context.Context, actorID int64, old, series domain.Event) error {
+	detached, err := c.repo.ListBySeries(ctx, series.ID)
+	if err != nil {
+		return fmt.Errorf("list occurrences: %w", err)
+	}
+
+	for _, occ := range detached {
+		before := occ
+		occ.RecurrenceID = shiftOccurrences([]time.Time{occ.RecurrenceID}, old.Start, series.Start, series.Location())[0]
+		if err := c.repo.Update(ctx, occ, auditEntry(actorID, domain.AuditUpdated, &before)); err != nil {
+			return fmt.Errorf("update occurrence: %w", err)
+		}
+		occ.Version++
+		c.notify(ctx, domain.ChangeUpdated, occ)
+	}
+
+	return nil
+}
+
+// shiftOccurrences - переносит начала вхождений серии так же, как перенесено ее начало из from в to:
+// на то же число дней и то же время по часам зоны серии loc
+func shiftOccurrences(starts []time.Time, from, to time.Time, loc *time.Location) []time.Time {
+	if len(starts) == 0 || from.Equal(to) {
+		return starts
+	}
+
+	from, to = from.In(loc), to.In(loc)
+	days := int(civilDate(to).Sub(civilDate(from)) / (24 * time.Hour))
+	clock := clockTime(to) - clockTime(from)
+
+	shifted := make([]time.Time, len(starts))
+	for i, t := range starts {
+		t = t.In(loc)
+		// время задается по часам, поэтому переход на летнее время в этот день его не сдвигает
+		shifted[i] = time.Date(t.Year(), t.Month(), t.Day()+days, 0, 0, 0, int(clockTime(t)+clock), loc).UTC()
+	}
+	return shifted
+}
+
+// civilDate - дата t без времени
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// clockTime - время t по часам от начала дня
+func clockTime(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
+
+// seriesWithOccurrence - возвращает серию пользователя, если recurrenceID - ее неисключенное вхождение
+func (c *Calendar) seriesWithOccurrence(ctx context.Context, userID, eventID int64, recurrenceID time.Time) (domain.Event, error) {
+	series, err := c.userEvent(ctx, userID, eventID)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if !series.Recurring() {
+		return domain.Event{}, fmt.Errorf("%w: event is not recurring", domain.ErrInvalidRecurrence)
+	}
+
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("parse stored rule: %w", err)
+	}
+	if !rule.Includes(series.Start.In(series.Location()), recurrenceID) || slices.ContainsFunc(series.ExDates, recurrenceID.Equal) {
+		return domain.Event{}, fmt.Errorf("%w: no such occurrence", domain.ErrInvalidRecurrence)
+	}
+
+	return series, nil
+}
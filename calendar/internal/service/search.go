@@ -0,0 +1,190 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// defaultSearchLimit, maxSearchLimit - размер страницы поиска по умолчанию и максимальный
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+	// maxTags, maxTagLen - ограничения на теги события
+	maxTags   = 20
+	maxTagLen = 50
+	// searchHorizon - на сколько вперед ищутся вхождения серий, если конец периода не задан
+	searchHorizon = 365 * 24 * time.Hour
+)
+
+// SearchEvents - поиск среди событий, видимых пользователю: собственных, из открытых ему календарей
+// и тех, на которые он приглашен и не отказался. Серия дает вхождения, начинающиеся в периоде поиска
+// (без конца периода - в ближайшие searchHorizon). Следующая страница запрашивается с курсором из SearchPage.NextCursor.
+func (c *Calendar) SearchEvents(ctx context.Context, q domain.SearchQuery) (domain.SearchPage, error) {
+	if err := checkUser(ctx, q.UserID); err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	f, err := searchFilter(q)
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+
+	shares, err := c.repo.ListShares(ctx, q.UserID)
+	if err != nil {
+		return domain.SearchPage{}, fmt.Errorf("list shares: %w", err)
+	}
+	for _, sh := range shares {
+		f.CalendarIDs = append(f.CalendarIDs, sh.CalendarID)
+	}
+	invitations, err := c.repo.ListUserInvitations(ctx, q.UserID)
+	if err != nil {
+		return domain.SearchPage{}, fmt.Errorf("list invitations: %w", err)
+	}
+	for _, inv := range invitations {
+		if inv.Status != domain.RSVPDeclined {
+			f.EventIDs = append(f.EventIDs, inv.EventID)
+		}
+	}
+
+	// лишнее событие показывает, что есть следующая страница
+	limit := f.Limit
+	f.Limit++
+	events, err := c.repo.Search(ctx, f)
+	if err != nil {
+		return domain.SearchPage{}, fmt.Errorf("search events: %w", err)
+	}
+	occurrences, err := c.searchOccurrences(ctx, f)
+	if err != nil {
+		return domain.SearchPage{}, err
+	}
+	if len(occurrences) > 0 {
+		events = append(events, occurrences...)
+		sort.Slice(events, func(i, j int) bool {
+			if events[i].Start.Equal(events[j].Start) {
+				return events[i].ID < events[j].ID
+			}
+			return events[i].Start.Before(events[j].Start)
+		})
+		events = events[:min(len(events), f.Limit)]
+	}
+
+	page := domain.SearchPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = domain.Cursor{Start: last.Start, ID: last.ID}.String()
+	}
+	return page, nil
+}
+
+// searchOccurrences - вхождения подходящих под фильтр серий, начинающиеся в периоде поиска
+// после курсора; от каждой серии - не больше f.Limit первых
+func (c *Calendar) searchOccurrences(ctx context.Context, f domain.EventFilter) ([]domain.Event, error) {
+	from, to := f.From, f.To
+	if f.After != nil && f.After.Start.After(from) {
+		from = f.After.Start
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+		if from.After(to) {
+			to = from
+		}
+		to = to.Add(searchHorizon)
+	}
+
+	// серия подходит, если начинается раньше конца периода: ее вхождения могут попасть в него
+	seriesFilter := f
+	seriesFilter.Recurring = true
+	seriesFilter.From, seriesFilter.To = time.Time{}, to
+	seriesFilter.After = nil
+	seriesFilter.Limit = 0
+	series, err := c.repo.Search(ctx, seriesFilter)
+	if err != nil {
+		return nil, fmt.Errorf("search series: %w", err)
+	}
+
+	var events []domain.Event
+	for _, s := range series {
+		occ, err := recurrence.Occurrences(s, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if f.After != nil {
+			occ = slices.DeleteFunc(occ, func(o domain.Event) bool { return !f.After.Before(o) })
+		}
+		events = append(events, occ[:min(len(occ), f.Limit)]...)
+	}
+	return events, nil
+}
+
+// searchFilter - проверяет параметры поиска и переводит их в фильтр хранилища
+func searchFilter(q domain.SearchQuery) (domain.EventFilter, error) {
+	f := domain.EventFilter{
+		UserID: q.UserID,
+		Text:   strings.TrimSpace(q.Text),
+		From:   q.From.UTC(),
+		To:     q.To.UTC(),
+		Limit:  q.Limit,
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return f, fmt.Errorf("%w: from must be before to", domain.ErrInvalidSearch)
+	}
+
+	switch {
+	case f.Limit == 0:
+		f.Limit = defaultSearchLimit
+	case f.Limit < 0 || f.Limit > maxSearchLimit:
+		return f, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidSearch, maxSearchLimit)
+	}
+
+	tags, err := normalizeTags(q.Tags)
+	if err != nil {
+		return f, fmt.Errorf("%w: %w", domain.ErrInvalidSearch, err)
+	}
+	f.Tags = tags
+
+	if q.Cursor != "" {
+		cursor, err := domain.ParseCursor(q.Cursor)
+		if err != nil {
+			return f, fmt.Errorf("%w: invalid cursor", domain.ErrInvalidSearch)
+		}
+		f.After = &cursor
+	}
+
+	return f, nil
+}
+
+// normalizeTags - теги в нижнем регистре без пробелов по краям, без повторов, по алфавиту
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			return nil, fmt.Errorf("%w: empty tag", domain.ErrInvalidTags)
+		case utf8.RuneCountInString(tag) > maxTagLen:
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", domain.ErrInvalidTags, tag, maxTagLen)
+		case strings.Contains(tag, ","):
+			return nil, fmt.Errorf("%w: tag %q contains a comma", domain.ErrInvalidTags, tag)
+		}
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+	if len(normalized) > maxTags {
+		return nil, fmt.Errorf("%w: more than %d tags", domain.ErrInvalidTags, maxTags)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SharingRepository - хранилище календарей, доступов к ним и приглашений
+type SharingRepository interface {
+	// CreateCalendar - сохраняет новый календарь, присваивая ему идентификатор
+	CreateCalendar(ctx context.Context, c domain.Calendar) (domain.Calendar, error)
+	// GetCalendar - возвращает календарь или domain.ErrCalendarNotFound
+	GetCalendar(ctx context.Context, id int64) (domain.Calendar, error)
+	// UpdateCalendar - заменяет существующий календарь или возвращает domain.ErrCalendarNotFound
+	UpdateCalendar(ctx context.Context, c domain.Calendar) error
+	// ListCalendars - календари пользователя по возрастанию идентификатора
+	ListCalendars(ctx context.Context, ownerID int64) ([]domain.Calendar, error)
+
+	// SaveShare - открывает календарь пользователю или меняет его право
+	SaveShare(ctx context.Context, sh domain.Share) error
+	// GetShare - доступ пользователя к календарю или domain.ErrNotShared
+	GetShare(ctx context.Context, calendarID, userID int64) (domain.Share, error)
+	// DeleteShare - закрывает календарь для пользователя или возвращает domain.ErrNotShared
+	DeleteShare(ctx context.Context, calendarID, userID int64) error
+	// ListShares - календари, открытые пользователю
+	ListShares(ctx context.Context, userID int64) ([]domain.Share, error)
+	// ListCalendarShares - пользователи, которым открыт календарь
+	ListCalendarShares(ctx context.Context, calendarID int64) ([]domain.Share, error)
+
+	// SaveInvitation - создает или заменяет приглашение
+	SaveInvitation(ctx context.Context, inv domain.Invitation) error
+	// GetInvitation - приглашение пользователя на событие или domain.ErrInvitationNotFound
+	GetInvitation(ctx context.Context, eventID, userID int64) (domain.Invitation, error)
+	// ListInvitations - приглашения на событие
+	ListInvitations(ctx context.Context, eventID int64) ([]domain.Invitation, error)
+	// ListUserInvitations - приглашения пользователя
+	ListUserInvitations(ctx context.Context, userID int64) ([]domain.Invitation, error)
+}
+
+// CreateCalendar - создает календарь пользователя
+func (c *Calendar) CreateCalendar(ctx context.Context, userID int64, name string) (domain.Calendar, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.Calendar{}, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.Calendar{}, domain.ErrEmptyName
+	}
+
+	cal, err := c.repo.CreateCalendar(ctx, domain.Calendar{OwnerID: userID, Name: name})
+	if err != nil {
+		return domain.Calendar{}, fmt.Errorf("create calendar: %w", err)
+	}
+	cal.Permission = domain.PermissionWrite
+
+	return cal, nil
+}
+
+// Calendars - собственные календари пользователя и открытые ему, с правом доступа
+func (c *Calendar) Calendars(ctx context.Context, userID int64) ([]domain.Calendar, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	calendars, err := c.repo.ListCalendars(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list calendars: %w", err)
+	}
+	for i := range calendars {
+		calendars[i].Permission = domain.PermissionWrite
+	}
+
+	shares, err := c.repo.ListShares(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list shares: %w", err)
+	}
+	for _, sh := range shares {
+		cal, err := c.repo.GetCalendar(ctx, sh.CalendarID)
+		if errors.Is(err, domain.ErrCalendarNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get calendar: %w", err)
+		}
+		cal.Permission = sh.Permission
+		calendars = append(calendars, cal)
+	}
+
+	return calendars, nil
+}
+
+// ShareCalendar - открывает календарь владельца пользователю с правом чтения или записи
+func (c *Calendar) ShareCalendar(ctx context.Context, ownerID, calendarID, userID int64, perm domain.Permission) (domain.Share, error) {
+	if !perm.Valid() {
+		return domain.Share{}, domain.ErrInvalidPermission
+	}
+	if userID <= 0 || userID == ownerID {
+		return domain.Share{}, domain.ErrInvalidUserID
+	}
+	if _, err := c.ownCalendar(ctx, ownerID, calendarID); err != nil {
+		return domain.Share{}, err
+	}
+
+	sh := domain.Share{CalendarID: calendarID, UserID: userID, Permission: perm}
+	if err := c.repo.SaveShare(ctx, sh); err != nil {
+		return domain.Share{}, fmt.Errorf("save share: %w", err)
+	}
+
+	return sh, nil
+}
+
+// UnshareCalendar - закрывает календарь владельца для пользователя
+func (c *Calendar) UnshareCalendar(ctx context.Context, ownerID, calendarID, userID int64) error {
+	if _, err := c.ownCalendar(ctx, ownerID, calendarID); err != nil {
+		return err
+	}
+
+	err := c.repo.DeleteShare(ctx, calendarID, userID)
+	if err != nil && !errors.Is(err, domain.ErrNotShared) {
+		return fmt.Errorf("delete share: %w", err)
+	}
+	return err
+}
+
+// InviteToEvent - приглашает пользователя на событие. Приглашать может тот, кто может изменять событие;
+// повторное приглашение возвращает существующее вместе с ответом участника.
+func (c *Calendar) InviteToEvent(ctx context.Context, userID, eventID, attendeeID int64) (domain.Invitation, error) {
+	e, err := c.userEvent(ctx, userID, eventID)
+	if err != nil {
+		return domain.Invitation{}, err
+	}
+	if attendeeID <= 0 || attendeeID == e.UserID {
+		return domain.Invitation{}, domain.ErrInvalidUserID
+	}
+
+	inv, err := c.repo.GetInvitation(ctx, eventID, attendeeID)
+	if err == nil {
+		return inv, nil
+	}
+	if !errors.Is(err, domain.ErrInvitationNotFound) {
+		return domain.Invitation{}, fmt.Errorf("get invitation: %w", err)
+	}
+
+	inv = domain.Invitation{
+		EventID:   eventID,
+		UserID:    attendeeID,
+		Status:    domain.RSVPNeedsAction,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := c.repo.SaveInvitation(ctx, inv); err != nil {
+		return domain.Invitation{}, fmt.Errorf("save invitation: %w", err)
+	}
+
+	return inv, nil
+}
+
+// RespondToInvitation - ответ участника на приглашение: accepted, declined или tentative
+func (c *Calendar) RespondToInvitation(ctx context.Context, userID, eventID int64, status domain.RSVP) (domain.Invitation, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.Invitation{}, err
+	}
+	if !status.Valid() {
+		return domain.Invitation{}, domain.ErrInvalidRSVP
+	}
+
+	inv, err := c.repo.GetInvitation(ctx, eventID, userID)
+	if errors.Is(err, domain.ErrInvitationNotFound) {
+		return domain.Invitation{}, err
+	}
+	if err != nil {
+		return domain.Invitation{}, fmt.Errorf("get invitation: %w", err)
+	}
+
+	inv.Status = status
+	inv.UpdatedAt = time.Now().UTC()
+	if err := c.repo.SaveInvitation(ctx, inv); err != nil {
+		return domain.Invitation{}, fmt.Errorf("save invitation: %w", err)
+	}
+
+	return inv, nil
+}
+
+// Invitations - приглашения пользователя
+func (c *Calendar) Invitations(ctx context.Context, userID int64) ([]domain.Invitation, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	invitations, err := c.repo.ListUserInvitations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// Attendees - приглашенные на событие и их ответы
+func (c *Calendar) Attendees(ctx context.Context, userID, eventID int64) ([]domain.Invitation, error) {
+	if _, err := c.accessibleEvent(ctx, userID, eventID, domain.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	invitations, err := c.repo.ListInvitations(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// ownCalendar - календарь, если пользователь - его владелец
+func (c *Calendar) ownCalendar(ctx context.Context, userID, calendarID int64) (domain.Calendar, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.Calendar{}, err
+	}
+
+	cal, err := c.repo.GetCalendar(ctx, calendarID)
+	if errors.Is(err, domain.ErrCalendarNotFound) {
+		return domain.Calendar{}, err
+	}
+	if err != nil {
+		return domain.Calendar{}, fmt.Errorf("get calendar: %w", err)
+	}
+	if cal.OwnerID != userID {
+		return domain.Calendar{}, domain.ErrCalendarNotFound
+	}
+
+	return cal, nil
+}
+
+// writableCalendar - календарь, если пользователь может добавлять в него события
+func (c *Calendar) writableCalendar(ctx context.Context, userID, calendarID int64) (domain.Calendar, error) {
+	cal, err := c.repo.GetCalendar(ctx, calendarID)
+	if errors.Is(err, domain.ErrCalendarNotFound) {
+		return domain.Calendar{}, err
+	}
+	if err != nil {
+		return domain.Calendar{}, fmt.Errorf("get calendar: %w", err)
+	}
+	if cal.OwnerID == userID {
+		return cal, nil
+	}
+
+	sh, err := c.repo.GetShare(ctx, calendarID, userID)
+	if errors.Is(err, domain.ErrNotShared) {
+		return domain.Calendar{}, domain.ErrCalendarNotFound
+	}
+	if err != nil {
+		return domain.Calendar{}, fmt.Errorf("get share: %w", err)
+	}
+	if !sh.Permission.Allows(domain.PermissionWrite) {
+		return domain.Calendar{}, domain.ErrForbidden
+	}
+
+	return cal, nil
+}
+
+// eventPermission - право пользователя на событие; пустое, если событие ему не видно.
+// Владелец может все, доступ к календарю дает его право, приглашение - чтение.
+func (c *Calendar) eventPermission(ctx context.Context, userID int64, e domain.Event) (domain.Permission, error) {
+	if e.UserID == userID {
+		return domain.PermissionWrite, nil
+	}
+
+	if e.CalendarID != 0 {
+		sh, err := c.repo.GetShare(ctx, e.CalendarID, userID)
+		if err == nil {
+			return sh.Permission, nil
+		}
+		if !errors.Is(err, domain.ErrNotShared) {
+			return "", fmt.Errorf("get share: %w", err)
+		}
+	}
+
+	// приглашение на серию распространяется на ее выделенные вхождения
+	for _, id := range []int64{e.ID, e.SeriesID} {
+		if id == 0 {
+			continue
+		}
+		_, err := c.repo.GetInvitation(ctx, id, userID)
+		if err == nil {
+			return domain.PermissionRead, nil
+		}
+		if !errors.Is(err, domain.ErrInvitationNotFound) {
+			return "", fmt.Errorf("get invitation: %w", err)
+		}
+	}
+
+	return "", nil
+}
+
+// sharedEvents - добавляет события календарей, открытых пользователю
+func (c *Calendar) sharedEvents(ctx context.Context, m *merger, userID int64, from, to time.Time) error {
+	shares, err := c.repo.ListShares(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list shares: %w", err)
+	}
+
+	for _, sh := range shares {
+		listed, err := c.repo.ListByCalendar(ctx, sh.CalendarID, from, to)
+		if err != nil {
+			return fmt.Errorf("list calendar events: %w", err)
+		}
+		series, err := c.repo.ListRecurringByCalendar(ctx, sh.CalendarID, to)
+		if err != nil {
+			return fmt.Errorf("list calendar recurring events: %w", err)
+		}
+		if err := m.add(listed, series); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// invitedEvents - добавляет события, на которые пользователь приглашен и не отказался
+func (c *Calendar) invitedEvents(ctx context.Context, m *merger, userID int64) error {
+	invitations, err := c.repo.ListUserInvitations(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list invitations: %w", err)
+	}
+
+	for _, inv := range invitations {
+		if inv.Status == domain.RSVPDeclined {
+			continue
+		}
+
+		e, err := c.repo.Get(ctx, inv.EventID)
+		if errors.Is(err, domain.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("get event: %w", err)
+		}
+
+		if !e.Recurring() {
+			if err := m.add([]domain.Event{e}, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		exceptions, err := c.repo.ListBySeries(ctx, e.ID)
+		if err != nil {
+			return fmt.Errorf("list series exceptions: %w", err)
+		}
+		if err := m.add(exceptions, []domain.Event{e}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// acceptedEvents - события, приглашение на которые пользователь принял;
+// серии разворачиваются во вхождения, пересекающиеся с интервалом (from, to)
+func (c *Calendar) acceptedEvents(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	invitations, err := c.repo.ListUserInvitations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+
+	var events []domain.Event
+	for _, inv := range invitations {
+		if inv.Status != domain.RSVPAccepted {
+			continue
+		}
+
+		e, err := c.repo.Get(ctx, inv.EventID)
+		if errors.Is(err, domain.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get event: %w", err)
+		}
+		if !e.Recurring() {
+			events = append(events, e)
+			continue
+		}
+
+		exceptions, err := c.repo.ListBySeries(ctx, e.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list series exceptions: %w", err)
+		}
+		occ, err := overlapping(e, from, to)
+		if err != nil {
+			return nil, err
+		}
+		events = append(append(events, exceptions...), occ...)
+	}
+
+	return events, nil
+}
+
+// merger - собирает события из разных источников без повторов
+type merger struct {
+	from, to time.Time
+	seen     map[occurrenceKey]bool
+	list     []domain.Event
+}
+
+// occurrenceKey - событие или вхождение серии
+type occurrenceKey struct {
+	id           int64
+	recurrenceID int64
+}
+
+func newMerger(from, to time.Time) *merger {
+	return &merger{from: from, to: to, seen: make(map[occurrenceKey]bool)}
+}
+
+// add - добавляет разовые события из listed, попадающие в интервал, и вхождения серий
+func (m *merger) add(listed, series []domain.Event) error {
+	for _, e := range listed {
+		// серии разворачиваются ниже
+		if !e.Recurring() && !e.Start.Before(m.from) && e.Start.Before(m.to) {
+			m.push(e)
+		}
+	}
+
+	for _, s := range series {
+		occurrences, err := recurrence.Occurrences(s, m.from, m.to)
+		if err != nil {
+			return err
+		}
+		for _, occ := range occurrences {
+			m.push(occ)
+		}
+	}
+
+	return nil
+}
+
+func (m *merger) push(e domain.Event) {
+	key := occurrenceKey{id: e.ID}
+	if !e.RecurrenceID.IsZero() {
+		key.recurrenceID = e.RecurrenceID.UnixNano()
+	}
+	if m.seen[key] {
+		return
+	}
+	m.seen[key] = true
+	m.list = append(m.list, e)
+}
+
+// events - собранные события по возрастанию начала
+func (m *merger) events() []domain.Event {
+	events := m.list
+	if events == nil {
+		events = make([]domain.Event, 0)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Start.Equal(events[j].Start) {
+			return events[i].ID < events[j].ID
+		}
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	return events
+}
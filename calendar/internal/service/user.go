@@ -0,0 +1,58 @@
+package service
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// User - настройки пользователя; если они не сохранены - зона UTC
+func (c *Calendar) User(ctx context.Context, userID int64) (domain.User, error) {
+	if err := checkUser(ctx, userID); err != nil {
+		return domain.User{}, err
+	}
+	return c.user(ctx, userID)
+}
+
+// user - настройки пользователя без проверки, что запрос сделал сам пользователь
+func (c *Calendar) user(ctx context.Context, userID int64) (domain.User, error) {
+	u, err := c.repo.GetUser(ctx, userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.User{ID: userID, TimeZone: "UTC"}, nil
+	}
+	if err != nil {
+		return domain.User{}, fmt.Errorf("get user: %w", err)
+	}
+
+	return u, nil
+}
+
+// UserLocation - зона пользователя
+func (c *Calendar) UserLocation(ctx context.Context, userID int64) (*time.Location, error) {
+	u, err := c.User(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.LoadLocation(u.TimeZone)
+}
+
+// SetUserTimeZone - сохраняет IANA зону пользователя
+func (c *Calendar) SetUserTimeZone(ctx context.Context, userID int64, timeZone string) (domain.User, error) {
+	if _, err := domain.LoadLocation(timeZone); err != nil || timeZone == "" {
+		return domain.User{}, domain.ErrInvalidTimeZone
+	}
+
+	u, err := c.User(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	u.TimeZone = timeZone
+	if err := c.repo.SaveUser(ctx, u); err != nil {
+		return domain.User{}, fmt.Errorf("save user: %w", err)
+	}
+
+	return u, nil
+}
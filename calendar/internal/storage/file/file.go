@@ -0,0 +1,464 @@
+package file
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/storage/memory"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+	opUser   = "user"
+	// API ключи
+	opKey       = "api_key"
+	opKeyDelete = "api_key_delete"
+	// календари, доступы и приглашения
+	opCalendar   = "calendar"
+	opShare      = "share"
+	opUnshare    = "unshare"
+	opInvitation = "invitation"
+	// корзина и журнал изменений
+	opRestore = "restore"
+	opPurge   = "purge"
+	opAudit   = "audit"
+	opDiscard = "discard"
+)
+
+// record - запись журнала изменений
+type record struct {
+	Op    string       `json:"op"`
+	Event domain.Event `json:"event,omitzero"`
+	User  domain.User  `json:"user,omitzero"`
+	// у ключа в журнал пишется и хэш, иначе его не восстановить
+	APIKey apiKey `json:"api_key,omitzero"`
+
+	Calendar   domain.Calendar   `json:"calendar,omitzero"`
+	Share      domain.Share      `json:"share,omitzero"`
+	Invitation domain.Invitation `json:"invitation,omitzero"`
+
+	// время удаления события или граница окончательного удаления
+	At time.Time `json:"at,omitzero"`
+	// запись журнала изменений; у create, update, delete и restore пишется в той же строке,
+	// что и само изменение, и отдельно от него не теряется
+	Audit domain.AuditEntry `json:"audit,omitzero"`
+	// записи журнала выделенных вхождений, удаленных или восстановленных вместе с серией
+	Occurrences []domain.AuditEntry `json:"occurrences,omitempty"`
+}
+
+// apiKey - API ключ в журнале (domain.APIKey не сериализует хэш)
+type apiKey struct {
+	domain.APIKey
+	Hash string `json:"hash"`
+}
+
+// Storage - хранилище событий в виде журнала JSON-строк.
+// Состояние держится в памяти и восстанавливается из журнала при открытии.
+type Storage struct {
+	mu  sync.Mutex
+	f   *os.File
+	mem *memory.Storage
+}
+
+// Open - открывает (или создает) журнал и восстанавливает из него состояние
+func Open(path string) (*Storage, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	s := &Storage{f: f, mem: memory.New()}
+	if err := s.replay(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("replay %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// replay - применяет записи журнала к состоянию в памяти
+func (s *Storage) replay() error {
+	ctx := context.Background()
+	reader := bufio.NewReader(s.f)
+
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// недописанную последнюю строку (например, после сбоя) отрезаем,
+			// чтобы следующие записи не склеились с ней
+			if len(data) > 0 {
+				return s.f.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		switch rec.Op {
+		case opCreate, opUpdate:
+			// в журналах до появления версий ее нет: такие события считаются первой версией
+			if rec.Event.Version == 0 {
+				rec.Event.Version = 1
+			}
+			err = s.mem.Put(ctx, rec.Event)
+		case opDelete:
+			// в старых журналах время удаления не пишется - такие события удаляются при первой очистке
+			err = s.mem.DeleteAt(ctx, rec.Event.ID, 0, rec.At)
+			for _, a := range rec.Occurrences {
+				err = errors.Join(err, s.mem.DeleteAt(ctx, a.EventID, 0, rec.At))
+			}
+		case opRestore:
+			err = s.mem.Untrash(ctx, rec.Event.ID)
+			for _, a := range rec.Occurrences {
+				err = errors.Join(err, s.mem.Untrash(ctx, a.EventID))
+			}
+		case opDiscard:
+			err = s.mem.Discard(ctx, rec.Event.ID)
+		case opPurge:
+			_, err = s.mem.PurgeDeleted(ctx, rec.At)
+		case opAudit:
+			err = s.mem.PutAudit(ctx, rec.Audit)
+		case opUser:
+			err = s.mem.SaveUser(ctx, rec.User)
+		case opKey:
+			key := rec.APIKey.APIKey
+			key.Hash = rec.APIKey.Hash
+			err = s.mem.SaveAPIKey(ctx, key)
+		case opKeyDelete:
+			err = s.mem.DeleteAPIKey(ctx, rec.APIKey.ID)
+		case opCalendar:
+			err = s.mem.PutCalendar(ctx, rec.Calendar)
+		case opShare:
+			err = s.mem.SaveShare(ctx, rec.Share)
+		case opUnshare:
+			err = s.mem.DeleteShare(ctx, rec.Share.CalendarID, rec.Share.UserID)
+		case opInvitation:
+			err = s.mem.SaveInvitation(ctx, rec.Invitation)
+		default:
+			err = fmt.Errorf("unknown op %q", rec.Op)
+		}
+		// в старых журналах запись журнала изменений пишется отдельной строкой opAudit
+		if err == nil && rec.Op != opAudit && rec.Audit.ID != 0 {
+			err = s.mem.PutAudit(ctx, rec.Audit)
+		}
+		for _, a := range rec.Occurrences {
+			err = errors.Join(err, s.mem.PutAudit(ctx, a))
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// append - дописывает запись в журнал
+func (s *Storage) append(rec record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal record: %w", err)
+	}
+
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write record: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	return nil
+}
+
+// Create - сохраняет новое событие вместе с записью журнала a
+func (s *Storage) Create(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, a, err := s.mem.CreateAudited(ctx, e, a)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	if err := s.append(record{Op: opCreate, Event: e, Audit: a}); err != nil {
+		_ = s.mem.Discard(ctx, e.ID)
+		return domain.Event{}, err
+	}
+
+	return e, nil
+}
+
+// Get - возвращает событие по идентификатору
+func (s *Storage) Get(ctx context.Context, id int64) (domain.Event, error) {
+	return s.mem.Get(ctx, id)
+}
+
+// Update - заменяет существующее событие, если его версия равна e.Version, и увеличивает версию;
+// вместе с событием сохраняется запись журнала a
+func (s *Storage) Update(ctx context.Context, e domain.Event, a domain.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.mem.Get(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	a, err = s.mem.UpdateAudited(ctx, e, a)
+	if err != nil {
+		return err
+	}
+	// в журнал пишется уже новая версия: при восстановлении событие кладется как есть
+	if err := s.append(record{Op: opUpdate, Event: *a.After, Audit: a}); err != nil {
+		_ = s.mem.Put(ctx, old)
+		s.mem.DeleteAudit(ctx, a)
+		return err
+	}
+
+	return nil
+}
+
+// Delete - переносит событие в корзину, если его версия равна version (0 - любая),
+// вместе с записью журнала a; выделенные вхождения серии удаляются вместе с ней
+func (s *Storage) Delete(ctx context.Context, id, version int64, a domain.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.mem.DeleteAudited(ctx, id, version, a)
+	if err != nil {
+		return err
+	}
+	rec := record{Op: opDelete, Event: domain.Event{ID: id}, At: a.At, Audit: entries[0], Occurrences: entries[1:]}
+	if err := s.append(rec); err != nil {
+		for _, a := range entries {
+			_ = s.mem.Untrash(ctx, a.EventID)
+			s.mem.DeleteAudit(ctx, a)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Deleted - событие из корзины и время его удаления
+func (s *Storage) Deleted(ctx context.Context, id int64) (domain.Event, time.Time, error) {
+	return s.mem.Deleted(ctx, id)
+}
+
+// Restore - возвращает событие из корзины вместе с записью журнала a;
+// выделенные вхождения, удаленные вместе с серией, возвращаются с ней
+func (s *Storage) Restore(ctx context.Context, id int64, a domain.AuditEntry) (domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, at, err := s.mem.Deleted(ctx, id)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	e, entries, err := s.mem.RestoreAudited(ctx, id, a)
+	if err != nil {
+		return domain.Event{}, err
+	}
+	rec := record{Op: opRestore, Event: domain.Event{ID: id}, Audit: entries[0], Occurrences: entries[1:]}
+	if err := s.append(rec); err != nil {
+		for _, a := range entries {
+			_ = s.mem.DeleteAt(ctx, a.EventID, 0, at)
+			s.mem.DeleteAudit(ctx, a)
+		}
+		return domain.Event{}, err
+	}
+
+	return e, nil
+}
+
+// Discard - удаляет событие безвозвратно, минуя корзину, вместе с журналом изменений
+func (s *Storage) Discard(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.mem.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	entries, err := s.mem.ListAudit(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.Discard(ctx, id); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opDiscard, Event: domain.Event{ID: id}}); err != nil {
+		_ = s.mem.Put(ctx, e)
+		for _, a := range entries {
+			_ = s.mem.PutAudit(ctx, a)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// PurgeDeleted - окончательно удаляет события, удаленные раньше before
+func (s *Storage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.mem.PurgeDeleted(ctx, before)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	// без записи в журнале события вернутся в корзину при открытии и удалятся повторно
+	if err := s.append(record{Op: opPurge, At: before}); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// ListAudit - записи журнала изменений события
+func (s *Storage) ListAudit(ctx context.Context, eventID int64) ([]domain.AuditEntry, error) {
+	return s.mem.ListAudit(ctx, eventID)
+}
+
+// ListByUser - события пользователя, начинающиеся в полуинтервале [from, to)
+func (s *Storage) ListByUser(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	return s.mem.ListByUser(ctx, userID, from, to)
+}
+
+// ListByCalendar - события календаря, начинающиеся в полуинтервале [from, to)
+func (s *Storage) ListByCalendar(ctx context.Context, calendarID int64, from, to time.Time) ([]domain.Event, error) {
+	return s.mem.ListByCalendar(ctx, calendarID, from, to)
+}
+
+// ListByUID - события пользователя с заданным UID
+func (s *Storage) ListByUID(ctx context.Context, userID int64, uid string) ([]domain.Event, error) {
+	return s.mem.ListByUID(ctx, userID, uid)
+}
+
+// ListRecurring - повторяющиеся серии пользователя, начинающиеся раньше to
+func (s *Storage) ListRecurring(ctx context.Context, userID int64, to time.Time) ([]domain.Event, error) {
+	return s.mem.ListRecurring(ctx, userID, to)
+}
+
+// ListBySeries - выделенные вхождения серии
+func (s *Storage) ListBySeries(ctx context.Context, seriesID int64) ([]domain.Event, error) {
+	return s.mem.ListBySeries(ctx, seriesID)
+}
+
+// ListRecurringByCalendar - повторяющиеся серии календаря, начинающиеся раньше to
+func (s *Storage) ListRecurringByCalendar(ctx context.Context, calendarID int64, to time.Time) ([]domain.Event, error) {
+	return s.mem.ListRecurringByCalendar(ctx, calendarID, to)
+}
+
+// Search - события, подходящие под фильтр, по возрастанию (Start, ID)
+func (s *Storage) Search(ctx context.Context, f domain.EventFilter) ([]domain.Event, error) {
+	return s.mem.Search(ctx, f)
+}
+
+// ListOverlapping - разовые события и выделенные вхождения пользователя, пересекающиеся с (from, to)
+func (s *Storage) ListOverlapping(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	return s.mem.ListOverlapping(ctx, userID, from, to)
+}
+
+// Reminders - события, напоминания о которых приходятся на полуинтервал [from, to)
+func (s *Storage) Reminders(ctx context.Context, from, to time.Time) ([]domain.Event, error) {
+	return s.mem.Reminders(ctx, from, to)
+}
+
+// GetUser - возвращает настройки пользователя
+func (s *Storage) GetUser(ctx context.Context, id int64) (domain.User, error) {
+	return s.mem.GetUser(ctx, id)
+}
+
+// SaveUser - создает или заменяет настройки пользователя
+func (s *Storage) SaveUser(ctx context.Context, u domain.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, oldErr := s.mem.GetUser(ctx, u.ID)
+	if err := s.mem.SaveUser(ctx, u); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opUser, User: u}); err != nil {
+		if oldErr == nil {
+			_ = s.mem.SaveUser(ctx, old)
+		} else {
+			s.mem.DeleteUser(ctx, u.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SaveAPIKey - сохраняет API ключ
+func (s *Storage) SaveAPIKey(ctx context.Context, k domain.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.mem.SaveAPIKey(ctx, k); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opKey, APIKey: apiKey{APIKey: k, Hash: k.Hash}}); err != nil {
+		_ = s.mem.DeleteAPIKey(ctx, k.ID)
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKey - возвращает API ключ по открытой части
+func (s *Storage) GetAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	return s.mem.GetAPIKey(ctx, id)
+}
+
+// DeleteAPIKey - удаляет API ключ
+func (s *Storage) DeleteAPIKey(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.mem.GetAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.DeleteAPIKey(ctx, id); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opKeyDelete, APIKey: apiKey{APIKey: domain.APIKey{ID: id}}}); err != nil {
+		_ = s.mem.SaveAPIKey(ctx, old)
+		return err
+	}
+
+	return nil
+}
+
+// Ping - проверяет, что журнал открыт и доступен
+func (s *Storage) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.f.Stat(); err != nil {
+		return fmt.Errorf("stat journal: %w", err)
+	}
+	return nil
+}
+
+// Close - закрывает журнал
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.f.Close()
+}
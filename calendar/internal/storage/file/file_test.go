@@ -0,0 +1,246 @@
+package file_test
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/storage/file"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	day := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	s, err := file.Open(path)
+	require.NoError(t, err)
+
+	first, err := s.Create(ctx, domain.Event{UserID: 1, Title: "standup", Start: day, End: day}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+	second, err := s.Create(ctx, domain.Event{UserID: 1, Title: "retro", Start: day, End: day, Tags: []string{"work"}}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+
+	first.Title = "daily standup"
+	first.Tags = []string{"daily", "work"}
+	require.NoError(t, s.Update(ctx, first, entry(domain.AuditUpdated)))
+	require.ErrorIs(t, s.Delete(ctx, second.ID, second.Version+1, entry(domain.AuditDeleted)), domain.ErrVersionMismatch)
+	require.NoError(t, s.Delete(ctx, second.ID, second.Version, entry(domain.AuditDeleted)))
+	require.NoError(t, s.SaveUser(ctx, domain.User{ID: 1, TimeZone: "Europe/Moscow"}))
+	key := domain.APIKey{ID: "key1", UserID: 1, Hash: "hash", CreatedAt: day}
+	require.NoError(t, s.SaveAPIKey(ctx, key))
+	require.NoError(t, s.SaveAPIKey(ctx, domain.APIKey{ID: "key2", UserID: 1, Hash: "hash2"}))
+	require.NoError(t, s.DeleteAPIKey(ctx, "key2"))
+	require.NoError(t, s.Close())
+
+	// имитируем недописанную при сбое строку
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"op":"create","ev`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = file.Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	events, err := s.ListByUser(ctx, 1, day.Add(-time.Hour), day.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "daily standup", events[0].Title)
+
+	_, err = s.Get(ctx, second.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// индекс тегов восстанавливается без удаленных событий
+	found, err := s.Search(ctx, domain.EventFilter{UserID: 1, Tags: []string{"work"}})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	require.Equal(t, first.ID, found[0].ID)
+
+	u, err := s.GetUser(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Moscow", u.TimeZone)
+
+	// хэш ключа переживает перезапуск
+	restored, err := s.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.Equal(t, key, restored)
+	_, err = s.GetAPIKey(ctx, "key2")
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+	// идентификаторы продолжаются после восстановления
+	third, err := s.Create(ctx, domain.Event{UserID: 1, Title: "demo", Start: day, End: day}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+	require.Greater(t, third.ID, second.ID)
+}
+
+func TestTrashReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	day := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	s, err := file.Open(path)
+	require.NoError(t, err)
+
+	restored, err := s.Create(ctx, domain.Event{UserID: 1, Title: "standup", Start: day, End: day}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+	deleted, err := s.Create(ctx, domain.Event{UserID: 1, Title: "retro", Start: day, End: day}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+	purged, err := s.Create(ctx, domain.Event{UserID: 1, Title: "demo", Start: day, End: day}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(ctx, restored.ID, 0, entry(domain.AuditDeleted)))
+	require.NoError(t, s.Delete(ctx, purged.ID, 0, entry(domain.AuditDeleted)))
+	_, err = s.Restore(ctx, restored.ID, entry(domain.AuditRestored))
+	require.NoError(t, err)
+	n, err := s.PurgeDeleted(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.NoError(t, s.Delete(ctx, deleted.ID, 0, entry(domain.AuditDeleted)))
+	require.NoError(t, s.Close())
+
+	s, err = file.Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	events, err := s.ListByUser(ctx, 1, day.Add(-time.Hour), day.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, restored.ID, events[0].ID)
+
+	// удаленное событие остается в корзине, окончательно удаленное - нет
+	_, err = s.Restore(ctx, purged.ID, entry(domain.AuditRestored))
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	_, at, err := s.Deleted(ctx, deleted.ID)
+	require.NoError(t, err)
+	require.False(t, at.IsZero())
+	e, err := s.Restore(ctx, deleted.ID, entry(domain.AuditRestored))
+	require.NoError(t, err)
+	require.Equal(t, "retro", e.Title)
+
+	// записи журнала изменений пишутся в тех же строках, что и изменения
+	entries, err := s.ListAudit(ctx, deleted.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, domain.AuditDeleted, entries[1].Action)
+	require.Equal(t, "retro", entries[1].Before.Title)
+	require.Equal(t, domain.AuditRestored, entries[2].Action)
+	require.Greater(t, entries[2].ID, entries[1].ID)
+
+	entries, err = s.ListAudit(ctx, restored.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, restored.ID, entries[2].After.ID)
+
+	// безвозвратно удаленное событие не возвращается и после перезапуска
+	require.NoError(t, s.Discard(ctx, restored.ID))
+	require.NoError(t, s.Close())
+	s, err = file.Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+	_, err = s.Get(ctx, restored.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+	entries, err = s.ListAudit(ctx, restored.ID)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+// entry - запись журнала изменений от пользователя 1
+func entry(action domain.AuditAction) domain.AuditEntry {
+	return domain.AuditEntry{ActorID: 1, Action: action, At: time.Now().UTC()}
+}
+
+func TestLegacyJournalVersion(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	// журнал, записанный до появления версий событий
+	line := `{"op":"create","event":{"id":1,"user_id":1,"title":"standup","start":"2025-01-15T10:00:00Z","end":"2025-01-15T11:00:00Z"}}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+
+	s, err := file.Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	e, err := s.Get(ctx, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, e.Version)
+
+	e.Title = "daily standup"
+	require.NoError(t, s.Update(ctx, e, entry(domain.AuditUpdated)))
+}
+
+func TestLegacyJournalRemindBefore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	// журнал, в котором напоминание записано числом наносекунд
+	line := `{"op":"create","event":{"id":1,"user_id":1,"title":"standup","start":"2025-01-15T10:00:00Z","end":"2025-01-15T11:00:00Z","remind_before":900000000000,"version":1}}` + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(line), 0644))
+
+	s, err := file.Open(path)
+	require.NoError(t, err)
+
+	e, err := s.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Minute, e.RemindBefore)
+
+	e.RemindBefore = time.Hour
+	require.NoError(t, s.Update(ctx, e, entry(domain.AuditUpdated)))
+	require.NoError(t, s.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"remind_before":"1h"`)
+
+	s, err = file.Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	e, err = s.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, e.RemindBefore)
+}
+
+func TestSeriesTrashReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	day := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	s, err := file.Open(path)
+	require.NoError(t, err)
+
+	series, err := s.Create(ctx, domain.Event{UserID: 1, Title: "standup", Start: day, End: day, RRule: "FREQ=DAILY"}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+	occ, err := s.Create(ctx, domain.Event{UserID: 1, Title: "moved", Start: day.Add(25 * time.Hour), End: day.Add(25 * time.Hour),
+		SeriesID: series.ID, RecurrenceID: day.AddDate(0, 0, 1)}, entry(domain.AuditCreated))
+	require.NoError(t, err)
+
+	// выделенное вхождение удаляется вместе с серией и после перезапуска
+	require.NoError(t, s.Delete(ctx, series.ID, 0, entry(domain.AuditDeleted)))
+	require.NoError(t, s.Close())
+	s, err = file.Open(path)
+	require.NoError(t, err)
+	_, err = s.Get(ctx, occ.ID)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	_, err = s.Restore(ctx, series.ID, entry(domain.AuditRestored))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	s, err = file.Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	restored, err := s.Get(ctx, occ.ID)
+	require.NoError(t, err)
+	require.Equal(t, series.ID, restored.SeriesID)
+	entries, err := s.ListAudit(ctx, occ.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, domain.AuditRestored, entries[2].Action)
+}
@@ -0,0 +1,146 @@
+package file
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"errors"
+)
+
+// CreateCalendar - сохраняет новый календарь
+func (s *Storage) CreateCalendar(ctx context.Context, c domain.Calendar) (domain.Calendar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.mem.CreateCalendar(ctx, c)
+	if err != nil {
+		return domain.Calendar{}, err
+	}
+	if err := s.append(record{Op: opCalendar, Calendar: c}); err != nil {
+		_ = s.mem.DeleteCalendar(ctx, c.ID)
+		return domain.Calendar{}, err
+	}
+
+	return c, nil
+}
+
+// UpdateCalendar - заменяет существующий календарь
+func (s *Storage) UpdateCalendar(ctx context.Context, c domain.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.mem.GetCalendar(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.UpdateCalendar(ctx, c); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opCalendar, Calendar: c}); err != nil {
+		_ = s.mem.UpdateCalendar(ctx, old)
+		return err
+	}
+
+	return nil
+}
+
+// GetCalendar - возвращает календарь по идентификатору
+func (s *Storage) GetCalendar(ctx context.Context, id int64) (domain.Calendar, error) {
+	return s.mem.GetCalendar(ctx, id)
+}
+
+// ListCalendars - календари пользователя
+func (s *Storage) ListCalendars(ctx context.Context, ownerID int64) ([]domain.Calendar, error) {
+	return s.mem.ListCalendars(ctx, ownerID)
+}
+
+// SaveShare - открывает календарь пользователю или меняет его право
+func (s *Storage) SaveShare(ctx context.Context, sh domain.Share) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, oldErr := s.mem.GetShare(ctx, sh.CalendarID, sh.UserID)
+	if err := s.mem.SaveShare(ctx, sh); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opShare, Share: sh}); err != nil {
+		if errors.Is(oldErr, domain.ErrNotShared) {
+			_ = s.mem.DeleteShare(ctx, sh.CalendarID, sh.UserID)
+		} else {
+			_ = s.mem.SaveShare(ctx, old)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetShare - доступ пользователя к календарю
+func (s *Storage) GetShare(ctx context.Context, calendarID, userID int64) (domain.Share, error) {
+	return s.mem.GetShare(ctx, calendarID, userID)
+}
+
+// DeleteShare - закрывает календарь для пользователя
+func (s *Storage) DeleteShare(ctx context.Context, calendarID, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.mem.GetShare(ctx, calendarID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.DeleteShare(ctx, calendarID, userID); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opUnshare, Share: old}); err != nil {
+		_ = s.mem.SaveShare(ctx, old)
+		return err
+	}
+
+	return nil
+}
+
+// ListShares - календари, открытые пользователю
+func (s *Storage) ListShares(ctx context.Context, userID int64) ([]domain.Share, error) {
+	return s.mem.ListShares(ctx, userID)
+}
+
+// ListCalendarShares - пользователи, которым открыт календарь
+func (s *Storage) ListCalendarShares(ctx context.Context, calendarID int64) ([]domain.Share, error) {
+	return s.mem.ListCalendarShares(ctx, calendarID)
+}
+
+// SaveInvitation - создает или заменяет приглашение
+func (s *Storage) SaveInvitation(ctx context.Context, inv domain.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, oldErr := s.mem.GetInvitation(ctx, inv.EventID, inv.UserID)
+	if err := s.mem.SaveInvitation(ctx, inv); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opInvitation, Invitation: inv}); err != nil {
+		if oldErr == nil {
+			_ = s.mem.SaveInvitation(ctx, old)
+		} else {
+			s.mem.DeleteInvitation(ctx, inv.EventID, inv.UserID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetInvitation - приглашение пользователя на событие
+func (s *Storage) GetInvitation(ctx context.Context, eventID, userID int64) (domain.Invitation, error) {
+	return s.mem.GetInvitation(ctx, eventID, userID)
+}
+
+// ListInvitations - приглашения на событие
+func (s *Storage) ListInvitations(ctx context.Context, eventID int64) ([]domain.Invitation, error) {
+	return s.mem.ListInvitations(ctx, eventID)
+}
+
+// ListUserInvitations - приглашения пользователя
+func (s *Storage) ListUserInvitations(ctx context.Context, userID int64) ([]domain.Invitation, error) {
+	return s.mem.ListUserInvitations(ctx, userID)
+}
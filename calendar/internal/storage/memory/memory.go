@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Storage - потокобезопасное хранилище событий в памяти
+type Storage struct {
+	mu     sync.RWMutex
+	events map[int64]domain.Event
+	users  map[int64]domain.User
+	keys   map[string]domain.APIKey
+	lastID int64
+	// индекс событий по тегам для поиска
+	tags map[string]map[int64]struct{}
+	// индекс интервалов разовых событий по пользователям для поиска пересечений
+	periods map[int64]*intervals
+	// удаленные события до окончательного удаления
+	trash map[int64]trashed
+	// журнал изменений по событиям
+	audit       map[int64][]domain.AuditEntry
+	lastAuditID int64
+
+	calendars      map[int64]domain.Calendar
+	lastCalendarID int64
+	shares         map[memberKey]domain.Share
+	invitations    map[memberKey]domain.Invitation
+}
+
+// New - конструктор
+func New() *Storage {
+	return &Storage{
+		events:  make(map[int64]domain.Event),
+		users:   make(map[int64]domain.User),
+		keys:    make(map[string]domain.APIKey),
+		tags:    make(map[string]map[int64]struct{}),
+		periods: make(map[int64]*intervals),
+		trash:   make(map[int64]trashed),
+		audit:   make(map[int64][]domain.AuditEntry),
+
+		calendars:   make(map[int64]domain.Calendar),
+		shares:      make(map[memberKey]domain.Share),
+		invitations: make(map[memberKey]domain.Invitation),
+	}
+}
+
+// Create - сохраняет новое событие вместе с записью журнала a, присваивая им идентификаторы
+func (s *Storage) Create(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.Event, error) {
+	e, _, err := s.CreateAudited(ctx, e, a)
+	return e, err
+}
+
+// CreateAudited - как Create, но возвращает и сохраненную запись журнала
+func (s *Storage) CreateAudited(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.Event, domain.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	e.ID = s.lastID
+	e.Version = 1
+	s.events[e.ID] = e
+	s.index(e)
+	a.EventID, a.After = e.ID, &e
+
+	return e, s.appendAudit(a), nil
+}
+
+// Put - сохраняет событие с уже назначенным идентификатором
+func (s *Storage) Put(ctx context.Context, e domain.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.events[e.ID]; ok {
+		s.unindex(old)
+	}
+	s.events[e.ID] = e
+	s.index(e)
+	s.lastID = max(s.lastID, e.ID)
+
+	return nil
+}
+
+// Get - возвращает событие по идентификатору
+func (s *Storage) Get(ctx context.Context, id int64) (domain.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.events[id]
+	if !ok {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	return e, nil
+}
+
+// Update - заменяет существующее событие, если его версия равна e.Version, и увеличивает версию;
+// вместе с событием сохраняется запись журнала a
+func (s *Storage) Update(ctx context.Context, e domain.Event, a domain.AuditEntry) error {
+	_, err := s.UpdateAudited(ctx, e, a)
+	return err
+}
+
+// UpdateAudited - как Update, но возвращает и сохраненную запись журнала
+func (s *Storage) UpdateAudited(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.events[e.ID]
+	if !ok {
+		return domain.AuditEntry{}, domain.ErrEventNotFound
+	}
+	if old.Version != e.Version {
+		return domain.AuditEntry{}, domain.ErrVersionMismatch
+	}
+	e.Version++
+	s.unindex(old)
+	s.events[e.ID] = e
+	s.index(e)
+	a.EventID, a.After = e.ID, &e
+
+	return s.appendAudit(a), nil
+}
+
+// ListByUser - события пользователя, начинающиеся в полуинтервале [from, to), по возрастанию начала
+func (s *Storage) ListByUser(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	return s.listBetween(func(e domain.Event) bool { return e.UserID == userID }, from, to), nil
+}
+
+// ListByCalendar - события календаря, начинающиеся в полуинтервале [from, to), по возрастанию начала
+func (s *Storage) ListByCalendar(ctx context.Context, calendarID int64, from, to time.Time) ([]domain.Event, error) {
+	return s.listBetween(func(e domain.Event) bool { return e.CalendarID == calendarID }, from, to), nil
+}
+
+// listBetween - подходящие события, начинающиеся в полуинтервале [from, to)
+func (s *Storage) listBetween(match func(domain.Event) bool, from, to time.Time) []domain.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]domain.Event, 0)
+	for _, e := range s.events {
+		if match(e) && !e.Start.Before(from) && e.Start.Before(to) {
+			events = append(events, e)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Start.Equal(events[j].Start) {
+			return events[i].ID < events[j].ID
+		}
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	return events
+}
+
+// ListByUID - события пользователя с заданным UID
+func (s *Storage) ListByUID(ctx context.Context, userID int64, uid string) ([]domain.Event, error) {
+	return s.list(func(e domain.Event) bool { return e.UserID == userID && e.UID == uid }), nil
+}
+
+// ListBySeries - выделенные вхождения серии
+func (s *Storage) ListBySeries(ctx context.Context, seriesID int64) ([]domain.Event, error) {
+	return s.list(func(e domain.Event) bool { return e.SeriesID == seriesID }), nil
+}
+
+// ListRecurring - повторяющиеся серии пользователя, начинающиеся раньше to
+func (s *Storage) ListRecurring(ctx context.Context, userID int64, to time.Time) ([]domain.Event, error) {
+	return s.list(func(e domain.Event) bool {
+		return e.UserID == userID && e.Recurring() && e.Start.Before(to)
+	}), nil
+}
+
+// ListRecurringByCalendar - повторяющиеся серии календаря, начинающиеся раньше to
+func (s *Storage) ListRecurringByCalendar(ctx context.Context, calendarID int64, to time.Time) ([]domain.Event, error) {
+	return s.list(func(e domain.Event) bool {
+		return e.CalendarID == calendarID && e.Recurring() && e.Start.Before(to)
+	}), nil
+}
+
+// list - подходящие события по возрастанию идентификатора
+func (s *Storage) list(match func(domain.Event) bool) []domain.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]domain.Event, 0)
+	for _, e := range s.events {
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	return events
+}
+
+// Reminders - события, напоминания о которых приходятся на полуинтервал [from, to);
+// у серий - вхождения, напоминания о которых попадают в полуинтервал
+func (s *Storage) Reminders(ctx context.Context, from, to time.Time) ([]domain.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// серия может напоминать о вхождениях еще долго после первого
+	candidates := make([]domain.Event, 0)
+	for _, e := range s.events {
+		if at, ok := e.RemindAt(); ok && at.Before(to) {
+			candidates = append(candidates, e)
+		}
+	}
+
+	return recurrence.Reminders(candidates, from, to)
+}
+
+// GetUser - возвращает настройки пользователя
+func (s *Storage) GetUser(ctx context.Context, id int64) (domain.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return u, nil
+}
+
+// SaveUser - создает или заменяет настройки пользователя
+func (s *Storage) SaveUser(ctx context.Context, u domain.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[u.ID] = u
+	return nil
+}
+
+// DeleteUser - удаляет настройки пользователя
+func (s *Storage) DeleteUser(ctx context.Context, id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, id)
+}
+
+// SaveAPIKey - сохраняет API ключ
+func (s *Storage) SaveAPIKey(ctx context.Context, k domain.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[k.ID] = k
+	return nil
+}
+
+// GetAPIKey - возвращает API ключ по открытой части
+func (s *Storage) GetAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.keys[id]
+	if !ok {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	return k, nil
+}
+
+// DeleteAPIKey - удаляет API ключ
+func (s *Storage) DeleteAPIKey(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[id]; !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+	delete(s.keys, id)
+	return nil
+}
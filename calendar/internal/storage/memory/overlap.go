@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"Calendar/internal/domain"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// period - интервал события в индексе пересечений
+type period struct {
+	start, end time.Time
+	id         int64
+}
+
+func comparePeriods(a, b period) int {
+	if c := a.start.Compare(b.start); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.id, b.id)
+}
+
+// intervals - интервалы событий пользователя по возрастанию (start, id).
+// maxLen - верхняя граница длительности: событие, начавшееся раньше from - maxLen,
+// закончилось до from, поэтому поиск пересечений - бинарный поиск и просмотр отрезка.
+type intervals struct {
+	periods []period
+	maxLen  time.Duration
+}
+
+func (iv *intervals) insert(p period) {
+	i, _ := slices.BinarySearchFunc(iv.periods, p, comparePeriods)
+	iv.periods = slices.Insert(iv.periods, i, p)
+	// после удаления длинного события граница не уменьшается - остается верной, но менее точной
+	iv.maxLen = max(iv.maxLen, p.end.Sub(p.start))
+}
+
+func (iv *intervals) remove(p period) {
+	if i, ok := slices.BinarySearchFunc(iv.periods, p, comparePeriods); ok {
+		iv.periods = slices.Delete(iv.periods, i, i+1)
+	}
+}
+
+// overlapping - идентификаторы событий, у которых start < to и end > from, по возрастанию (start, id)
+func (iv *intervals) overlapping(from, to time.Time) []int64 {
+	i, _ := slices.BinarySearchFunc(iv.periods, period{start: from.Add(-iv.maxLen)}, comparePeriods)
+
+	var ids []int64
+	for _, p := range iv.periods[i:] {
+		if !p.start.Before(to) {
+			break
+		}
+		if p.end.After(from) {
+			ids = append(ids, p.id)
+		}
+	}
+	return ids
+}
+
+// ListOverlapping - разовые события и выделенные вхождения пользователя, пересекающиеся
+// с интервалом (from, to): Start < to и End > from. По возрастанию начала.
+func (s *Storage) ListOverlapping(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]domain.Event, 0)
+	if iv, ok := s.periods[userID]; ok {
+		for _, id := range iv.overlapping(from, to) {
+			events = append(events, s.events[id])
+		}
+	}
+	return events, nil
+}
+
+// indexPeriod - добавляет разовое событие в индекс пересечений; вызывается под блокировкой.
+// Серии не индексируются: их вхождения разворачивает сервис.
+func (s *Storage) indexPeriod(e domain.Event) {
+	if e.Recurring() {
+		return
+	}
+	iv, ok := s.periods[e.UserID]
+	if !ok {
+		iv = &intervals{}
+		s.periods[e.UserID] = iv
+	}
+	iv.insert(period{start: e.Start, end: e.End, id: e.ID})
+}
+
+// unindexPeriod - убирает событие из индекса пересечений; вызывается под блокировкой
+func (s *Storage) unindexPeriod(e domain.Event) {
+	iv, ok := s.periods[e.UserID]
+	if e.Recurring() || !ok {
+		return
+	}
+	iv.remove(period{start: e.Start, end: e.End, id: e.ID})
+	if len(iv.periods) == 0 {
+		delete(s.periods, e.UserID)
+	}
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"sort"
+)
+
+// Search - события, подходящие под фильтр, по возрастанию (Start, ID), не больше f.Limit
+func (s *Storage) Search(ctx context.Context, f domain.EventFilter) ([]domain.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]domain.Event, 0)
+	match := func(e domain.Event) {
+		if f.Match(e) && (f.After == nil || f.After.Before(e)) {
+			events = append(events, e)
+		}
+	}
+
+	if len(f.Tags) > 0 {
+		// перебираем только события с самым редким тегом
+		ids := s.tags[f.Tags[0]]
+		for _, tag := range f.Tags[1:] {
+			if len(s.tags[tag]) < len(ids) {
+				ids = s.tags[tag]
+			}
+		}
+		for id := range ids {
+			match(s.events[id])
+		}
+	} else {
+		for _, e := range s.events {
+			match(e)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Start.Equal(events[j].Start) {
+			return events[i].ID < events[j].ID
+		}
+		return events[i].Start.Before(events[j].Start)
+	})
+	if f.Limit > 0 && len(events) > f.Limit {
+		events = events[:f.Limit]
+	}
+
+	return events, nil
+}
+
+// index - добавляет событие в индексы тегов и интервалов; вызывается под блокировкой
+func (s *Storage) index(e domain.Event) {
+	s.indexPeriod(e)
+	for _, tag := range e.Tags {
+		ids, ok := s.tags[tag]
+		if !ok {
+			ids = make(map[int64]struct{})
+			s.tags[tag] = ids
+		}
+		ids[e.ID] = struct{}{}
+	}
+}
+
+// unindex - убирает событие из индексов тегов и интервалов; вызывается под блокировкой
+func (s *Storage) unindex(e domain.Event) {
+	s.unindexPeriod(e)
+	for _, tag := range e.Tags {
+		delete(s.tags[tag], e.ID)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
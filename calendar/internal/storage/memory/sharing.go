@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"sort"
+)
+
+// memberKey - пара (календарь или событие, пользователь)
+type memberKey struct {
+	id     int64
+	userID int64
+}
+
+// CreateCalendar - сохраняет новый календарь, присваивая ему идентификатор
+func (s *Storage) CreateCalendar(ctx context.Context, c domain.Calendar) (domain.Calendar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastCalendarID++
+	c.ID = s.lastCalendarID
+	s.calendars[c.ID] = c
+
+	return c, nil
+}
+
+// PutCalendar - сохраняет календарь с уже назначенным идентификатором
+func (s *Storage) PutCalendar(ctx context.Context, c domain.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calendars[c.ID] = c
+	s.lastCalendarID = max(s.lastCalendarID, c.ID)
+
+	return nil
+}
+
+// UpdateCalendar - заменяет существующий календарь
+func (s *Storage) UpdateCalendar(ctx context.Context, c domain.Calendar) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.calendars[c.ID]; !ok {
+		return domain.ErrCalendarNotFound
+	}
+	s.calendars[c.ID] = c
+
+	return nil
+}
+
+// DeleteCalendar - удаляет календарь и доступы к нему
+func (s *Storage) DeleteCalendar(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.calendars[id]; !ok {
+		return domain.ErrCalendarNotFound
+	}
+	delete(s.calendars, id)
+	for key := range s.shares {
+		if key.id == id {
+			delete(s.shares, key)
+		}
+	}
+
+	return nil
+}
+
+// GetCalendar - возвращает календарь по идентификатору
+func (s *Storage) GetCalendar(ctx context.Context, id int64) (domain.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.calendars[id]
+	if !ok {
+		return domain.Calendar{}, domain.ErrCalendarNotFound
+	}
+	return c, nil
+}
+
+// ListCalendars - календари пользователя по возрастанию идентификатора
+func (s *Storage) ListCalendars(ctx context.Context, ownerID int64) ([]domain.Calendar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendars := make([]domain.Calendar, 0)
+	for _, c := range s.calendars {
+		if c.OwnerID == ownerID {
+			calendars = append(calendars, c)
+		}
+	}
+
+	sort.Slice(calendars, func(i, j int) bool {
+		return calendars[i].ID < calendars[j].ID
+	})
+
+	return calendars, nil
+}
+
+// SaveShare - открывает календарь пользователю или меняет его право
+func (s *Storage) SaveShare(ctx context.Context, sh domain.Share) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shares[memberKey{sh.CalendarID, sh.UserID}] = sh
+	return nil
+}
+
+// GetShare - доступ пользователя к календарю
+func (s *Storage) GetShare(ctx context.Context, calendarID, userID int64) (domain.Share, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sh, ok := s.shares[memberKey{calendarID, userID}]
+	if !ok {
+		return domain.Share{}, domain.ErrNotShared
+	}
+	return sh, nil
+}
+
+// DeleteShare - закрывает календарь для пользователя
+func (s *Storage) DeleteShare(ctx context.Context, calendarID, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memberKey{calendarID, userID}
+	if _, ok := s.shares[key]; !ok {
+		return domain.ErrNotShared
+	}
+	delete(s.shares, key)
+
+	return nil
+}
+
+// ListShares - календари, открытые пользователю, по возрастанию идентификатора календаря
+func (s *Storage) ListShares(ctx context.Context, userID int64) ([]domain.Share, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shares := make([]domain.Share, 0)
+	for _, sh := range s.shares {
+		if sh.UserID == userID {
+			shares = append(shares, sh)
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].CalendarID < shares[j].CalendarID
+	})
+
+	return shares, nil
+}
+
+// ListCalendarShares - пользователи, которым открыт календарь, по возрастанию идентификатора
+func (s *Storage) ListCalendarShares(ctx context.Context, calendarID int64) ([]domain.Share, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shares := make([]domain.Share, 0)
+	for _, sh := range s.shares {
+		if sh.CalendarID == calendarID {
+			shares = append(shares, sh)
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].UserID < shares[j].UserID
+	})
+
+	return shares, nil
+}
+
+// SaveInvitation - создает или заменяет приглашение
+func (s *Storage) SaveInvitation(ctx context.Context, inv domain.Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.invitations[memberKey{inv.EventID, inv.UserID}] = inv
+	return nil
+}
+
+// GetInvitation - приглашение пользователя на событие
+func (s *Storage) GetInvitation(ctx context.Context, eventID, userID int64) (domain.Invitation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inv, ok := s.invitations[memberKey{eventID, userID}]
+	if !ok {
+		return domain.Invitation{}, domain.ErrInvitationNotFound
+	}
+	return inv, nil
+}
+
+// ListInvitations - приглашения на событие по возрастанию идентификатора участника
+func (s *Storage) ListInvitations(ctx context.Context, eventID int64) ([]domain.Invitation, error) {
+	return s.listInvitations(func(inv domain.Invitation) bool { return inv.EventID == eventID }), nil
+}
+
+// ListUserInvitations - приглашения пользователя по возрастанию идентификатора события;
+// приглашения на удаленные события не возвращаются
+func (s *Storage) ListUserInvitations(ctx context.Context, userID int64) ([]domain.Invitation, error) {
+	return s.listInvitations(func(inv domain.Invitation) bool { return inv.UserID == userID }), nil
+}
+
+func (s *Storage) listInvitations(match func(domain.Invitation) bool) []domain.Invitation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invitations := make([]domain.Invitation, 0)
+	for _, inv := range s.invitations {
+		// до окончательного удаления события приглашения хранятся для восстановления
+		if _, deleted := s.trash[inv.EventID]; deleted {
+			continue
+		}
+		if match(inv) {
+			invitations = append(invitations, inv)
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool {
+		if invitations[i].EventID == invitations[j].EventID {
+			return invitations[i].UserID < invitations[j].UserID
+		}
+		return invitations[i].EventID < invitations[j].EventID
+	})
+
+	return invitations
+}
+
+// DeleteInvitation - удаляет приглашение
+func (s *Storage) DeleteInvitation(ctx context.Context, eventID, userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.invitations, memberKey{eventID, userID})
+}
@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"sort"
+	"time"
+)
+
+// trashed - удаленное событие и время удаления
+type trashed struct {
+	event domain.Event
+	at    time.Time
+}
+
+// Delete - переносит событие в корзину, если его версия равна version (0 - любая),
+// вместе с записью журнала a; время удаления - a.At. Выделенные вхождения серии
+// удаляются вместе с ней.
+func (s *Storage) Delete(ctx context.Context, id, version int64, a domain.AuditEntry) error {
+	_, err := s.DeleteAudited(ctx, id, version, a)
+	return err
+}
+
+// DeleteAudited - как Delete, но возвращает и сохраненные записи журнала:
+// первая - о самом событии, остальные - о выделенных вхождениях серии
+func (s *Storage) DeleteAudited(ctx context.Context, id, version int64, a domain.AuditEntry) ([]domain.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.trashEvent(id, version, a.At)
+	if err != nil {
+		return nil, err
+	}
+	a.EventID, a.Before = id, &e
+	entries := []domain.AuditEntry{s.appendAudit(a)}
+
+	if e.Recurring() {
+		for _, occ := range s.seriesEvents(id) {
+			_, _ = s.trashEvent(occ.ID, 0, a.At)
+			a.EventID, a.Before = occ.ID, &occ
+			entries = append(entries, s.appendAudit(a))
+		}
+	}
+
+	return entries, nil
+}
+
+// seriesEvents - выделенные вхождения серии по возрастанию идентификатора; вызывается под блокировкой
+func (s *Storage) seriesEvents(seriesID int64) []domain.Event {
+	var events []domain.Event
+	for _, e := range s.events {
+		if e.SeriesID == seriesID {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	return events
+}
+
+// DeleteAt - переносит событие в корзину с заданным временем удаления без записи в журнал,
+// если его версия равна version (0 - любая)
+func (s *Storage) DeleteAt(ctx context.Context, id, version int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.trashEvent(id, version, at)
+	return err
+}
+
+// trashEvent - переносит событие в корзину; вызывается под блокировкой
+func (s *Storage) trashEvent(id, version int64, at time.Time) (domain.Event, error) {
+	e, ok := s.events[id]
+	if !ok {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	if version != 0 && e.Version != version {
+		return domain.Event{}, domain.ErrVersionMismatch
+	}
+	s.unindex(e)
+	delete(s.events, id)
+	s.trash[id] = trashed{event: e, at: at}
+
+	return e, nil
+}
+
+// Deleted - событие из корзины и время его удаления
+func (s *Storage) Deleted(ctx context.Context, id int64) (domain.Event, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.trash[id]
+	if !ok {
+		return domain.Event{}, time.Time{}, domain.ErrEventNotFound
+	}
+	return t.event, t.at, nil
+}
+
+// Restore - возвращает событие из корзины вместе с записью журнала a.
+// Выделенные вхождения, удаленные вместе с серией, возвращаются с ней.
+func (s *Storage) Restore(ctx context.Context, id int64, a domain.AuditEntry) (domain.Event, error) {
+	e, _, err := s.RestoreAudited(ctx, id, a)
+	return e, err
+}
+
+// RestoreAudited - как Restore, но возвращает и сохраненные записи журнала:
+// первая - о самом событии, остальные - о выделенных вхождениях серии
+func (s *Storage) RestoreAudited(ctx context.Context, id int64, a domain.AuditEntry) (domain.Event, []domain.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.trash[id]
+	if !ok {
+		return domain.Event{}, nil, domain.ErrEventNotFound
+	}
+	e, _ := s.untrash(id)
+	a.EventID, a.After = id, &e
+	entries := []domain.AuditEntry{s.appendAudit(a)}
+
+	var occurrences []domain.Event
+	for _, o := range s.trash {
+		if o.event.SeriesID == id && o.at.Equal(t.at) {
+			occurrences = append(occurrences, o.event)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].ID < occurrences[j].ID })
+	for _, occ := range occurrences {
+		_, _ = s.untrash(occ.ID)
+		a.EventID, a.After = occ.ID, &occ
+		entries = append(entries, s.appendAudit(a))
+	}
+
+	return e, entries, nil
+}
+
+// Untrash - возвращает событие из корзины без записи в журнал
+func (s *Storage) Untrash(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.untrash(id)
+	return err
+}
+
+// untrash - возвращает событие из корзины; вызывается под блокировкой
+func (s *Storage) untrash(id int64) (domain.Event, error) {
+	t, ok := s.trash[id]
+	if !ok {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	delete(s.trash, id)
+	s.events[id] = t.event
+	s.index(t.event)
+
+	return t.event, nil
+}
+
+// Discard - удаляет событие безвозвратно, минуя корзину, вместе с приглашениями
+// и журналом изменений (откат незавершенной операции)
+func (s *Storage) Discard(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.events[id]
+	if !ok {
+		return domain.ErrEventNotFound
+	}
+	s.unindex(e)
+	delete(s.events, id)
+	for key := range s.invitations {
+		if key.id == id {
+			delete(s.invitations, key)
+		}
+	}
+	delete(s.audit, id)
+
+	return nil
+}
+
+// PurgeDeleted - окончательно удаляет события, удаленные раньше before, вместе с приглашениями
+func (s *Storage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, t := range s.trash {
+		if !t.at.Before(before) {
+			continue
+		}
+		delete(s.trash, id)
+		for key := range s.invitations {
+			if key.id == id {
+				delete(s.invitations, key)
+			}
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// appendAudit - сохраняет запись журнала, присваивая ей идентификатор; вызывается под блокировкой
+func (s *Storage) appendAudit(a domain.AuditEntry) domain.AuditEntry {
+	s.lastAuditID++
+	a.ID = s.lastAuditID
+	s.audit[a.EventID] = append(s.audit[a.EventID], a)
+
+	return a
+}
+
+// PutAudit - сохраняет запись журнала с уже назначенным идентификатором
+func (s *Storage) PutAudit(ctx context.Context, a domain.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit[a.EventID] = append(s.audit[a.EventID], a)
+	s.lastAuditID = max(s.lastAuditID, a.ID)
+
+	return nil
+}
+
+// DeleteAudit - убирает запись журнала (откат неудачной записи)
+func (s *Storage) DeleteAudit(ctx context.Context, a domain.AuditEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.audit[a.EventID]
+	for i := range entries {
+		if entries[i].ID == a.ID {
+			s.audit[a.EventID] = append(entries[:i:i], entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// ListAudit - записи журнала события по возрастанию идентификатора
+func (s *Storage) ListAudit(ctx context.Context, eventID int64) ([]domain.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append(make([]domain.AuditEntry, 0), s.audit[eventID]...), nil
+}
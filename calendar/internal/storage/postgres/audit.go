@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const auditColumns = `id, event_id, actor_id, action, at, before_event, after_event`
+
+// appendAudit - сохраняет запись журнала изменений; q - транзакция, в которой меняется событие
+func appendAudit(ctx context.Context, q querier, a domain.AuditEntry) error {
+	const query = `INSERT INTO event_audit (event_id, actor_id, action, at, before_event, after_event)
+VALUES ($1, $2, $3, $4, $5, $6)`
+
+	before, err := snapshotJSON(a.Before)
+	if err != nil {
+		return err
+	}
+	after, err := snapshotJSON(a.After)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.ExecContext(ctx, query, a.EventID, a.ActorID, a.Action, a.At, before, after); err != nil {
+		return fmt.Errorf("insert audit: %w", err)
+	}
+	return nil
+}
+
+// ListAudit - записи журнала изменений события по возрастанию идентификатора
+func (s *Storage) ListAudit(ctx context.Context, eventID int64) ([]domain.AuditEntry, error) {
+	const query = `SELECT ` + auditColumns + ` FROM event_audit WHERE event_id = $1 ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("select audit: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		var (
+			a             domain.AuditEntry
+			before, after []byte
+		)
+		if err := rows.Scan(&a.ID, &a.EventID, &a.ActorID, &a.Action, &a.At, &before, &after); err != nil {
+			return nil, fmt.Errorf("scan audit: %w", err)
+		}
+		if a.Before, err = parseSnapshot(before); err != nil {
+			return nil, err
+		}
+		if a.After, err = parseSnapshot(after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select audit: %w", err)
+	}
+
+	return entries, nil
+}
+
+// snapshotJSON - состояние события для колонки JSONB; nil - NULL
+func snapshotJSON(e *domain.Event) (any, error) {
+	if e == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("encode snapshot: %w", err)
+	}
+	return string(data), nil
+}
+
+func parseSnapshot(data []byte) (*domain.Event, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var e domain.Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return &e, nil
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS events (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT      NOT NULL,
+    title       TEXT        NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    start_at    TIMESTAMPTZ NOT NULL,
+    end_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS events_user_start_idx ON events (user_id, start_at);
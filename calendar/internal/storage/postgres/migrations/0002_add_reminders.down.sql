@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS events_remind_at_idx;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS remind_at,
+    DROP COLUMN IF EXISTS remind_before;
@@ -0,0 +1,5 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS remind_before BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS remind_at     TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS events_remind_at_idx ON events (remind_at) WHERE remind_at IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS events_user_recurring_idx;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS series_id,
+    DROP COLUMN IF EXISTS recurrence_id,
+    DROP COLUMN IF EXISTS exdates,
+    DROP COLUMN IF EXISTS rrule;
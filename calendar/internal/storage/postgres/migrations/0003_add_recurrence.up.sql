@@ -0,0 +1,7 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS rrule         TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS exdates       JSONB  NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS recurrence_id TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS series_id     BIGINT;
+
+CREATE INDEX IF NOT EXISTS events_user_recurring_idx ON events (user_id, start_at) WHERE rrule <> '';
@@ -0,0 +1,3 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS uid TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS events_user_uid_idx ON events (user_id, uid) WHERE uid <> '';
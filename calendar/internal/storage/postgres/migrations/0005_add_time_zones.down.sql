@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS users;
+
+ALTER TABLE events DROP COLUMN IF EXISTS time_zone;
@@ -0,0 +1,6 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS time_zone TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS users (
+    id        BIGINT PRIMARY KEY,
+    time_zone TEXT NOT NULL DEFAULT 'UTC'
+);
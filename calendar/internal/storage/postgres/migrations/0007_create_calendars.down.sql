@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS invitations;
+
+DROP INDEX IF EXISTS events_series_idx;
+DROP INDEX IF EXISTS events_calendar_start_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS calendar_id;
+
+DROP TABLE IF EXISTS calendar_shares;
+DROP TABLE IF EXISTS calendars;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS calendars (
+    id       BIGSERIAL PRIMARY KEY,
+    owner_id BIGINT NOT NULL,
+    name     TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS calendars_owner_idx ON calendars (owner_id);
+
+CREATE TABLE IF NOT EXISTS calendar_shares (
+    calendar_id BIGINT NOT NULL REFERENCES calendars (id) ON DELETE CASCADE,
+    user_id     BIGINT NOT NULL,
+    permission  TEXT NOT NULL,
+    PRIMARY KEY (calendar_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS calendar_shares_user_idx ON calendar_shares (user_id);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS calendar_id BIGINT REFERENCES calendars (id);
+
+CREATE INDEX IF NOT EXISTS events_calendar_start_idx ON events (calendar_id, start_at) WHERE calendar_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS events_series_idx ON events (series_id) WHERE series_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS invitations (
+    event_id   BIGINT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+    user_id    BIGINT NOT NULL,
+    status     TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS invitations_user_idx ON invitations (user_id);
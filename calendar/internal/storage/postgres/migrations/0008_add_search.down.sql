@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS events_start_id_idx;
+DROP INDEX IF EXISTS events_description_trgm_idx;
+DROP INDEX IF EXISTS events_title_trgm_idx;
+DROP INDEX IF EXISTS events_tags_idx;
+
+ALTER TABLE events DROP COLUMN IF EXISTS tags;
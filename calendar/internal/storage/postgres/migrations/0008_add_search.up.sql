@@ -0,0 +1,25 @@
+-- pg_trgm нужен только для индексов поиска подстроки. Создание расширения требует прав
+-- суперпользователя (или владельца базы для доверенных расширений в PostgreSQL 13+);
+-- без них поиск работает через ILIKE без индексов. Расширение можно создать заранее:
+-- CREATE EXTENSION pg_trgm; - тогда миграция создаст и индексы.
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'pg_trgm is not available, text search is not indexed: %', SQLERRM;
+END
+$$;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX IF NOT EXISTS events_tags_idx ON events USING GIN (tags jsonb_path_ops);
+-- поиск подстроки через ILIKE '%...%'
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+        CREATE INDEX IF NOT EXISTS events_title_trgm_idx ON events USING GIN (title gin_trgm_ops);
+        CREATE INDEX IF NOT EXISTS events_description_trgm_idx ON events USING GIN (description gin_trgm_ops);
+    END IF;
+END
+$$;
+CREATE INDEX IF NOT EXISTS events_start_id_idx ON events (start_at, id);
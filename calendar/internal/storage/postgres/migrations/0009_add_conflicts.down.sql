@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS events_user_period_idx;
+
+ALTER TABLE calendars DROP COLUMN IF EXISTS conflict_policy;
+ALTER TABLE users DROP COLUMN IF EXISTS conflict_policy;
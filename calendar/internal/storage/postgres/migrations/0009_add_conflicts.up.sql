@@ -0,0 +1,26 @@
+-- btree_gist нужен для индекса пересечений по пользователю и интервалу. Создание расширения
+-- требует прав суперпользователя (или владельца базы для доверенных расширений в PostgreSQL 13+);
+-- без них создается обычный индекс по пользователю и началу. Расширение можно создать заранее:
+-- CREATE EXTENSION btree_gist;
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS btree_gist;
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'btree_gist is not available, overlap search uses a btree index: %', SQLERRM;
+END
+$$;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS conflict_policy TEXT NOT NULL DEFAULT '';
+ALTER TABLE calendars ADD COLUMN IF NOT EXISTS conflict_policy TEXT NOT NULL DEFAULT '';
+
+-- поиск пересечений: интервалы разовых событий и выделенных вхождений пользователя
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'btree_gist') THEN
+        CREATE INDEX IF NOT EXISTS events_user_period_idx ON events
+            USING GIST (user_id, tstzrange(start_at, end_at, '[]')) WHERE rrule = '';
+    ELSE
+        CREATE INDEX IF NOT EXISTS events_user_period_idx ON events (user_id, start_at) WHERE rrule = '';
+    END IF;
+END
+$$;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS event_audit;
+
+DELETE FROM events WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS events_deleted_at_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,17 @@
+-- удаленные события хранятся до окончательного удаления по истечении срока восстановления
+ALTER TABLE events ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS events_deleted_at_idx ON events (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- журнал не ссылается на events: история остается и после окончательного удаления
+CREATE TABLE IF NOT EXISTS event_audit (
+    id           BIGSERIAL PRIMARY KEY,
+    event_id     BIGINT      NOT NULL,
+    actor_id     BIGINT      NOT NULL,
+    action       TEXT        NOT NULL,
+    at           TIMESTAMPTZ NOT NULL,
+    before_event JSONB,
+    after_event  JSONB
+);
+
+CREATE INDEX IF NOT EXISTS event_audit_event_idx ON event_audit (event_id, id);
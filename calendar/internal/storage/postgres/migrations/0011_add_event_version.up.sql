@@ -0,0 +1,2 @@
+-- версия события для оптимистичной блокировки (ETag и If-Match)
+ALTER TABLE events ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
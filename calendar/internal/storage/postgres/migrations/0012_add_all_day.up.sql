@@ -0,0 +1,2 @@
+-- событие на весь день: экспортируется в iCalendar датами без времени
+ALTER TABLE events ADD COLUMN IF NOT EXISTS all_day BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+-- отправленные напоминания, чтобы после перезапуска они не срабатывали снова;
+-- записи старше окна досылки планировщик удаляет сам
+CREATE TABLE IF NOT EXISTS reminders_sent (
+    event_id BIGINT      NOT NULL,
+    at       TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (event_id, at)
+);
+
+CREATE INDEX IF NOT EXISTS reminders_sent_at_idx ON reminders_sent (at);
@@ -0,0 +1,574 @@
+package postgres
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/recurrence"
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations - миграции схемы хранилища
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Storage - хранилище событий поверх database/sql
+type Storage struct {
+	db *sql.DB
+}
+
+// New - конструктор
+func New(db *sql.DB) *Storage {
+	return &Storage{db: db}
+}
+
+// querier - общие методы *sql.DB и *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// withTx - выполняет fn в транзакции; ошибка fn откатывает транзакцию
+func (s *Storage) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Ping - проверяет соединение с базой
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// writeColumns - колонки, заполняемые из события (в порядке eventArgs)
+const writeColumns = `user_id, title, description, start_at, end_at, remind_before, remind_at,
+    rrule, exdates, recurrence_id, series_id, uid, time_zone, calendar_id, tags, all_day`
+
+const eventColumns = `id, user_id, title, description, start_at, end_at, remind_before,
+    rrule, exdates, recurrence_id, series_id, uid, time_zone, calendar_id, tags, all_day, version`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEvent(row scanner) (domain.Event, error) {
+	var (
+		e            domain.Event
+		exdates      []byte
+		tags         []byte
+		recurrenceID sql.NullTime
+		seriesID     sql.NullInt64
+		calendarID   sql.NullInt64
+	)
+
+	err := row.Scan(&e.ID, &e.UserID, &e.Title, &e.Description, &e.Start, &e.End, &e.RemindBefore,
+		&e.RRule, &exdates, &recurrenceID, &seriesID, &e.UID, &e.TimeZone, &calendarID, &tags, &e.AllDay, &e.Version)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	if len(exdates) > 0 {
+		if err := json.Unmarshal(exdates, &e.ExDates); err != nil {
+			return domain.Event{}, fmt.Errorf("decode exdates: %w", err)
+		}
+		if len(e.ExDates) == 0 {
+			e.ExDates = nil
+		}
+	}
+	if len(tags) > 0 {
+		if err := json.Unmarshal(tags, &e.Tags); err != nil {
+			return domain.Event{}, fmt.Errorf("decode tags: %w", err)
+		}
+		if len(e.Tags) == 0 {
+			e.Tags = nil
+		}
+	}
+	e.RecurrenceID = recurrenceID.Time
+	e.SeriesID = seriesID.Int64
+	e.CalendarID = calendarID.Int64
+
+	return e, nil
+}
+
+// eventArgs - значения колонок writeColumns
+func eventArgs(e domain.Event) ([]any, error) {
+	exdates := e.ExDates
+	if exdates == nil {
+		exdates = []time.Time{}
+	}
+	exdatesJSON, err := json.Marshal(exdates)
+	if err != nil {
+		return nil, fmt.Errorf("encode exdates: %w", err)
+	}
+
+	tags := e.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return nil, fmt.Errorf("encode tags: %w", err)
+	}
+
+	at, remind := e.RemindAt()
+
+	return []any{
+		e.UserID, e.Title, e.Description, e.Start, e.End, int64(e.RemindBefore),
+		sql.NullTime{Time: at, Valid: remind},
+		e.RRule, string(exdatesJSON),
+		sql.NullTime{Time: e.RecurrenceID, Valid: !e.RecurrenceID.IsZero()},
+		sql.NullInt64{Int64: e.SeriesID, Valid: e.SeriesID != 0},
+		e.UID, e.TimeZone,
+		sql.NullInt64{Int64: e.CalendarID, Valid: e.CalendarID != 0},
+		string(tagsJSON), e.AllDay,
+	}, nil
+}
+
+// placeholders - "$from, $from+1, ..." для n параметров
+func placeholders(from, n int) string {
+	var b strings.Builder
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("$" + strconv.Itoa(from+i))
+	}
+	return b.String()
+}
+
+// Create - сохраняет новое событие вместе с записью журнала a в одной транзакции
+func (s *Storage) Create(ctx context.Context, e domain.Event, a domain.AuditEntry) (domain.Event, error) {
+	args, err := eventArgs(e)
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	query := `INSERT INTO events (` + writeColumns + `)
+VALUES (` + placeholders(1, len(args)) + `) RETURNING id, version`
+
+	err = s.withTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, query, args...).Scan(&e.ID, &e.Version); err != nil {
+			return fmt.Errorf("insert event: %w", err)
+		}
+		a.EventID, a.After = e.ID, &e
+		return appendAudit(ctx, tx, a)
+	})
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	return e, nil
+}
+
+// Get - возвращает событие по идентификатору
+func (s *Storage) Get(ctx context.Context, id int64) (domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events WHERE id = $1 AND deleted_at IS NULL`
+
+	e, err := scanEvent(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Event{}, domain.ErrEventNotFound
+	}
+	if err != nil {
+		return domain.Event{}, fmt.Errorf("select event: %w", err)
+	}
+
+	return e, nil
+}
+
+// Update - заменяет существующее событие, если его версия равна e.Version, и увеличивает версию;
+// вместе с событием в той же транзакции сохраняется запись журнала a
+func (s *Storage) Update(ctx context.Context, e domain.Event, a domain.AuditEntry) error {
+	args, err := eventArgs(e)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE events SET (` + writeColumns + `) = (` + placeholders(3, len(args)) + `), version = version + 1
+WHERE id = $1 AND version = $2 AND deleted_at IS NULL`
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, query, append([]any{e.ID, e.Version}, args...)...)
+		if err != nil {
+			return fmt.Errorf("update event: %w", err)
+		}
+		if err := checkAffected(res); err != nil {
+			return versionError(ctx, tx, err, e.ID)
+		}
+
+		e.Version++
+		a.EventID, a.After = e.ID, &e
+		return appendAudit(ctx, tx, a)
+	})
+}
+
+// versionError - ошибка запроса с условием на версию события, не нашедшего строк:
+// domain.ErrVersionMismatch, если событие есть, иначе domain.ErrEventNotFound
+func versionError(ctx context.Context, q querier, err error, id int64) error {
+	if !errors.Is(err, domain.ErrEventNotFound) && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var exists bool
+	err = q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("select event: %w", err)
+	}
+	if exists {
+		return domain.ErrVersionMismatch
+	}
+	return domain.ErrEventNotFound
+}
+
+// Delete - помечает событие удаленным, если его версия равна version (0 - любая), и сохраняет
+// запись журнала a в той же транзакции; до PurgeDeleted событие можно вернуть через Restore.
+// Выделенные вхождения серии удаляются вместе с ней с тем же временем удаления.
+func (s *Storage) Delete(ctx context.Context, id, version int64, a domain.AuditEntry) error {
+	const query = `UPDATE events SET deleted_at = $3
+WHERE id = $1 AND ($2::bigint = 0 OR version = $2) AND deleted_at IS NULL
+RETURNING ` + eventColumns
+	const occurrencesQuery = `UPDATE events SET deleted_at = $2
+WHERE series_id = $1 AND deleted_at IS NULL
+RETURNING ` + eventColumns
+
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		e, err := scanEvent(tx.QueryRowContext(ctx, query, id, version, a.At))
+		if err != nil {
+			return versionError(ctx, tx, err, id)
+		}
+		a.EventID, a.Before = id, &e
+		if err := appendAudit(ctx, tx, a); err != nil {
+			return err
+		}
+		if !e.Recurring() {
+			return nil
+		}
+
+		occurrences, err := queryEvents(ctx, tx, occurrencesQuery, id, a.At)
+		if err != nil {
+			return err
+		}
+		for _, occ := range occurrences {
+			a.EventID, a.Before = occ.ID, &occ
+			if err := appendAudit(ctx, tx, a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Deleted - событие из корзины и время его удаления
+func (s *Storage) Deleted(ctx context.Context, id int64) (domain.Event, time.Time, error) {
+	const query = `SELECT ` + eventColumns + `, deleted_at FROM events WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	var at time.Time
+	e, err := scanEvent(withColumn{row: s.db.QueryRowContext(ctx, query, id), dest: &at})
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Event{}, time.Time{}, domain.ErrEventNotFound
+	}
+	if err != nil {
+		return domain.Event{}, time.Time{}, fmt.Errorf("select event: %w", err)
+	}
+
+	return e, at, nil
+}
+
+// withColumn - строка с дополнительной колонкой после колонок события
+type withColumn struct {
+	row  scanner
+	dest any
+}
+
+func (w withColumn) Scan(dest ...any) error {
+	return w.row.Scan(append(dest, w.dest)...)
+}
+
+// Restore - снимает с события пометку об удалении и сохраняет запись журнала a в той же транзакции.
+// Выделенные вхождения, удаленные вместе с серией (в то же время), возвращаются с ней.
+func (s *Storage) Restore(ctx context.Context, id int64, a domain.AuditEntry) (domain.Event, error) {
+	// вхождения снимаются первыми, пока время удаления серии еще известно
+	const occurrencesQuery = `UPDATE events SET deleted_at = NULL
+WHERE series_id = $1 AND deleted_at = (SELECT deleted_at FROM events WHERE id = $1)
+RETURNING ` + eventColumns
+	const query = `UPDATE events SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING ` + eventColumns
+
+	var e domain.Event
+	err := s.withTx(ctx, func(tx *sql.Tx) error {
+		occurrences, err := queryEvents(ctx, tx, occurrencesQuery, id)
+		if err != nil {
+			return err
+		}
+		e, err = scanEvent(tx.QueryRowContext(ctx, query, id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrEventNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("restore event: %w", err)
+		}
+
+		a.EventID, a.After = id, &e
+		if err := appendAudit(ctx, tx, a); err != nil {
+			return err
+		}
+		for _, occ := range occurrences {
+			a.EventID, a.After = occ.ID, &occ
+			if err := appendAudit(ctx, tx, a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Event{}, err
+	}
+
+	return e, nil
+}
+
+// Discard - удаляет событие безвозвратно, минуя корзину, вместе с журналом изменений
+// (приглашения - каскадом)
+func (s *Storage) Discard(ctx context.Context, id int64) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM events WHERE id = $1 AND deleted_at IS NULL`, id)
+		if err != nil {
+			return fmt.Errorf("delete event: %w", err)
+		}
+		if err := checkAffected(res); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM event_audit WHERE event_id = $1`, id); err != nil {
+			return fmt.Errorf("delete audit: %w", err)
+		}
+		return nil
+	})
+}
+
+// PurgeDeleted - окончательно удаляет события, удаленные раньше before (приглашения - каскадом)
+func (s *Storage) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM events WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("purge events: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("rows affected: %w", err)
+	}
+
+	return int(n), nil
+}
+
+// ListByUser - события пользователя, начинающиеся в полуинтервале [from, to)
+func (s *Storage) ListByUser(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE user_id = $1 AND start_at >= $2 AND start_at < $3 AND deleted_at IS NULL
+ORDER BY start_at, id`
+
+	return s.list(ctx, query, userID, from, to)
+}
+
+// ListByCalendar - события календаря, начинающиеся в полуинтервале [from, to)
+func (s *Storage) ListByCalendar(ctx context.Context, calendarID int64, from, to time.Time) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE calendar_id = $1 AND start_at >= $2 AND start_at < $3 AND deleted_at IS NULL
+ORDER BY start_at, id`
+
+	return s.list(ctx, query, calendarID, from, to)
+}
+
+// ListByUID - события пользователя с заданным UID
+func (s *Storage) ListByUID(ctx context.Context, userID int64, uid string) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE user_id = $1 AND uid = $2 AND deleted_at IS NULL
+ORDER BY id`
+
+	return s.list(ctx, query, userID, uid)
+}
+
+// ListRecurring - повторяющиеся серии пользователя, начинающиеся раньше to
+func (s *Storage) ListRecurring(ctx context.Context, userID int64, to time.Time) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE user_id = $1 AND rrule <> '' AND start_at < $2 AND deleted_at IS NULL
+ORDER BY id`
+
+	return s.list(ctx, query, userID, to)
+}
+
+// ListBySeries - выделенные вхождения серии
+func (s *Storage) ListBySeries(ctx context.Context, seriesID int64) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE series_id = $1 AND deleted_at IS NULL
+ORDER BY id`
+
+	return s.list(ctx, query, seriesID)
+}
+
+// ListRecurringByCalendar - повторяющиеся серии календаря, начинающиеся раньше to
+func (s *Storage) ListRecurringByCalendar(ctx context.Context, calendarID int64, to time.Time) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE calendar_id = $1 AND rrule <> '' AND start_at < $2 AND deleted_at IS NULL
+ORDER BY id`
+
+	return s.list(ctx, query, calendarID, to)
+}
+
+// ListOverlapping - разовые события и выделенные вхождения пользователя, пересекающиеся
+// с интервалом (from, to). Замкнутый интервал события учитывает моментальные события;
+// запрос использует GiST индекс events_user_period_idx.
+func (s *Storage) ListOverlapping(ctx context.Context, userID int64, from, to time.Time) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE user_id = $1 AND rrule = '' AND tstzrange(start_at, end_at, '[]') && tstzrange($2, $3, '()') AND deleted_at IS NULL
+ORDER BY start_at, id`
+
+	return s.list(ctx, query, userID, from, to)
+}
+
+// Reminders - события, напоминания о которых приходятся на полуинтервал [from, to);
+// у серий - вхождения, напоминания о которых попадают в полуинтервал. remind_at серии -
+// напоминание о первом вхождении, поэтому серии выбираются все, начавшиеся до to, и разворачиваются.
+func (s *Storage) Reminders(ctx context.Context, from, to time.Time) ([]domain.Event, error) {
+	const query = `SELECT ` + eventColumns + ` FROM events
+WHERE remind_at < $2 AND (remind_at >= $1 OR rrule <> '') AND deleted_at IS NULL
+ORDER BY remind_at, id`
+
+	candidates, err := s.list(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return recurrence.Reminders(candidates, from, to)
+}
+
+// GetUser - возвращает настройки пользователя
+func (s *Storage) GetUser(ctx context.Context, id int64) (domain.User, error) {
+	const query = `SELECT id, time_zone, conflict_policy FROM users WHERE id = $1`
+
+	var u domain.User
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&u.ID, &u.TimeZone, &u.ConflictPolicy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return domain.User{}, fmt.Errorf("select user: %w", err)
+	}
+
+	return u, nil
+}
+
+// SaveUser - создает или заменяет настройки пользователя
+func (s *Storage) SaveUser(ctx context.Context, u domain.User) error {
+	const query = `INSERT INTO users (id, time_zone, conflict_policy) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE SET time_zone = EXCLUDED.time_zone, conflict_policy = EXCLUDED.conflict_policy`
+
+	if _, err := s.db.ExecContext(ctx, query, u.ID, u.TimeZone, u.ConflictPolicy); err != nil {
+		return fmt.Errorf("save user: %w", err)
+	}
+	return nil
+}
+
+// SaveAPIKey - сохраняет API ключ
+func (s *Storage) SaveAPIKey(ctx context.Context, k domain.APIKey) error {
+	const query = `INSERT INTO api_keys (id, user_id, name, hash, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := s.db.ExecContext(ctx, query, k.ID, k.UserID, k.Name, k.Hash, k.CreatedAt); err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKey - возвращает API ключ по открытой части
+func (s *Storage) GetAPIKey(ctx context.Context, id string) (domain.APIKey, error) {
+	const query = `SELECT id, user_id, name, hash, created_at FROM api_keys WHERE id = $1`
+
+	var k domain.APIKey
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&k.ID, &k.UserID, &k.Name, &k.Hash, &k.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return domain.APIKey{}, fmt.Errorf("select api key: %w", err)
+	}
+
+	return k, nil
+}
+
+// DeleteAPIKey - удаляет API ключ
+func (s *Storage) DeleteAPIKey(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// list - выполняет запрос и читает события
+func (s *Storage) list(ctx context.Context, query string, args ...any) ([]domain.Event, error) {
+	return queryEvents(ctx, s.db, query, args...)
+}
+
+// queryEvents - события, возвращенные запросом; q - база или транзакция
+func queryEvents(ctx context.Context, q querier, query string, args ...any) ([]domain.Event, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]domain.Event, 0)
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select events: %w", err)
+	}
+
+	return events, nil
+}
+
+// checkAffected - возвращает domain.ErrEventNotFound, если запрос не затронул строк
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrEventNotFound
+	}
+	return nil
+}
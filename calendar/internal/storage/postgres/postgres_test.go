@@ -0,0 +1,309 @@
+package postgres_test
+
+import (
+	"Calendar/internal/domain"
+	"Calendar/internal/storage/migrate"
+	"Calendar/internal/storage/postgres"
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+)
+
+var columns = []string{
+	"id", "user_id", "title", "description", "start_at", "end_at", "remind_before",
+	"rrule", "exdates", "recurrence_id", "series_id", "uid", "time_zone", "calendar_id", "tags", "all_day", "version",
+}
+
+func TestMigrationsEmbedded(t *testing.T) {
+	migrations, err := migrate.Load(postgres.Migrations())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for _, m := range migrations {
+		require.NotEmpty(t, m.Down, "migration %d has no down script", m.Version)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	s := postgres.New(db)
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	e := domain.Event{UserID: 1, Title: "standup", Start: start, End: start.Add(time.Hour)}
+
+	// событие и запись журнала сохраняются в одной транзакции
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO events")).
+		WithArgs(e.UserID, e.Title, e.Description, e.Start, e.End, int64(0), sql.NullTime{},
+			"", "[]", sql.NullTime{}, sql.NullInt64{}, "", "", sql.NullInt64{}, "[]", false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version"}).AddRow(7, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(7, 1, domain.AuditCreated, start, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	created, err := s.Create(ctx, e, domain.AuditEntry{ActorID: 1, Action: domain.AuditCreated, At: start})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), created.ID)
+	require.Equal(t, int64(1), created.Version)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM events WHERE id = $1")).
+		WithArgs(8).WillReturnError(sql.ErrNoRows)
+	_, err = s.Get(ctx, 8)
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE events")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM events WHERE id = $1 AND deleted_at IS NULL)")).
+		WithArgs(8).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectRollback()
+	require.ErrorIs(t, s.Update(ctx, domain.Event{ID: 8}, domain.AuditEntry{}), domain.ErrEventNotFound)
+
+	// событие есть, но его версия уже другая
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("version = version + 1\nWHERE id = $1 AND version = $2 AND deleted_at IS NULL")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+		WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+	require.ErrorIs(t, s.Update(ctx, domain.Event{ID: 7, Version: 1}, domain.AuditEntry{}), domain.ErrVersionMismatch)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("version = version + 1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(7, 1, domain.AuditUpdated, start, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.Update(ctx, created, domain.AuditEntry{ActorID: 1, Action: domain.AuditUpdated, At: start, Before: &created}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM events WHERE id = $1 AND deleted_at IS NULL")).
+		WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM event_audit WHERE event_id = $1")).
+		WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.Discard(ctx, 7))
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM events WHERE deleted_at < $1")).
+		WithArgs(start).WillReturnResult(sqlmock.NewResult(0, 2))
+	n, err := s.PurgeDeleted(ctx, start)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = $1 AND start_at >= $2 AND start_at < $3")).
+		WithArgs(1, start, start.Add(24*time.Hour)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 1, "standup", "", start, start.Add(time.Hour), 0,
+			"FREQ=DAILY", []byte(`["2025-01-16T10:00:00Z"]`), nil, nil, "", "Europe/Moscow", 3, []byte(`["work"]`), false, 2))
+	events, err := s.ListByUser(ctx, 1, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, "standup", events[0].Title)
+	require.Equal(t, []time.Time{start.AddDate(0, 0, 1)}, events[0].ExDates)
+	require.Equal(t, "Europe/Moscow", events[0].TimeZone)
+	require.Equal(t, int64(3), events[0].CalendarID)
+	require.Equal(t, []string{"work"}, events[0].Tags)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users WHERE id = $1")).
+		WithArgs(2).WillReturnError(sql.ErrNoRows)
+	_, err = s.GetUser(ctx, 2)
+	require.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
+		WithArgs(2, "Asia/Novosibirsk", domain.ConflictReject).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, s.SaveUser(ctx, domain.User{ID: 2, TimeZone: "Asia/Novosibirsk", ConflictPolicy: domain.ConflictReject}))
+
+	mock.ExpectQuery(regexp.QuoteMeta("tstzrange(start_at, end_at, '[]') && tstzrange($2, $3, '()')")).
+		WithArgs(1, start, start.Add(time.Hour)).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(8, 1, "review", "", start.Add(-time.Hour), start.Add(time.Minute), 0,
+			"", nil, nil, nil, "", "UTC", nil, []byte(`[]`), false, 1))
+	events, err = s.ListOverlapping(ctx, 1, start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(8), events[0].ID)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE calendars SET name = $2, conflict_policy = $3 WHERE id = $1")).
+		WithArgs(4, "team", domain.ConflictReject).WillReturnResult(sqlmock.NewResult(0, 0))
+	err = s.UpdateCalendar(ctx, domain.Calendar{ID: 4, Name: "team", ConflictPolicy: domain.ConflictReject})
+	require.ErrorIs(t, err, domain.ErrCalendarNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	s := postgres.New(db)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := domain.Cursor{Start: from.Add(time.Hour), ID: 5}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE (user_id = $1 OR calendar_id IN ($2, $3) OR id IN ($4)) `+
+		`AND deleted_at IS NULL AND rrule = '' AND (title ILIKE $5 OR description ILIKE $5) AND tags @> $6::jsonb AND start_at >= $7 `+
+		`AND (start_at, id) > ($8, $9)
+ORDER BY start_at, id LIMIT $10`)).
+		WithArgs(1, 3, 4, 9, `%50\%%`, `["work"]`, from, after.Start, after.ID, 11).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 1, "50% done", "", from.Add(2*time.Hour), from.Add(3*time.Hour), 0,
+			"", []byte(`[]`), nil, nil, "", "UTC", nil, []byte(`["work"]`), false, 1))
+
+	events, err := s.Search(context.Background(), domain.EventFilter{
+		UserID:      1,
+		CalendarIDs: []int64{3, 4},
+		EventIDs:    []int64{9},
+		Text:        "50%",
+		Tags:        []string{"work"},
+		From:        from,
+		After:       &after,
+		Limit:       11,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(7), events[0].ID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	s := postgres.New(db)
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(7, 1, "standup", "", at, at.Add(time.Hour), 0,
+			"", []byte(`[]`), nil, nil, "", "", nil, []byte(`[]`), false, 2)
+	}
+	series := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(7, 1, "standup", "", at, at.Add(time.Hour), 0,
+			"FREQ=DAILY", []byte(`["2025-01-16T10:00:00Z"]`), nil, nil, "", "", nil, []byte(`[]`), false, 2)
+	}
+	occurrence := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(9, 1, "standup (moved)", "", at.AddDate(0, 0, 1).Add(time.Hour), at.AddDate(0, 0, 1).Add(2*time.Hour), 0,
+			"", []byte(`[]`), at.AddDate(0, 0, 1), 7, "", "", nil, []byte(`[]`), false, 1)
+	}
+
+	// удаление и запись журнала - в одной транзакции; время удаления - время записи
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = $3\nWHERE id = $1 AND ($2::bigint = 0 OR version = $2) AND deleted_at IS NULL")).
+		WithArgs(7, 0, at).WillReturnRows(row())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(7, 2, domain.AuditDeleted, at, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.Delete(ctx, 7, 0, domain.AuditEntry{ActorID: 2, Action: domain.AuditDeleted, At: at}))
+
+	// выделенные вхождения удаляются вместе с серией
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = $3")).
+		WithArgs(7, 0, at).WillReturnRows(series())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(7, 2, domain.AuditDeleted, at, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = $2\nWHERE series_id = $1 AND deleted_at IS NULL")).
+		WithArgs(7, at).WillReturnRows(occurrence())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(9, 2, domain.AuditDeleted, at, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, s.Delete(ctx, 7, 0, domain.AuditEntry{ActorID: 2, Action: domain.AuditDeleted, At: at}))
+
+	// без записи журнала удаление откатывается
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = $3")).
+		WithArgs(7, 2, at).WillReturnRows(row())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+	require.ErrorIs(t, s.Delete(ctx, 7, 2, domain.AuditEntry{ActorID: 2, Action: domain.AuditDeleted, At: at}), sql.ErrConnDone)
+
+	// удаление по устаревшей версии
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = $3")).
+		WithArgs(7, 1, at).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).
+		WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+	require.ErrorIs(t, s.Delete(ctx, 7, 1, domain.AuditEntry{At: at}), domain.ErrVersionMismatch)
+
+	mock.ExpectQuery(regexp.QuoteMeta("deleted_at FROM events WHERE id = $1 AND deleted_at IS NOT NULL")).
+		WithArgs(7).WillReturnRows(sqlmock.NewRows(append(columns, "deleted_at")).AddRow(7, 1, "standup", "", at, at.Add(time.Hour), 0,
+		"", []byte(`[]`), nil, nil, "", "", nil, []byte(`[]`), false, 2, at))
+	deleted, deletedAt, err := s.Deleted(ctx, 7)
+	require.NoError(t, err)
+	require.Equal(t, "standup", deleted.Title)
+	require.Equal(t, at, deletedAt)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE series_id = $1 AND deleted_at = (SELECT deleted_at FROM events WHERE id = $1)")).
+		WithArgs(8).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL")).
+		WithArgs(8).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	_, err = s.Restore(ctx, 8, domain.AuditEntry{At: at})
+	require.ErrorIs(t, err, domain.ErrEventNotFound)
+
+	// серия возвращается вместе с вхождениями, удаленными с ней
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE series_id = $1 AND deleted_at =")).
+		WithArgs(7).WillReturnRows(occurrence())
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE events SET deleted_at = NULL WHERE id = $1")).
+		WithArgs(7).WillReturnRows(series())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(7, 2, domain.AuditRestored, at, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_audit")).
+		WithArgs(9, 2, domain.AuditRestored, at, nil, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	restored, err := s.Restore(ctx, 7, domain.AuditEntry{ActorID: 2, Action: domain.AuditRestored, At: at})
+	require.NoError(t, err)
+	require.Equal(t, "standup", restored.Title)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM event_audit WHERE event_id = $1 ORDER BY id")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "actor_id", "action", "at", "before_event", "after_event"}).
+			AddRow(3, 7, 2, "deleted", at, []byte(`{"id":7,"user_id":1,"title":"standup"}`), nil))
+	entries, err := s.ListAudit(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, domain.AuditDeleted, entries[0].Action)
+	require.NotNil(t, entries[0].Before)
+	require.Equal(t, "standup", entries[0].Before.Title)
+	require.Nil(t, entries[0].After)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemindersSent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	s := postgres.New(db)
+	at := time.Date(2025, 1, 15, 9, 45, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM reminders_sent WHERE event_id = $1 AND at = $2)")).
+		WithArgs(7, at).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO reminders_sent (event_id, at) VALUES ($1, $2) ON CONFLICT DO NOTHING")).
+		WithArgs(7, at).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM reminders_sent WHERE at < $1")).
+		WithArgs(at).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	sent, err := s.IsReminderSent(ctx, 7, at)
+	require.NoError(t, err)
+	require.False(t, sent)
+	require.NoError(t, s.MarkReminderSent(ctx, 7, at))
+	require.NoError(t, s.PruneRemindersSent(ctx, at))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// likeEscaper - экранирует спецсимволы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Search - события, подходящие под фильтр, по возрастанию (start_at, id), не больше f.Limit
+func (s *Storage) Search(ctx context.Context, f domain.EventFilter) ([]domain.Event, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	list := func(ids []int64) string {
+		values := make([]string, len(ids))
+		for i, id := range ids {
+			values[i] = arg(id)
+		}
+		return strings.Join(values, ", ")
+	}
+
+	scope := []string{"user_id = " + arg(f.UserID)}
+	if len(f.CalendarIDs) > 0 {
+		scope = append(scope, "calendar_id IN ("+list(f.CalendarIDs)+")")
+	}
+	if len(f.EventIDs) > 0 {
+		scope = append(scope, "id IN ("+list(f.EventIDs)+")")
+	}
+	where = append(where, "("+strings.Join(scope, " OR ")+")", "deleted_at IS NULL")
+	if f.Recurring {
+		where = append(where, "rrule <> ''")
+	} else {
+		where = append(where, "rrule = ''")
+	}
+
+	if f.Text != "" {
+		pattern := arg("%" + likeEscaper.Replace(f.Text) + "%")
+		where = append(where, "(title ILIKE "+pattern+" OR description ILIKE "+pattern+")")
+	}
+	if len(f.Tags) > 0 {
+		tags, err := json.Marshal(f.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("encode tags: %w", err)
+		}
+		where = append(where, "tags @> "+arg(string(tags))+"::jsonb")
+	}
+	if !f.From.IsZero() {
+		where = append(where, "start_at >= "+arg(f.From))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "start_at < "+arg(f.To))
+	}
+	if f.After != nil {
+		where = append(where, "(start_at, id) > ("+arg(f.After.Start)+", "+arg(f.After.ID)+")")
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM events
+WHERE ` + strings.Join(where, " AND ") + `
+ORDER BY start_at, id`
+	if f.Limit > 0 {
+		query += ` LIMIT ` + arg(f.Limit)
+	}
+
+	return s.list(ctx, query, args...)
+}
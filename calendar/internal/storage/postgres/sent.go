@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IsReminderSent - было ли отправлено напоминание о событии, сработавшее в at
+func (s *Storage) IsReminderSent(ctx context.Context, eventID int64, at time.Time) (bool, error) {
+	const query = `SELECT EXISTS (SELECT 1 FROM reminders_sent WHERE event_id = $1 AND at = $2)`
+
+	var sent bool
+	if err := s.db.QueryRowContext(ctx, query, eventID, at).Scan(&sent); err != nil {
+		return false, fmt.Errorf("select sent reminder: %w", err)
+	}
+	return sent, nil
+}
+
+// MarkReminderSent - отмечает напоминание отправленным
+func (s *Storage) MarkReminderSent(ctx context.Context, eventID int64, at time.Time) error {
+	const query = `INSERT INTO reminders_sent (event_id, at) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	if _, err := s.db.ExecContext(ctx, query, eventID, at); err != nil {
+		return fmt.Errorf("insert sent reminder: %w", err)
+	}
+	return nil
+}
+
+// PruneRemindersSent - удаляет отметки напоминаний, сработавших раньше before
+func (s *Storage) PruneRemindersSent(ctx context.Context, before time.Time) error {
+	const query = `DELETE FROM reminders_sent WHERE at < $1`
+
+	if _, err := s.db.ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("delete sent reminders: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"Calendar/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateCalendar - сохраняет новый календарь
+func (s *Storage) CreateCalendar(ctx context.Context, c domain.Calendar) (domain.Calendar, error) {
+	const query = `INSERT INTO calendars (owner_id, name, conflict_policy) VALUES ($1, $2, $3) RETURNING id`
+
+	if err := s.db.QueryRowContext(ctx, query, c.OwnerID, c.Name, c.ConflictPolicy).Scan(&c.ID); err != nil {
+		return domain.Calendar{}, fmt.Errorf("insert calendar: %w", err)
+	}
+	return c, nil
+}
+
+// GetCalendar - возвращает календарь по идентификатору
+func (s *Storage) GetCalendar(ctx context.Context, id int64) (domain.Calendar, error) {
+	const query = `SELECT id, owner_id, name, conflict_policy FROM calendars WHERE id = $1`
+
+	var c domain.Calendar
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.OwnerID, &c.Name, &c.ConflictPolicy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Calendar{}, domain.ErrCalendarNotFound
+	}
+	if err != nil {
+		return domain.Calendar{}, fmt.Errorf("select calendar: %w", err)
+	}
+
+	return c, nil
+}
+
+// UpdateCalendar - заменяет существующий календарь
+func (s *Storage) UpdateCalendar(ctx context.Context, c domain.Calendar) error {
+	const query = `UPDATE calendars SET name = $2, conflict_policy = $3 WHERE id = $1`
+
+	res, err := s.db.ExecContext(ctx, query, c.ID, c.Name, c.ConflictPolicy)
+	if err != nil {
+		return fmt.Errorf("update calendar: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrCalendarNotFound
+	}
+
+	return nil
+}
+
+// ListCalendars - календари пользователя
+func (s *Storage) ListCalendars(ctx context.Context, ownerID int64) ([]domain.Calendar, error) {
+	const query = `SELECT id, owner_id, name, conflict_policy FROM calendars WHERE owner_id = $1 ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("select calendars: %w", err)
+	}
+	defer rows.Close()
+
+	calendars := make([]domain.Calendar, 0)
+	for rows.Next() {
+		var c domain.Calendar
+		if err := rows.Scan(&c.ID, &c.OwnerID, &c.Name, &c.ConflictPolicy); err != nil {
+			return nil, fmt.Errorf("scan calendar: %w", err)
+		}
+		calendars = append(calendars, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+// SaveShare - открывает календарь пользователю или меняет его право
+func (s *Storage) SaveShare(ctx context.Context, sh domain.Share) error {
+	const query = `INSERT INTO calendar_shares (calendar_id, user_id, permission) VALUES ($1, $2, $3)
+ON CONFLICT (calendar_id, user_id) DO UPDATE SET permission = EXCLUDED.permission`
+
+	if _, err := s.db.ExecContext(ctx, query, sh.CalendarID, sh.UserID, string(sh.Permission)); err != nil {
+		return fmt.Errorf("save share: %w", err)
+	}
+	return nil
+}
+
+// GetShare - доступ пользователя к календарю
+func (s *Storage) GetShare(ctx context.Context, calendarID, userID int64) (domain.Share, error) {
+	const query = `SELECT calendar_id, user_id, permission FROM calendar_shares
+WHERE calendar_id = $1 AND user_id = $2`
+
+	var sh domain.Share
+	err := s.db.QueryRowContext(ctx, query, calendarID, userID).Scan(&sh.CalendarID, &sh.UserID, &sh.Permission)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Share{}, domain.ErrNotShared
+	}
+	if err != nil {
+		return domain.Share{}, fmt.Errorf("select share: %w", err)
+	}
+
+	return sh, nil
+}
+
+// DeleteShare - закрывает календарь для пользователя
+func (s *Storage) DeleteShare(ctx context.Context, calendarID, userID int64) error {
+	const query = `DELETE FROM calendar_shares WHERE calendar_id = $1 AND user_id = $2`
+
+	res, err := s.db.ExecContext(ctx, query, calendarID, userID)
+	if err != nil {
+		return fmt.Errorf("delete share: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrNotShared
+	}
+	return nil
+}
+
+// ListShares - календари, открытые пользователю
+func (s *Storage) ListShares(ctx context.Context, userID int64) ([]domain.Share, error) {
+	const query = `SELECT calendar_id, user_id, permission FROM calendar_shares
+WHERE user_id = $1 ORDER BY calendar_id`
+
+	return s.listShares(ctx, query, userID)
+}
+
+// ListCalendarShares - пользователи, которым открыт календарь
+func (s *Storage) ListCalendarShares(ctx context.Context, calendarID int64) ([]domain.Share, error) {
+	const query = `SELECT calendar_id, user_id, permission FROM calendar_shares
+WHERE calendar_id = $1 ORDER BY user_id`
+
+	return s.listShares(ctx, query, calendarID)
+}
+
+func (s *Storage) listShares(ctx context.Context, query string, args ...any) ([]domain.Share, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := make([]domain.Share, 0)
+	for rows.Next() {
+		var sh domain.Share
+		if err := rows.Scan(&sh.CalendarID, &sh.UserID, &sh.Permission); err != nil {
+			return nil, fmt.Errorf("scan share: %w", err)
+		}
+		shares = append(shares, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// SaveInvitation - создает или заменяет приглашение
+func (s *Storage) SaveInvitation(ctx context.Context, inv domain.Invitation) error {
+	const query = `INSERT INTO invitations (event_id, user_id, status, updated_at) VALUES ($1, $2, $3, $4)
+ON CONFLICT (event_id, user_id) DO UPDATE SET status = EXCLUDED.status, updated_at = EXCLUDED.updated_at`
+
+	_, err := s.db.ExecContext(ctx, query, inv.EventID, inv.UserID, string(inv.Status), inv.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("save invitation: %w", err)
+	}
+	return nil
+}
+
+const invitationColumns = `event_id, user_id, status, updated_at`
+
+func scanInvitation(row scanner) (domain.Invitation, error) {
+	var inv domain.Invitation
+	err := row.Scan(&inv.EventID, &inv.UserID, &inv.Status, &inv.UpdatedAt)
+	return inv, err
+}
+
+// GetInvitation - приглашение пользователя на событие
+func (s *Storage) GetInvitation(ctx context.Context, eventID, userID int64) (domain.Invitation, error) {
+	const query = `SELECT ` + invitationColumns + ` FROM invitations WHERE event_id = $1 AND user_id = $2`
+
+	inv, err := scanInvitation(s.db.QueryRowContext(ctx, query, eventID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Invitation{}, domain.ErrInvitationNotFound
+	}
+	if err != nil {
+		return domain.Invitation{}, fmt.Errorf("select invitation: %w", err)
+	}
+
+	return inv, nil
+}
+
+// ListInvitations - приглашения на событие
+func (s *Storage) ListInvitations(ctx context.Context, eventID int64) ([]domain.Invitation, error) {
+	const query = `SELECT ` + invitationColumns + ` FROM invitations WHERE event_id = $1 ORDER BY user_id`
+
+	return s.listInvitations(ctx, query, eventID)
+}
+
+// ListUserInvitations - приглашения пользователя на неудаленные события
+func (s *Storage) ListUserInvitations(ctx context.Context, userID int64) ([]domain.Invitation, error) {
+	const query = `SELECT ` + invitationColumns + ` FROM invitations
+WHERE user_id = $1 AND event_id IN (SELECT id FROM events WHERE deleted_at IS NULL)
+ORDER BY event_id`
+
+	return s.listInvitations(ctx, query, userID)
+}
+
+func (s *Storage) listInvitations(ctx context.Context, query string, args ...any) ([]domain.Invitation, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := make([]domain.Invitation, 0)
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select invitations: %w", err)
+	}
+
+	return invitations, nil
+}
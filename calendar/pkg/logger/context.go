@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Ключи полей запроса в записях лога
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+	TraceIDKey   = "trace_id"
+)
+
+type fieldsKey struct{}
+
+// fields - поля запроса; заполняются по мере обработки (пользователь известен только после аутентификации),
+// поэтому хранятся по указателю и видны всем логам запроса, в том числе записанным внешними middleware
+type fields struct {
+	mu        sync.RWMutex
+	requestID string
+	traceID   string
+	userID    int64
+}
+
+// NewContext - контекст с новым пустым набором полей запроса
+func NewContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &fields{})
+}
+
+func fromContext(ctx context.Context) *fields {
+	if ctx == nil {
+		return nil
+	}
+	f, _ := ctx.Value(fieldsKey{}).(*fields)
+	return f
+}
+
+// SetRequestID - задает идентификатор запроса; без NewContext ничего не делает
+func SetRequestID(ctx context.Context, id string) {
+	if f := fromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.requestID = id
+		f.mu.Unlock()
+	}
+}
+
+// SetTraceID - задает идентификатор трассировки; без NewContext ничего не делает
+func SetTraceID(ctx context.Context, id string) {
+	if f := fromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.traceID = id
+		f.mu.Unlock()
+	}
+}
+
+// SetUserID - задает пользователя запроса; без NewContext ничего не делает
+func SetUserID(ctx context.Context, id int64) {
+	if f := fromContext(ctx); f != nil {
+		f.mu.Lock()
+		f.userID = id
+		f.mu.Unlock()
+	}
+}
+
+// attrs - заданные поля запроса
+func (f *fields) attrs() []slog.Attr {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	attrs := make([]slog.Attr, 0, 3)
+	if f.requestID != "" {
+		attrs = append(attrs, slog.String(RequestIDKey, f.requestID))
+	}
+	if f.userID != 0 {
+		attrs = append(attrs, slog.Int64(UserIDKey, f.userID))
+	}
+	if f.traceID != "" {
+		attrs = append(attrs, slog.String(TraceIDKey, f.traceID))
+	}
+	return attrs
+}
+
+// ContextHandler - обертка обработчика slog, добавляющая в каждую запись поля запроса из контекста.
+// Поля, уже переданные в записи явно, не дублируются.
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler - конструктор
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: h}
+}
+
+// Handle - дополняет запись полями запроса
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f := fromContext(ctx); f != nil {
+		for _, attr := range f.attrs() {
+			if !hasAttr(r, attr.Key) {
+				r.AddAttrs(attr)
+			}
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs - сохраняет обертку у производных логгеров
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup - сохраняет обертку у производных логгеров
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func hasAttr(r slog.Record, key string) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = a.Key == key
+		return !found
+	})
+	return found
+}
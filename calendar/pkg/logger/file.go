@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// File - файл лога с ротацией по размеру: когда очередная запись не помещается в maxBytes,
+// файл переименовывается в path.1 (path.1 - в path.2 и т.д.), а запись продолжается в новый файл.
+// Хранится не больше maxBackups старых файлов.
+type File struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	f    *os.File
+	size int64
+}
+
+// OpenFile - открывает (или создает) файл лога для дозаписи
+func OpenFile(path string, maxBytes int64, maxBackups int) (*File, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("log file max size must be positive")
+	}
+	if maxBackups < 0 {
+		return nil, errors.New("log file backups must not be negative")
+	}
+
+	lf := &File{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := lf.open(); err != nil {
+		return nil, err
+	}
+	return lf, nil
+}
+
+func (lf *File) open() error {
+	f, err := os.OpenFile(lf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	lf.f = f
+	lf.size = info.Size()
+	return nil
+}
+
+// Write - дописывает запись целиком в текущий файл, при необходимости ротируя его перед этим.
+// Запись больше maxBytes попадает в отдельный файл.
+func (lf *File) Write(p []byte) (int, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.f == nil {
+		return 0, fs.ErrClosed
+	}
+	if lf.size > 0 && lf.size+int64(len(p)) > lf.maxBytes {
+		// не удалось ротировать - продолжаем писать в текущий файл, чтобы не терять записи
+		if err := lf.rotate(); err != nil && lf.f == nil {
+			if lf.open() != nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := lf.f.Write(p)
+	lf.size += int64(n)
+	return n, err
+}
+
+// rotate - сдвигает старые файлы и начинает новый
+func (lf *File) rotate() error {
+	if err := lf.f.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	lf.f = nil
+
+	if lf.maxBackups == 0 {
+		if err := os.Remove(lf.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove log file: %w", err)
+		}
+		return lf.open()
+	}
+
+	// самый старый файл перезаписывается следующим по возрасту
+	for i := lf.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(lf.backup(i), lf.backup(i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(lf.path, lf.backup(1)); err != nil {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	return lf.open()
+}
+
+func (lf *File) backup(i int) string {
+	return lf.path + "." + strconv.Itoa(i)
+}
+
+// Close - закрывает файл
+func (lf *File) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lf.f == nil {
+		return nil
+	}
+	err := lf.f.Close()
+	lf.f = nil
+	return err
+}
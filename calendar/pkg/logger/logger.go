@@ -1,27 +1,28 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
 
-func New(env string) *slog.Logger {
+// New - логгер для окружения env: JSON в production и staging, текст в остальных.
+// Пишет в w (nil - os.Stdout); уровень можно менять на лету, передав *slog.LevelVar.
+// Записи дополняются полями запроса из контекста (см. ContextHandler).
+func New(env string, level slog.Leveler, w io.Writer) *slog.Logger {
 	var handler slog.Handler
 
+	if w == nil {
+		w = os.Stdout
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
 	switch env {
-	case "production":
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelInfo,
-		})
-	case "staging":
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		})
+	case "production", "staging":
+		handler = slog.NewJSONHandler(w, opts)
 	default:
-		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		})
+		handler = slog.NewTextHandler(w, opts)
 	}
 
-	return slog.New(handler)
+	return slog.New(NewContextHandler(handler))
 }
@@ -0,0 +1,89 @@
+package logger_test
+
+import (
+	"Calendar/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelInfo)
+	log := logger.New("production", level, &buf).With(slog.String("component", "test"))
+
+	ctx := logger.NewContext(context.Background())
+	logger.SetRequestID(ctx, "req-1")
+	logger.SetTraceID(ctx, "trace-1")
+	logger.SetUserID(ctx, 7)
+
+	log.DebugContext(ctx, "hidden")
+	log.InfoContext(ctx, "shown")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "shown", entry["msg"])
+	require.Equal(t, "test", entry["component"])
+	require.Equal(t, "req-1", entry["request_id"])
+	require.Equal(t, "trace-1", entry["trace_id"])
+	require.EqualValues(t, 7, entry["user_id"])
+
+	// уровень меняется на лету
+	buf.Reset()
+	level.Set(slog.LevelDebug)
+	log.DebugContext(ctx, "debug")
+	require.Contains(t, buf.String(), `"msg":"debug"`)
+
+	// явно переданное поле не дублируется, контекст без полей ничего не добавляет
+	buf.Reset()
+	log.InfoContext(ctx, "explicit", slog.String("request_id", "own"))
+	log.Info("plain")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, 1, strings.Count(lines[0], "request_id"))
+	require.Contains(t, lines[0], `"request_id":"own"`)
+	require.NotContains(t, lines[1], "request_id")
+
+	// без NewContext поля не задаются
+	logger.SetUserID(context.Background(), 1)
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+
+	f, err := logger.OpenFile(path, 10, 2)
+	require.NoError(t, err)
+
+	// существующий файл дописывается, запись не разрывается между файлами
+	for _, line := range []string{"aaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, "dddddd\n", read(path))
+	require.Equal(t, "cccccc\n", read(path+".1"))
+	require.Equal(t, "bbbbbb\n", read(path+".2"))
+	// самый старый файл удален
+	require.NoFileExists(t, path+".3")
+
+	_, err = f.Write([]byte("x"))
+	require.Error(t, err)
+
+	_, err = logger.OpenFile(path, 0, 1)
+	require.Error(t, err)
+}